@@ -0,0 +1,144 @@
+package kingpin
+
+import (
+	"reflect"
+	"time"
+)
+
+// Typed accessors for the values of flags and arguments in scope, for use by
+// validators and actions, eg.
+//
+//     deploy.ValidateContext(func(ctx *kingpin.ParseContext) error {
+//         if ctx.GetInt("replicas") > 1 && ctx.GetString("cluster") == "" {
+//             return errors.New("--replicas requires --cluster")
+//         }
+//         return nil
+//     })
+//
+// Each returns the zero value if there is no flag or argument with the name,
+// if it holds a different type, or if it is an unset Optional() value.
+
+// GetString returns the value of a string flag or argument.
+func (p *ParseContext) GetString(name string) string {
+	v, _ := p.typedValue(name).(string)
+	return v
+}
+
+// GetStrings returns the values of a repeatable string flag or argument.
+func (p *ParseContext) GetStrings(name string) []string {
+	v, _ := p.typedValue(name).([]string)
+	return v
+}
+
+// GetBool returns the value of a boolean flag or argument.
+func (p *ParseContext) GetBool(name string) bool {
+	v, _ := p.typedValue(name).(bool)
+	return v
+}
+
+// GetInt returns the value of an int flag or argument.
+func (p *ParseContext) GetInt(name string) int {
+	v, _ := p.typedValue(name).(int)
+	return v
+}
+
+// GetInt64 returns the value of an int64 flag or argument.
+func (p *ParseContext) GetInt64(name string) int64 {
+	v, _ := p.typedValue(name).(int64)
+	return v
+}
+
+// GetFloat64 returns the value of a float64 flag or argument.
+func (p *ParseContext) GetFloat64(name string) float64 {
+	v, _ := p.typedValue(name).(float64)
+	return v
+}
+
+// GetDuration returns the value of a duration flag or argument.
+func (p *ParseContext) GetDuration(name string) time.Duration {
+	v, _ := p.typedValue(name).(time.Duration)
+	return v
+}
+
+// FlagValue returns the last value given on the command line for the named
+// flag, or "" if it wasn't given. Boolean flags have the value "true" or
+// "false". See Value() for the value resolved from all sources.
+func (p *ParseContext) FlagValue(name string) string {
+	values := p.FlagValues(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// FlagValues returns each value given on the command line for the named flag,
+// in order.
+func (p *ParseContext) FlagValues(name string) []string {
+	values := []string{}
+	for _, element := range p.Elements {
+		if flag, ok := element.Clause.(*FlagClause); ok && flag.name == name && element.Value != nil {
+			values = append(values, *element.Value)
+		}
+	}
+	return values
+}
+
+// ArgValue returns the first value given on the command line for the named
+// argument, or "" if it wasn't given.
+func (p *ParseContext) ArgValue(name string) string {
+	values := p.ArgValues(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// ArgValues returns each value given on the command line for the named
+// argument, in order. Only repeatable arguments take more than one.
+func (p *ParseContext) ArgValues(name string) []string {
+	values := []string{}
+	for _, element := range p.Elements {
+		if arg, ok := element.Clause.(*ArgClause); ok && arg.name == name && element.Value != nil {
+			values = append(values, *element.Value)
+		}
+	}
+	return values
+}
+
+// SelectedCommands returns the selected command and its parents, outermost
+// first, eg. for "app user add" the commands "user" and "user add".
+// SelectedCommand is the last of these.
+func (p *ParseContext) SelectedCommands() []*Cmd {
+	commands := []*Cmd{}
+	for _, element := range p.Elements {
+		if cmd, ok := element.Clause.(*Cmd); ok {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+// The value of the named flag or argument, dereferencing repeatable and
+// Optional() values.
+func (p *ParseContext) typedValue(name string) interface{} {
+	_, value := p.lookup(name)
+	switch value.(type) {
+	case *accumulator, *optionalValue:
+		// Pointers to the slice or the optional value.
+		if v := reflect.ValueOf(p.Value(name)); v.IsValid() && !v.IsNil() {
+			return v.Elem().Interface()
+		}
+		return nil
+	}
+	return p.Value(name)
+}
+
+// ValidateContext adds a validation function to run after parsing when the
+// command is selected, once values from the command line, environment and
+// defaults have been resolved. Flags and arguments in scope can be looked up
+// by name with the typed accessors of ParseContext, such as GetString(), rather
+// than captured in closures. See also Application.ValidateContext().
+func (c *Cmd) ValidateContext(validator ContextValidator) *Cmd {
+	c.ctxValidators = append(c.ctxValidators, validator)
+	return c
+}
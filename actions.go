@@ -1,10 +1,45 @@
 package kingpin
 
+import "context"
+
 // Action callback executed at various stages after all values are populated.
 // The application, commands, arguments and flags all have corresponding
 // actions.
+//
+// Actions run in the following order, stopping at the first error:
+//
+//     1. Application pre-actions.
+//     2. Pre-actions of the selected commands, outermost first.
+//     3. Pre-actions of the flags and arguments that were given, in order.
+//     4. Application actions.
+//     5. Actions of the flags and arguments that were given, in order.
+//     6. Actions of the selected commands, outermost first.
+//
+// Steps 4 to 6 are wrapped in any Middleware added with Application.Use().
+//
+// Post-actions then run whether or not an earlier stage failed, and are all
+// run even if one of them fails, making them suitable for cleanup:
+//
+//     7. Post-actions of the flags and arguments that were given, in order.
+//     8. Post-actions of the selected commands, innermost first.
+//     9. Application post-actions.
+//
+// The error from the earlier stages, if any, is available to post-actions
+// from ParseContext.ActionError().
 type Action func(*ParseContext) error
 
+// Middleware wraps the actions run by a parse. It is passed the next Action
+// in the chain, and returns an Action that should normally call it. See
+// Application.Use().
+type Middleware func(next Action) Action
+
+// Adapt an action that takes a context.Context to an Action.
+func withContext(action func(ctx context.Context, pc *ParseContext) error) Action {
+	return func(pc *ParseContext) error {
+		return action(pc.Context(), pc)
+	}
+}
+
 type actionMixin struct {
 	actions     []Action
 	preActions  []Action
@@ -14,6 +49,7 @@ type actionMixin struct {
 type actionApplier interface {
 	applyActions(*ParseContext) error
 	applyPreActions(*ParseContext) error
+	applyPostActions(*ParseContext) error
 }
 
 func (a *actionMixin) addAction(action Action) {
@@ -46,11 +82,12 @@ func (a *actionMixin) applyPreActions(context *ParseContext) error {
 	return nil
 }
 
-func (a *actionMixin) applyPostActions(context *ParseContext) error {
-	for _, postActions := range a.postActions {
-		if err := postActions(context); err != nil {
-			return err
+// Run every post-action, returning the first error.
+func (a *actionMixin) applyPostActions(context *ParseContext) (err error) {
+	for _, postAction := range a.postActions {
+		if perr := postAction(context); perr != nil && err == nil {
+			err = perr
 		}
 	}
-	return nil
+	return err
 }
@@ -0,0 +1,91 @@
+package kingpin
+
+import (
+	"strings"
+)
+
+// Aliases adds user-defined command aliases, in the style of git, eg.
+//
+//     app.Aliases(map[string][]string{
+//         "co": {"checkout", "--force"},
+//     })
+//
+// If the first command word on the command line is an alias it is replaced by
+// its expansion before parsing, so "app co main" is parsed as
+// "app checkout --force main". An expansion may itself start with an alias.
+// Aliases never shadow the application's own commands. Errors in an aliased
+// command line note the alias they came from.
+//
+// Aliases are typically loaded from user configuration.
+func (a *Application) Aliases(aliases map[string][]string) *Application {
+	if a.aliases == nil {
+		a.aliases = map[string][]string{}
+	}
+	for name, expansion := range aliases {
+		a.aliases[name] = expansion
+	}
+	return a
+}
+
+// Expand an alias in the command position of args, returning the expanded
+// args and the alias used, if any.
+func (a *Application) expandAliases(args []string) (expanded []string, alias string, err error) {
+	if len(a.aliases) == 0 {
+		return args, "", nil
+	}
+	i := a.commandPosition(args)
+	if i < 0 {
+		return args, "", nil
+	}
+	seen := map[string]bool{}
+	for {
+		name := args[i]
+		expansion, ok := a.aliases[name]
+		if !ok || a.GetCommand(name) != nil {
+			return args, alias, nil
+		}
+		if seen[name] {
+			return nil, alias, errorf(MsgAliasLoop, alias)
+		}
+		seen[name] = true
+		if alias == "" {
+			alias = name
+		}
+		out := append(append(append([]string{}, args[:i]...), expansion...), args[i+1:]...)
+		if len(expansion) == 0 || strings.HasPrefix(expansion[0], "-") {
+			return out, alias, nil
+		}
+		args = out
+	}
+}
+
+// The index of the first command word in args, skipping leading application
+// flags and their values, or -1 if there is none.
+func (a *Application) commandPosition(args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return -1
+		case strings.HasPrefix(arg, "--"):
+			if flag, ok := a.flagGroup.long[arg[2:]]; ok && !isBoolValue(flag.value) {
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			if flag, ok := a.flagGroup.short[arg[len(arg)-1:]]; ok && !isBoolValue(flag.value) {
+				i++
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// Note the alias a command line was expanded from in err.
+func (p *ParseContext) aliasHint(err error) error {
+	if e, ok := err.(*Error); ok && p != nil && p.alias != "" && e.Hint == "" {
+		e.Hint = "'" + p.alias + "' is an alias for '" + strings.Join(p.app.aliases[p.alias], " ") + "'"
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package kingpin
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func aliasApp() (*Application, *bool, *string) {
+	app := newTestApp()
+	app.Flag("config", "").String()
+	checkout := app.Command("checkout", "")
+	force := checkout.Flag("force", "").Bool()
+	branch := checkout.Arg("branch", "").Required().String()
+	app.Aliases(map[string][]string{
+		"co":       {"checkout", "--force"},
+		"cm":       {"co", "main"},
+		"checkout": {"nope"},
+		"loop":     {"again"},
+		"again":    {"loop"},
+	})
+	return app, force, branch
+}
+
+func TestAliases(t *testing.T) {
+	app, force, branch := aliasApp()
+	command, err := app.Parse([]string{"--config", "co", "cm"})
+	assert.NoError(t, err)
+	assert.Equal(t, "checkout", command)
+	assert.True(t, *force)
+	assert.Equal(t, "main", *branch)
+
+	app, force, _ = aliasApp()
+	_, err = app.Parse([]string{"checkout", "dev"})
+	assert.NoError(t, err)
+	assert.False(t, *force)
+}
+
+func TestAliasErrors(t *testing.T) {
+	app, _, _ := aliasApp()
+	_, err := app.Parse([]string{"co"})
+	assert.EqualError(t, err, "required argument 'branch' not provided")
+	assert.Equal(t, "'co' is an alias for 'checkout --force'", err.(*Error).Hint)
+
+	_, err = app.Parse([]string{"loop"})
+	assert.EqualError(t, err, "alias 'loop' expands to itself")
+}
@@ -1,11 +1,15 @@
 package kingpin
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
+	"reflect"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 var (
@@ -18,11 +22,23 @@ var (
 
 type ApplicationValidator func(*Application) error
 
+// An ErrorHandler maps an error returned by parsing, validation or an action
+// to the exit status of the application and the message to report for it.
+// An empty message reports nothing. See Application.ErrorHandler().
+type ErrorHandler func(err error) (exitCode int, message string)
+
+// ContextValidator validates the result of a parse as a whole.
+type ContextValidator func(*ParseContext) error
+
 // An Application contains the definitions of flags, arguments and commands
 // for an application.
 type Application struct {
 	cmdMixin
 	initialized bool
+	initLock    sync.Mutex // Guards init() so that parsing is concurrency-safe.
+	serveLock   sync.Mutex // Serialises Handler() requests, which share parsed values.
+	valuesLock  sync.Mutex // Serialises setting flag and argument values.
+	savedValues []savedValue
 
 	Name string
 	Help string
@@ -31,12 +47,56 @@ type Application struct {
 	version        string
 	errorWriter    io.Writer // Destination for errors.
 	usageWriter    io.Writer // Destination for usage
+	outputWriter   io.Writer // Destination for ParseContext.Print()
+	stdin          io.Reader // Source for "--flags-json=-" and prompts
+	stdinLines     *bufio.Reader
 	usageTemplate  string
 	validator      ApplicationValidator
+	ctxValidators  []ContextValidator
 	terminate      func(status int) // See Terminate()
 	noInterspersed bool             // can flags be interspersed with args (or must they come first)
+	windowsFlags   bool             // See AllowWindowsFlags()
+	abbreviations  bool             // See AbbreviatedFlags()
+	errorHandler   ErrorHandler
 	defaultEnvars  bool
 	completion     bool
+	completionDesc bool
+	completionOpts CompletionOptions // See CompletionOptions()
+	argv0          string            // See DispatchOnArgv0()
+	profiles       map[string]map[string][]string
+	profile        string
+	formatters     map[string]Formatter
+	messages       Messages
+	numberFormat   *NumberFormat
+	colorMode      ColorMode
+	theme          *Theme   // See HelpTheme()
+	terminalWidth  int      // See Terminal()
+	pagedHelp      bool     // See PagedHelp()
+	expandDefaults bool     // See ExpandDefaults()
+	dotEnv         []string // See DotEnv()
+	resolvers      []Resolver
+	printConfig    *FlagClause // See PrintConfigFlag()
+	explain        *FlagClause // See EnableExplain()
+	redactor       Redactor
+	redefinition   RedefinitionPolicy
+	missingCommand MissingCommandPolicy
+	quiet          bool // See VerbosityFlags()
+	verbose        int
+	output         string              // Format selected by OutputFlag()
+	jsonValues     map[string][]string // See ParseJSON()
+	serveCommand   *Cmd                // See ServeCommand()
+	man            manMetadata
+	configFiles    []string // See ConfigFile()
+	promptMissing  bool     // See PromptMissing()
+	aliases        map[string][]string
+	externalPrefix string   // See ExternalCommands()
+	categoryOrder  []string // See CategoryOrder()
+	middleware     []Middleware
+	providers      map[reflect.Type]reflect.Value
+	provideErrs    []error
+	helpTopics     []HelpTopic  // See HelpTopic()
+	versionInfo    *VersionInfo // See VersionInfo()
+	versionTmpl    string       // See VersionTemplate()
 
 	// Help flag. Exposed for user customisation.
 	HelpFlag *FlagClause
@@ -44,6 +104,17 @@ type Application struct {
 	HelpCommand *Cmd
 	// Version flag. Exposed for user customisation. May be nil.
 	VersionFlag *FlagClause
+	// Version command, added by VersionInfo(). May be nil.
+	VersionCommand *Cmd
+	// Profile flag. Exposed for user customisation. Nil until a profile is defined.
+	ProfileFlag *FlagClause
+	// Quiet and verbose flags. Exposed for user customisation. Nil unless
+	// VerbosityFlags() is called.
+	QuietFlag   *FlagClause
+	VerboseFlag *FlagClause
+	// Flag values JSON flag. Exposed for user customisation. Nil unless
+	// FlagsJSONFlag() is called.
+	FlagsJSON *FlagClause
 }
 
 // New creates a new Kingpin application instance.
@@ -53,44 +124,53 @@ func New(name, help string) *Application {
 		Help:          help,
 		errorWriter:   os.Stderr, // Left for backwards compatibility purposes.
 		usageWriter:   os.Stderr,
+		outputWriter:  os.Stdout,
+		stdin:         os.Stdin,
 		usageTemplate: DefaultUsageTemplate,
 		terminate:     os.Exit,
 	}
 	a.flagGroup = newFlagGroup()
 	a.argGroup = newArgGroup()
 	a.cmdGroup = newCmdGroup(a)
-	a.HelpFlag = a.Flag("help", "Output usage information.").Short('h')
+	a.HelpFlag = a.builtinFlag("help", "Output usage information.").Short('h')
 	a.HelpFlag.Bool()
-	a.Flag("help-long", "Generate long help.").Hidden().PreAction(a.generateLongHelp).Bool()
-	a.Flag("help-man", "Generate a man page.").Hidden().PreAction(a.generateManPage).Bool()
-	a.Flag("completion-bash", "Output possible completions for the given args.").Hidden().BoolVar(&a.completion)
-	a.Flag("completion-script-bash", "Generate completion script for bash.").Hidden().PreAction(a.generateBashCompletionScript).Bool()
-	a.Flag("completion-script-zsh", "Generate completion script for ZSH.").Hidden().PreAction(a.generateZSHCompletionScript).Bool()
+	a.builtinFlag("help-long", "Generate long help.").Hidden().PreAction(a.generateLongHelp).Bool()
+	a.builtinFlag("help-all", "Generate long help, including all advanced flags and commands.").Hidden().PreAction(a.generateAllHelp).Bool()
+	a.builtinFlag("help-man", "Generate a man page.").Hidden().PreAction(a.generateManPage).Bool()
+	a.builtinFlag("help-values", "Generate help showing the resolved value and source of each flag.").Hidden().PreAction(a.generateValuesHelp).Bool()
+	a.builtinFlag("help-json", "Generate a JSON description of the application.").Hidden().PreAction(a.generateHelpJSON).Bool()
+	a.builtinFlag("completion-bash", "Output possible completions for the given args.").Hidden().BoolVar(&a.completion)
+	a.builtinFlag("completion-descriptions", "Include descriptions in completions, separated by a tab.").Hidden().BoolVar(&a.completionDesc)
+	a.builtinFlag("completion-script-bash", "Generate completion script for bash.").Hidden().PreAction(a.generateBashCompletionScript).Bool()
+	a.builtinFlag("completion-script-zsh", "Generate completion script for ZSH.").Hidden().PreAction(a.generateZSHCompletionScript).Bool()
+	a.builtinFlag("completion-script-fish", "Generate completion script for fish.").Hidden().PreAction(a.generateFishCompletionScript).Bool()
+	a.builtinFlag("completion-script-powershell", "Generate completion script for PowerShell.").Hidden().PreAction(a.generatePowerShellCompletionScript).Bool()
+	a.builtinFlag("debug-flags", "Print the resolved value and source of every flag.").Hidden().PreAction(a.debugFlags).Bool()
 
 	return a
 }
 
 func (a *Application) generateLongHelp(c *ParseContext) error {
-	a.Writer(os.Stdout)
-	if err := a.UsageForContextWithTemplate(c, 2, LongHelpTemplate); err != nil {
+	if c.helpLevel < HelpLevelLong {
+		c.helpLevel = HelpLevelLong
+	}
+	if err := a.renderUsage(a.outputWriter, c, 2, LongHelpTemplate); err != nil {
 		return err
 	}
 	a.terminate(0)
 	return nil
 }
 
-func (a *Application) generateManPage(c *ParseContext) error {
-	a.Writer(os.Stdout)
-	if err := a.UsageForContextWithTemplate(c, 2, ManPageTemplate); err != nil {
+func (a *Application) generateBashCompletionScript(c *ParseContext) error {
+	if err := a.renderUsage(a.outputWriter, c, 2, BashCompletionTemplate); err != nil {
 		return err
 	}
 	a.terminate(0)
 	return nil
 }
 
-func (a *Application) generateBashCompletionScript(c *ParseContext) error {
-	a.Writer(os.Stdout)
-	if err := a.UsageForContextWithTemplate(c, 2, BashCompletionTemplate); err != nil {
+func (a *Application) generatePowerShellCompletionScript(c *ParseContext) error {
+	if err := a.renderUsage(a.outputWriter, c, 2, PowerShellCompletionTemplate); err != nil {
 		return err
 	}
 	a.terminate(0)
@@ -98,14 +178,21 @@ func (a *Application) generateBashCompletionScript(c *ParseContext) error {
 }
 
 func (a *Application) generateZSHCompletionScript(c *ParseContext) error {
-	a.Writer(os.Stdout)
-	if err := a.UsageForContextWithTemplate(c, 2, ZshCompletionTemplate); err != nil {
+	if err := a.renderUsage(a.outputWriter, c, 2, ZshCompletionTemplate); err != nil {
 		return err
 	}
 	a.terminate(0)
 	return nil
 }
 
+// Define a flag that controls kingpin itself, such as --help, rather than the
+// application. Such flags can't be set through Handler().
+func (a *Application) builtinFlag(name, help string) *FlagClause {
+	flag := a.Flag(name, help)
+	flag.builtin = true
+	return flag
+}
+
 // DefaultEnvars configures all flags (that do not already have an associated
 // envar) to use a default environment variable in the form "<app>_<flag>".
 //
@@ -146,6 +233,26 @@ func (a *Application) UsageWriter(w io.Writer) *Application {
 	return a
 }
 
+// Terminal sets the width, in columns, that help is wrapped to. By default the
+// width is taken from the COLUMNS environment variable or the terminal usage
+// is written to, falling back to 80 columns.
+func (a *Application) Terminal(width int) *Application {
+	a.terminalWidth = width
+	return a
+}
+
+// Stdout sets the io.Writer used for regular output.
+// DEPRECATED: See OutputWriter.
+func (a *Application) Stdout(w io.Writer) *Application {
+	return a.OutputWriter(w)
+}
+
+// Stderr sets the io.Writer used for usage, errors and warnings.
+// DEPRECATED: See ErrorWriter and UsageWriter.
+func (a *Application) Stderr(w io.Writer) *Application {
+	return a.ErrorWriter(w).UsageWriter(w)
+}
+
 // UsageTemplate specifies the text template to use when displaying usage
 // information. The default is UsageTemplate.
 func (a *Application) UsageTemplate(template string) *Application {
@@ -153,6 +260,15 @@ func (a *Application) UsageTemplate(template string) *Application {
 	return a
 }
 
+// ValidateContext adds a validation function to run after parsing, once the
+// selected command's validators have passed. Use it for constraints spanning
+// global and command flags, which can be looked up with ParseContext.Value()
+// and ParseContext.IsSet().
+func (a *Application) ValidateContext(validator ContextValidator) *Application {
+	a.ctxValidators = append(a.ctxValidators, validator)
+	return a
+}
+
 // Validate sets a validation function to run when parsing.
 func (a *Application) Validate(validator ApplicationValidator) *Application {
 	a.validator = validator
@@ -160,9 +276,51 @@ func (a *Application) Validate(validator ApplicationValidator) *Application {
 }
 
 // ParseContext parses the given command line and returns the fully populated
-// ParseContext.
+// ParseContext. Flag and argument values are not set, and no actions are
+// run, until the context is passed to Execute().
+//
+// ParseContext does not modify the application, so it may be called
+// concurrently, eg. to inspect command lines received by a server.
 func (a *Application) ParseContext(args []string) (*ParseContext, error) {
-	return a.parseContext(false, args)
+	if err := a.init(); err != nil {
+		return nil, err
+	}
+	args, alias, err := a.expandAliases(a.dispatchArgs(args))
+	if err != nil {
+		return nil, a.localize(err)
+	}
+	context, err := a.parseContext(false, args)
+	if context != nil {
+		context.alias = alias
+		context.parseErr = a.localize(context.aliasHint(err))
+	}
+	return context, a.localize(context.aliasHint(err))
+}
+
+// Execute completes parsing of a context returned by ParseContext(): it sets
+// flag and argument values, then handles help, validates and runs actions as
+// Parse() does. This allows the application to examine the command line
+// before anything is run, eg.
+//
+//     verbose := app.Flag("verbose", "Verbose logging.")
+//     verbose.Bool()
+//
+//     context, err := app.ParseContext(os.Args[1:])
+//     app.FatalIfError(err, "")
+//     for _, element := range context.Elements {
+//         if element.Clause == verbose {
+//             log.SetLevel(log.DebugLevel)
+//         }
+//     }
+//     command, err := app.Execute(context)
+//
+// A context may only be executed once.
+func (a *Application) Execute(context *ParseContext) (command string, err error) {
+	if err := a.init(); err != nil {
+		return "", err
+	}
+	command, err = a.run(context)
+	return command, a.localize(err)
 }
 
 func (a *Application) parseContext(ignoreDefault bool, args []string) (*ParseContext, error) {
@@ -170,6 +328,7 @@ func (a *Application) parseContext(ignoreDefault bool, args []string) (*ParseCon
 		return nil, err
 	}
 	context := tokenize(args, ignoreDefault)
+	context.app = a
 	err := parse(context, a)
 	return context, err
 }
@@ -179,30 +338,72 @@ func (a *Application) parseContext(ignoreDefault bool, args []string) (*ParseCon
 // subcommands have been configured.
 //
 // This will populate all flag and argument values, call all callbacks, and so
-// on.
+// on. Every flag and argument is first reset to its value before the first
+// parse, so values do not carry over between calls.
+//
+// Parse may be called concurrently: values are set one parse at a time and
+// recorded on the ParseContext passed to actions, whose accessors such as
+// GetString() return the values of that parse. The variables bound to flags
+// and arguments hold the values of the most recent parse, so concurrent
+// actions should not read them.
 func (a *Application) Parse(args []string) (command string, err error) {
+	return a.ParseWithContext(context.Background(), args)
+}
 
-	context, parseErr := a.ParseContext(args)
-	selected := []string{}
-	var setValuesErr error
+// ParseWithContext is like Parse(), but makes ctx available to actions, so
+// that long-running actions can respect its cancellation and deadline. See
+// ActionCtx() and ParseContext.Context().
+func (a *Application) ParseWithContext(ctx context.Context, args []string) (command string, err error) {
+	if handled, err := a.runEntryPoint(args); handled {
+		return "", a.localize(err)
+	}
+	command, err = a.parse(ctx, args)
+	return command, a.localize(err)
+}
 
+func (a *Application) parse(ctx context.Context, args []string) (command string, err error) {
+	if err := a.init(); err != nil {
+		return "", err
+	}
+	if command, ok, err := a.runExternalCommand(ctx, args); ok {
+		return command, err
+	}
+	context, parseErr := a.ParseContext(args)
 	if context == nil {
 		// Since we do not throw error immediately, there could be a case
 		// where a context returns nil. Protect against that.
 		return "", parseErr
 	}
+	context.ctx = ctx
+	return a.run(context)
+}
 
-	if err := a.setDefaults(context); err != nil {
+// Set values, then run actions for a parsed context.
+func (a *Application) run(context *ParseContext) (command string, err error) {
+	parseErr := context.parseErr
+	selected := []string{}
+	var setValuesErr error
+
+	defer func() { err = context.aliasHint(err) }()
+
+	if selected, setValuesErr, err = a.resolveValues(context); err != nil {
 		return "", err
 	}
+	completion := a.completing(context)
 
-	selected, setValuesErr = a.setValues(context)
+	if !completion && parseErr == nil && setValuesErr == nil && a.explaining(context) {
+		if err := context.WriteExplanation(a.outputWriter); err != nil {
+			return "", err
+		}
+		a.terminate(0)
+		return "", nil
+	}
 
-	if err := a.applyPreActions(context, !a.completion); err != nil {
+	if err := a.applyPreActions(context, !completion); err != nil {
 		return "", err
 	}
 
-	if a.completion {
+	if completion {
 		a.generateBashCompletion(context)
 		a.terminate(0)
 	} else {
@@ -212,19 +413,23 @@ func (a *Application) Parse(args []string) (command string, err error) {
 
 		a.maybeHelp(context)
 		if !context.EOL() {
-			return "", fmt.Errorf("unexpected argument '%s'", context.Peek())
+			return "", errorf(MsgUnexpectedArgument, context.Peek())
 		}
+		a.maybeMissingCommand(context)
 
 		if setValuesErr != nil {
 			return "", setValuesErr
 		}
 
+		a.warnDeprecated(context)
+
 		command, err = a.execute(context, selected)
 		if err == ErrCommandNotSpecified {
 			return "", nil
 		}
 	}
 
+	context.actionErr = err
 	if err := a.applyPostActions(context); err != nil {
 		return "", err
 	}
@@ -232,9 +437,35 @@ func (a *Application) Parse(args []string) (command string, err error) {
 	return command, err
 }
 
+// Reset every flag and argument, then set their values for context from the
+// command line, defaults and other sources, and record them on the context.
+// The values are shared by all parses of the application, so this is
+// serialised.
+func (a *Application) resolveValues(context *ParseContext) (selected []string, setValuesErr, err error) {
+	a.valuesLock.Lock()
+	defer a.valuesLock.Unlock()
+	a.resetValues()
+	if err := a.setDefaults(context); err != nil {
+		return nil, nil, err
+	}
+	selected, setValuesErr = a.setValues(context)
+	if setValuesErr == nil {
+		setValuesErr = a.setDerivedDefaults(context)
+	}
+	context.captureValues()
+	return selected, setValuesErr, nil
+}
+
+// Whether --completion-bash was given.
+func (a *Application) completing(context *ParseContext) bool {
+	flag := a.flagGroup.long["completion-bash"]
+	completion, _ := context.clauseValue(flag, flag.value).(bool)
+	return completion
+}
+
 func (a *Application) writeUsage(context *ParseContext, err error) {
 	if err != nil {
-		a.Errorf("%s", err)
+		a.writeErr("", err)
 	}
 	if err := a.UsageForContext(context); err != nil {
 		panic(err)
@@ -264,8 +495,13 @@ func (a *Application) GetVersion() string {
 // Version adds a --version flag for displaying the application version.
 func (a *Application) Version(version string) *Application {
 	a.version = version
-	a.VersionFlag = a.Flag("version", "Show application version.").PreAction(func(*ParseContext) error {
-		fmt.Fprintln(a.usageWriter, version)
+	if a.VersionFlag != nil {
+		return a
+	}
+	a.VersionFlag = a.builtinFlag("version", "Show application version.").PreAction(func(*ParseContext) error {
+		if err := a.writeVersion(a.usageWriter, false); err != nil {
+			return err
+		}
 		a.terminate(0)
 		return nil
 	})
@@ -282,30 +518,77 @@ func (a *Application) Author(author string) *Application {
 // Action callback to call when all values are populated and parsing is
 // complete, but before any command, flag or argument actions.
 //
-// All Action() callbacks are called in the order they are encountered on the
-// command line.
+// Flag and argument actions are called in the order they are encountered on
+// the command line, followed by those of the selected commands. See Action.
 func (a *Application) Action(action Action) *Application {
 	a.addAction(action)
 	return a
 }
 
+// Use adds middleware around the actions of the application, flags,
+// arguments and selected commands, eg. to time or log every command, or to
+// recover from panics:
+//
+//     app.Use(func(next kingpin.Action) kingpin.Action {
+//         return func(context *kingpin.ParseContext) error {
+//             start := time.Now()
+//             err := next(context)
+//             command := "(none)"
+//             if context.SelectedCommand != nil {
+//                 command = context.SelectedCommand.FullCommand()
+//             }
+//             log.Printf("%s took %s", command, time.Since(start))
+//             return err
+//         }
+//     })
+//
+// Middleware is called once per parse, after validation, and does not wrap
+// pre-actions or post-actions. The first middleware added is the outermost.
+func (a *Application) Use(middleware Middleware) *Application {
+	a.middleware = append(a.middleware, middleware)
+	return a
+}
+
+// ActionCtx is like Action(), but the callback also receives the
+// context.Context passed to ParseWithContext().
+func (a *Application) ActionCtx(action func(ctx context.Context, pc *ParseContext) error) *Application {
+	a.addAction(withContext(action))
+	return a
+}
+
 // PreAction called after parsing completes but before validation and execution.
 func (a *Application) PreAction(action Action) *Application {
 	a.addPreAction(action)
 	return a
 }
 
-// PostAction called after execution.
+// PostAction called after execution, whether or not it succeeded. See Action
+// for the order in which actions run.
 func (a *Application) PostAction(action Action) *Application {
 	a.addPostAction(action)
 	return a
 }
 
+// CategoryOrder sets the order in which command categories are listed in
+// help. Categories not listed follow in order of definition. Commands
+// without a category are always listed first. See Cmd.Category().
+func (a *Application) CategoryOrder(titles ...string) *Application {
+	a.categoryOrder = titles
+	return a
+}
+
 // Command adds a new top-level command.
 func (a *Application) Command(name, help string) *Cmd {
 	return a.addCommand(name, help)
 }
 
+// AllowArgsWithCommands allows top-level Arg()s alongside Command()s. See
+// Cmd.AllowArgsWithCommands().
+func (a *Application) AllowArgsWithCommands() *Application {
+	a.mixedArgs = true
+	return a
+}
+
 // Interspersed control if flags can be interspersed with positional arguments
 //
 // true (the default) means that they can, false means that all the flags must appear before the first positional arguments.
@@ -314,6 +597,99 @@ func (a *Application) Interspersed(interspersed bool) *Application {
 	return a
 }
 
+// AllowWindowsFlags additionally accepts flags in the Windows style, eg.
+// "/verbose", "/output:file.txt" or "/o:file.txt". "/?" is equivalent to
+// --help.
+//
+// Only words naming a known flag are treated as flags, so that arguments
+// such as "/tmp" are still accepted as positional arguments.
+func (a *Application) AllowWindowsFlags() *Application {
+	a.windowsFlags = true
+	return a
+}
+
+// AbbreviatedFlags accepts any unambiguous prefix of a long flag in place of
+// the flag, as GNU getopt does, eg. --verb for --verbose. Exact matches
+// always win, and ambiguous prefixes are reported along with the flags they
+// could refer to. Hidden flags must be given in full.
+func (a *Application) AbbreviatedFlags() *Application {
+	a.abbreviations = true
+	return a
+}
+
+// MissingCommandPolicy controls what happens when a command with subcommands
+// is selected but none of its subcommands are given.
+type MissingCommandPolicy int
+
+const (
+	// MissingCommandError fails the parse with an error (the default).
+	MissingCommandError MissingCommandPolicy = iota
+	// MissingCommandHelp prints the command's help and exits with status 0.
+	MissingCommandHelp
+	// MissingCommandUsage prints a short usage line and exits with status 2.
+	MissingCommandUsage
+)
+
+// MissingCommand sets the behaviour when a subcommand is required but not
+// given. Commands with a Default() subcommand are unaffected.
+//
+// The help and usage policies also apply when the application has commands
+// and none is given.
+func (a *Application) MissingCommand(policy MissingCommandPolicy) *Application {
+	a.missingCommand = policy
+	return a
+}
+
+// Apply the MissingCommand() policy if a subcommand is required but missing.
+func (a *Application) maybeMissingCommand(context *ParseContext) {
+	if a.missingCommand == MissingCommandError {
+		return
+	}
+	name := a.Name
+	if cmd := context.SelectedCommand; cmd != nil {
+		if !cmd.cmdGroup.have() || cmd.mixedArgs {
+			return
+		}
+		name += " " + cmd.FullCommand()
+	} else if !a.cmdGroup.have() || a.mixedArgs {
+		return
+	}
+	switch a.missingCommand {
+	case MissingCommandHelp:
+		a.writeUsage(context, nil)
+	case MissingCommandUsage:
+		fmt.Fprintf(a.usageWriter, "usage: %s <command> [<args> ...]\n", name)
+		fmt.Fprintf(a.usageWriter, "Run '%s --help' for more information.\n", name)
+		a.terminate(2)
+	}
+}
+
+// Warn about each Deprecated() flag, argument or command given on the command
+// line.
+func (a *Application) warnDeprecated(context *ParseContext) {
+	warned := map[interface{}]bool{}
+	for _, element := range context.Elements {
+		if warned[element.Clause] {
+			continue
+		}
+		warned[element.Clause] = true
+		switch clause := element.Clause.(type) {
+		case *FlagClause:
+			if clause.deprecated != "" {
+				a.warnf("flag --%s is deprecated, %s", clause.name, clause.deprecated)
+			}
+		case *ArgClause:
+			if clause.deprecated != "" {
+				a.warnf("argument '%s' is deprecated, %s", clause.name, clause.deprecated)
+			}
+		case *Cmd:
+			if clause.deprecated != "" {
+				a.warnf("command '%s' is deprecated, %s", clause.FullCommand(), clause.deprecated)
+			}
+		}
+	}
+}
+
 func (a *Application) defaultEnvarPrefix() string {
 	if a.defaultEnvars {
 		return a.Name
@@ -321,28 +697,89 @@ func (a *Application) defaultEnvarPrefix() string {
 	return ""
 }
 
+// The subcommands of the command path given to "help" so far, and help
+// topics at the top-level.
+func helpCommandHints(a *Application, arg *ArgClause, context *ParseContext) []string {
+	group := a.cmdGroup
+	for _, element := range context.Elements {
+		if element.Clause != arg || element.Value == nil || *element.Value == "" {
+			continue
+		}
+		cmd := group.GetCommand(*element.Value)
+		if cmd == nil {
+			return nil
+		}
+		group = cmd.cmdGroup
+	}
+	names := group.completionNames()
+	if group == a.cmdGroup {
+		for _, topic := range a.helpTopics {
+			names = append(names, topic.Name)
+		}
+	}
+	return names
+}
+
 func (a *Application) init() error {
+	a.initLock.Lock()
+	defer a.initLock.Unlock()
 	if a.initialized {
 		return nil
 	}
-	if a.cmdGroup.have() && a.argGroup.have() {
+	a.discoverExternalCommands()
+	if a.cmdGroup.have() && a.argGroup.have() && !a.mixedArgs {
 		return fmt.Errorf("can't mix top-level Arg()s with Command()s")
 	}
 
+	a.addVersionCommand()
+
 	// If we have subcommands, add a help command at the top-level.
 	if a.cmdGroup.have() {
 		var command []string
 		a.HelpCommand = a.Command("help", "Show help for a command.").PreAction(func(context *ParseContext) error {
-			a.Usage(command)
+			if topic := a.helpTopicFor(command); topic != nil {
+				a.writeHelpTopic(topic)
+			} else {
+				a.Usage(command)
+			}
 			a.terminate(0)
 			return nil
 		})
-		a.HelpCommand.Arg("command", "Show help for a command.").StringsVar(&command)
+		help := a.HelpCommand.Arg("command", "Show help for a command.")
+		help.HintActionCtx(func(context *ParseContext, prefix string) []string {
+			return helpCommandHints(a, help, context)
+		}).StringsVar(&command)
 		// Make help first command.
 		l := len(a.commandOrder)
 		a.commandOrder = append(a.commandOrder[l-1:l], a.commandOrder[:l-1]...)
 	}
 
+	registerErrs := []string{}
+	for _, err := range a.provideErrs {
+		registerErrs = append(registerErrs, err.Error())
+	}
+	a.eachCmdMixin(func(c *cmdMixin) {
+		for _, err := range append(append(c.registerErrs, c.structErrs...), c.injectErrs...) {
+			registerErrs = append(registerErrs, err.Error())
+		}
+		for _, fn := range c.injected {
+			if err := a.checkInjectable(fn.Type(), nil); err != nil {
+				registerErrs = append(registerErrs, "ActionInjected(): "+err.Error())
+			}
+		}
+		c.flagGroup.redefine(a.redefinition)
+		c.annotateRelations()
+	})
+	if len(registerErrs) > 0 {
+		return fmt.Errorf("%s", strings.Join(registerErrs, "; "))
+	}
+	a.applyNumberFormat()
+	a.applyExpandDefaults()
+	a.nameHintCaches()
+	if err := a.applyDotEnv(); err != nil {
+		return err
+	}
+
 	if err := a.flagGroup.init(a.defaultEnvarPrefix()); err != nil {
 		return err
 	}
@@ -363,21 +800,42 @@ func (a *Application) init() error {
 			return err
 		}
 	}
+	if err := checkConstraintNames(&a.cmdMixin, nil); err != nil {
+		return err
+	}
+	a.saveValues()
 	a.initialized = true
 	return nil
 }
 
+// Call fn for the application and each of its commands, recursively.
+func (a *Application) eachCmdMixin(fn func(c *cmdMixin)) {
+	var walk func(c *cmdMixin)
+	walk = func(c *cmdMixin) {
+		fn(c)
+		for _, cmd := range c.cmdGroup.commandOrder {
+			walk(&cmd.cmdMixin)
+		}
+	}
+	walk(&a.cmdMixin)
+}
+
 // Recursively check commands for duplicate flags.
 func checkDuplicateFlags(current *Cmd, flagGroups []*flagGroup) error {
 	// Check for duplicates.
 	for _, flags := range flagGroups {
 		for _, flag := range current.flagOrder {
+			// Override() flags may redefine an inherited flag.
+			var shadowed *FlagClause
+			if flag.override {
+				shadowed = flags.long[flag.name]
+			}
 			if flag.shorthand != 0 {
-				if _, ok := flags.short[string(flag.shorthand)]; ok {
+				if other, ok := flags.short[string(flag.shorthand)]; ok && other != shadowed {
 					return fmt.Errorf("duplicate short flag -%c", flag.shorthand)
 				}
 			}
-			if _, ok := flags.long[flag.name]; ok {
+			if _, ok := flags.long[flag.name]; ok && shadowed == nil {
 				return fmt.Errorf("duplicate long flag --%s", flag.name)
 			}
 		}
@@ -403,22 +861,55 @@ func (a *Application) execute(context *ParseContext, selected []string) (string,
 		return "", err
 	}
 
+	if err = a.finalizeValues(context); err != nil {
+		return "", err
+	}
+
 	if err = a.applyActions(context); err != nil {
 		return "", err
 	}
 
 	command := strings.Join(selected, " ")
-	if command == "" && a.cmdGroup.have() {
+	if command == "" && a.cmdGroup.have() && !a.mixedArgs {
 		return "", ErrCommandNotSpecified
 	}
 	return command, err
 }
 
+// Let path values in scope act on the values they were set to, eg. create
+// the directory of an ExistingDirOrCreate() flag. This is only done once
+// parsing and validation have succeeded, never for completion or help.
+func (a *Application) finalizeValues(context *ParseContext) error {
+	finalize := func(clause interface{}, value Value) error {
+		if p, ok := value.(*pathValue); ok {
+			path, _ := context.clauseValue(clause, value).(string)
+			return p.finalize(path)
+		}
+		return nil
+	}
+	for _, flag := range context.flags.long {
+		if err := finalize(flag, flag.value); err != nil {
+			return err
+		}
+	}
+	for _, flag := range context.shadowedFlags {
+		if err := finalize(flag, flag.value); err != nil {
+			return err
+		}
+	}
+	for _, arg := range context.arguments.args {
+		if err := finalize(arg, arg.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (a *Application) setDefaults(context *ParseContext) error {
-	flagElements := map[string]*ParseElement{}
+	flagElements := map[*FlagClause]*ParseElement{}
 	for _, element := range context.Elements {
 		if flag, ok := element.Clause.(*FlagClause); ok {
-			flagElements[flag.name] = element
+			flagElements[flag] = element
 		}
 	}
 
@@ -429,10 +920,42 @@ func (a *Application) setDefaults(context *ParseContext) error {
 		}
 	}
 
+	profile, err := a.activeProfile(context)
+	if err != nil {
+		return err
+	}
+
+	config, err := a.loadConfigFiles(context)
+	if err != nil {
+		return err
+	}
+
+	jsonValues, err := a.flagsJSONValues(context)
+	if err != nil {
+		return err
+	}
+
 	// Check required flags and set defaults.
+	chain := a.resolverChain(context, profile, config)
 	for _, flag := range context.flags.long {
-		if flagElements[flag.name] == nil {
-			if err := flag.setDefault(); err != nil {
+		if flagElements[flag] == nil {
+			if values, ok := jsonValues[flag.name]; ok {
+				context.setSource(flag, SourceJSON)
+				if err := flag.setStoredValues(values); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := a.setFlagDefault(context, flag, chain); err != nil {
+				return err
+			}
+		}
+	}
+	// Flags redefined by a command can't be given after it, so are only set
+	// if given before.
+	for _, flag := range context.shadowedFlags {
+		if flagElements[flag] == nil {
+			if err := a.setFlagDefault(context, flag, chain); err != nil {
 				return err
 			}
 		}
@@ -440,6 +963,11 @@ func (a *Application) setDefaults(context *ParseContext) error {
 
 	for _, arg := range context.arguments.args {
 		if argElements[arg.name] == nil {
+			if arg.HasEnvarValue() {
+				context.setSource(arg, SourceEnvar)
+			} else if len(arg.defaultValues) > 0 {
+				context.setSource(arg, SourceDefault)
+			}
 			if err := arg.setDefault(); err != nil {
 				return err
 			}
@@ -449,11 +977,37 @@ func (a *Application) setDefaults(context *ParseContext) error {
 	return nil
 }
 
+// Set a flag that was not provided on the command line from the first source
+// in chain that has a value for it, or its default. See Resolvers().
+func (a *Application) setFlagDefault(context *ParseContext, flag *FlagClause, chain []resolverStep) error {
+	for _, step := range chain {
+		if values, ok := step.resolve(flag); ok {
+			context.setSource(flag, step.source)
+			if step.source == SourceEnvar {
+				return flag.setValues(values)
+			}
+			return flag.setStoredValues(values)
+		}
+	}
+	if flag.defaultFrom != nil {
+		// Resolved by setDerivedDefaults() once other values are known.
+		return nil
+	}
+	if len(flag.defaultValues) > 0 {
+		context.setSource(flag, SourceDefault)
+	}
+	return flag.setDefault()
+}
+
 func (a *Application) validateRequired(context *ParseContext) error {
-	flagElements := map[string]*ParseElement{}
+	// Prompted values are set like any other, see resolveValues().
+	a.valuesLock.Lock()
+	defer a.valuesLock.Unlock()
+
+	flagElements := map[*FlagClause]*ParseElement{}
 	for _, element := range context.Elements {
 		if flag, ok := element.Clause.(*FlagClause); ok {
-			flagElements[flag.name] = element
+			flagElements[flag] = element
 		}
 	}
 
@@ -466,10 +1020,15 @@ func (a *Application) validateRequired(context *ParseContext) error {
 
 	// Check required flags and set defaults.
 	for _, flag := range context.flags.long {
-		if flagElements[flag.name] == nil {
+		if flagElements[flag] == nil {
 			// Check required flags were provided.
-			if flag.needsValue() {
-				return fmt.Errorf("required flag --%s not provided", flag.name)
+			if flag.needsValue() && context.sourceOf(flag) == SourceNone {
+				if a.promptRequired("--"+flag.name, flag.help, flag.value, flag.secret, flag.setValue) {
+					context.setSource(flag, SourcePrompt)
+					context.values[flag] = capturedValue(flag.value)
+					continue
+				}
+				return errorf(MsgRequiredFlag, flag.name)
 			}
 		}
 	}
@@ -477,7 +1036,12 @@ func (a *Application) validateRequired(context *ParseContext) error {
 	for _, arg := range context.arguments.args {
 		if argElements[arg.name] == nil {
 			if arg.needsValue() {
-				return fmt.Errorf("required argument '%s' not provided", arg.name)
+				if a.promptRequired(arg.name, arg.help, arg.value, false, arg.setValue) {
+					context.setSource(arg, SourcePrompt)
+					context.values[arg] = capturedValue(arg.value)
+					continue
+				}
+				return errorf(MsgRequiredArgument, arg.name)
 			}
 		}
 	}
@@ -488,25 +1052,27 @@ func (a *Application) setValues(context *ParseContext) (selected []string, err e
 	// Set all arg and flag values.
 	var (
 		lastCmd *Cmd
-		flagSet = map[string]struct{}{}
+		flagSet = map[*FlagClause]struct{}{}
 	)
 	for _, element := range context.Elements {
 		switch clause := element.Clause.(type) {
 		case *FlagClause:
-			if _, ok := flagSet[clause.name]; ok {
+			if _, ok := flagSet[clause]; ok {
 				if v, ok := clause.value.(repeatableFlag); !ok || !v.IsCumulative() {
-					return nil, fmt.Errorf("flag '%s' cannot be repeated", clause.name)
+					return nil, errorf(MsgRepeatedFlag, clause.name)
 				}
 			}
-			if err = clause.value.Set(*element.Value); err != nil {
+			if err = clause.setValue(*element.Value); err != nil {
 				return
 			}
-			flagSet[clause.name] = struct{}{}
+			flagSet[clause] = struct{}{}
+			context.setSource(clause, SourceArgs)
 
 		case *ArgClause:
-			if err = clause.value.Set(*element.Value); err != nil {
+			if err = clause.setValue(*element.Value); err != nil {
 				return
 			}
+			context.setSource(clause, SourceArgs)
 
 		case *Cmd:
 			if clause.validator != nil {
@@ -519,8 +1085,12 @@ func (a *Application) setValues(context *ParseContext) (selected []string, err e
 		}
 	}
 
-	if lastCmd != nil && len(lastCmd.commands) > 0 {
-		return nil, fmt.Errorf("must select a subcommand of '%s'", lastCmd.FullCommand())
+	for target, flags := range context.unknownFlags {
+		*target = append(*target, flags...)
+	}
+
+	if lastCmd != nil && len(lastCmd.commands) > 0 && !lastCmd.mixedArgs {
+		return nil, errorf(MsgSubcommandRequired, lastCmd.FullCommand())
 	}
 
 	return
@@ -535,11 +1105,49 @@ func (a *Application) applyValidators(context *ParseContext) (err error) {
 			}
 		}
 	}
+	for _, element := range context.Elements {
+		if cmd, ok := element.Clause.(*Cmd); ok {
+			for _, validator := range cmd.ctxValidators {
+				if err = validator(context); err != nil {
+					return err
+				}
+			}
+		}
+	}
 
 	if a.validator != nil {
-		err = a.validator(a)
+		if err = a.validator(a); err != nil {
+			return err
+		}
 	}
-	return err
+
+	if err = a.checkConstraints(context); err != nil {
+		return err
+	}
+
+	for _, validator := range a.ctxValidators {
+		if err = validator(context); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The flags and arguments, and the commands, selected by context that have
+// actions, in the order they were parsed.
+func selectedAppliers(context *ParseContext) (values, commands []actionApplier) {
+	for _, element := range context.Elements {
+		applier, ok := element.Clause.(actionApplier)
+		if !ok {
+			continue
+		}
+		if _, ok := element.Clause.(*Cmd); ok {
+			commands = append(commands, applier)
+		} else {
+			values = append(values, applier)
+		}
+	}
+	return values, commands
 }
 
 func (a *Application) applyPreActions(context *ParseContext, dispatch bool) error {
@@ -548,11 +1156,10 @@ func (a *Application) applyPreActions(context *ParseContext, dispatch bool) erro
 	}
 	// Dispatch to actions.
 	if dispatch {
-		for _, element := range context.Elements {
-			if applier, ok := element.Clause.(actionApplier); ok {
-				if err := applier.applyPreActions(context); err != nil {
-					return err
-				}
+		values, commands := selectedAppliers(context)
+		for _, applier := range append(commands, values...) {
+			if err := applier.applyPreActions(context); err != nil {
+				return err
 			}
 		}
 	}
@@ -560,24 +1167,50 @@ func (a *Application) applyPreActions(context *ParseContext, dispatch bool) erro
 	return nil
 }
 
+// Run the actions of the application and the selected flags, arguments and
+// commands, wrapped in the application's middleware.
 func (a *Application) applyActions(context *ParseContext) error {
+	run := a.runActions
+	for i := len(a.middleware) - 1; i >= 0; i-- {
+		run = a.middleware[i](run)
+	}
+	return run(context)
+}
+
+func (a *Application) runActions(context *ParseContext) error {
 	if err := a.actionMixin.applyActions(context); err != nil {
 		return err
 	}
 	// Dispatch to actions.
-	for _, element := range context.Elements {
-		if applier, ok := element.Clause.(actionApplier); ok {
-			if err := applier.applyActions(context); err != nil {
-				return err
-			}
+	values, commands := selectedAppliers(context)
+	for _, applier := range append(values, commands...) {
+		if err := applier.applyActions(context); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
+// Run the post-actions of the selected flags, arguments and commands, and
+// then of the application, returning the first error.
+func (a *Application) applyPostActions(context *ParseContext) (err error) {
+	values, commands := selectedAppliers(context)
+	appliers := values
+	for i := len(commands) - 1; i >= 0; i-- {
+		appliers = append(appliers, commands[i])
+	}
+	appliers = append(appliers, &a.actionMixin)
+	for _, applier := range appliers {
+		if perr := applier.applyPostActions(context); perr != nil && err == nil {
+			err = perr
+		}
+	}
+	return err
+}
+
 // Errorf prints an error message to w in the format "<appname>: error: <message>".
 func (a *Application) Errorf(format string, args ...interface{}) {
-	fmt.Fprintf(a.errorWriter, a.Name+": error: "+format+"\n", args...)
+	a.writeError(fmt.Sprintf(format, args...), "", "")
 }
 
 // Fatalf writes a formatted error to w then terminates with exit status 1.
@@ -608,17 +1241,52 @@ func (a *Application) FatalUsageContext(context *ParseContext, format string, ar
 
 // FatalIfError prints an error and exits if err is not nil. The error is printed
 // with the given formatted string, if any.
+//
+// If an ErrorHandler has been set, it chooses the message and exit status.
 func (a *Application) FatalIfError(err error, format string, args ...interface{}) {
 	if err != nil {
 		prefix := ""
 		if format != "" {
 			prefix = fmt.Sprintf(format, args...) + ": "
 		}
-		a.Errorf(prefix+"%s", err)
+		if a.errorHandler != nil {
+			status, message := a.errorHandler(err)
+			if message != "" {
+				fmt.Fprintln(a.errorWriter, strings.TrimSuffix(prefix+message, "\n"))
+			}
+			a.terminate(status)
+			return
+		}
+		a.writeErr(prefix, err)
 		a.terminate(1)
 	}
 }
 
+// ErrorHandler sets a function that maps errors reported by FatalIfError()
+// and MustParse() to an exit status and message, eg.
+//
+//     app.ErrorHandler(func(err error) (int, string) {
+//         if e, ok := err.(*kingpin.Error); ok {
+//             return 2, "usage error: " + e.Error()
+//         }
+//         return 1, err.Error()
+//     })
+//
+// Errors returned by Parse() are *Error for invalid command lines, and are
+// otherwise returned unchanged from validators and actions.
+func (a *Application) ErrorHandler(handler ErrorHandler) *Application {
+	a.errorHandler = handler
+	return a
+}
+
+// MustParse parses args, returning the selected command. If parsing fails the
+// error is reported with FatalIfError(), and so by the ErrorHandler if any.
+func (a *Application) MustParse(args []string) string {
+	command, err := a.Parse(args)
+	a.FatalIfError(err, "")
+	return command
+}
+
 func (a *Application) completionOptions(context *ParseContext) []string {
 	args := context.rawArgs
 
@@ -643,6 +1311,56 @@ func (a *Application) completionOptions(context *ParseContext) []string {
 		target = context.SelectedCommand.cmdMixin
 	}
 
+	// Complete the value of "--flag=value". Bash splits the word at the "=",
+	// so the flag and the value may also be separate words.
+	flagWord, flagValue, prefix := "", "", ""
+	switch {
+	case strings.HasPrefix(currArg, "--") && strings.Contains(currArg, "="):
+		parts := strings.SplitN(currArg, "=", 2)
+		flagWord, flagValue, prefix = parts[0], parts[1], parts[0]+"="
+	case currArg == "=":
+		flagWord = prevArg
+	case prevArg == "=" && len(args) > 2:
+		flagWord, flagValue = args[len(args)-3], currArg
+	}
+	if strings.HasPrefix(flagWord, "--") {
+		flag, ok := context.flags.long[flagWord[2:]]
+		if !ok {
+			return nil
+		}
+		options := []string{}
+		for _, option := range flag.resolveCompletionsFor(context, flagValue) {
+			if !isFileDirective(option) {
+				option = prefix + option
+			}
+			options = append(options, option)
+		}
+		return options
+	}
+
+	// Complete a cluster of short flags, eg. "-xv" or "-xvfVALUE".
+	if prefix, flag, value, ok := shortFlagCluster(context.flags, currArg); ok {
+		if flag != nil && value != "" {
+			options := []string{}
+			for _, option := range flag.resolveCompletionsFor(context, value) {
+				if !isFileDirective(option) {
+					option = prefix + option
+				}
+				options = append(options, option)
+			}
+			return options
+		}
+		options := []string{}
+		for _, candidate := range shortFlagCandidates(context.flags, currArg, flag) {
+			options = append(options, candidate.Value)
+		}
+		return options
+	}
+	// The value of a short flag, treated as its long form.
+	if flag := pendingFlag(context, prevArg); flag != nil && !strings.HasPrefix(prevArg, "--") {
+		prevArg = "--" + flag.name
+	}
+
 	if (currArg != "" && strings.HasPrefix(currArg, "--")) || strings.HasPrefix(prevArg, "--") {
 		// Perform completion for A flag. The last/current argument started with "-"
 		var (
@@ -662,7 +1380,7 @@ func (a *Application) completionOptions(context *ParseContext) []string {
 			flagName = currArg[2:] // Strip the "--"
 		}
 
-		options, flagMatched, valueMatched := target.FlagCompletion(flagName, flagValue)
+		options, flagMatched, valueMatched := target.flagCompletion(context, flagName, flagValue)
 		if valueMatched {
 			// Value Matched. Show cmdCompletions
 			return target.CmdCompletion(context)
@@ -670,7 +1388,7 @@ func (a *Application) completionOptions(context *ParseContext) []string {
 
 		// Add top level flags if we're not at the top level and no match was found.
 		if context.SelectedCommand != nil && !flagMatched {
-			topOptions, topFlagMatched, topValueMatched := a.FlagCompletion(flagName, flagValue)
+			topOptions, topFlagMatched, topValueMatched := a.flagCompletion(context, flagName, flagValue)
 			if topValueMatched {
 				// Value Matched. Back to cmdCompletions
 				return target.CmdCompletion(context)
@@ -687,13 +1405,54 @@ func (a *Application) completionOptions(context *ParseContext) []string {
 		return options
 	}
 
+	// The help command's argument is a command path, completed one command
+	// at a time. See helpCommandHints().
+	if help := a.HelpCommand; help != nil && context.SelectedCommand == help && len(help.argGroup.args) > 0 {
+		return help.argGroup.args[0].resolveCompletionsFor(context, "")
+	}
+
 	// Perform completion for sub commands and arguments.
 	return target.CmdCompletion(context)
 }
 
 func (a *Application) generateBashCompletion(context *ParseContext) {
+	if a.completionDesc {
+		a.generateDescribedCompletion(context)
+		return
+	}
 	options := a.completionOptions(context)
-	fmt.Printf("%s", strings.Join(options, "\n"))
+	fmt.Fprintf(a.outputWriter, "%s", strings.Join(options, "\n"))
+}
+
+// Write "value<tab>description" completions for shells that can show
+// descriptions. Unlike the bash protocol, the last argument is always the
+// word being completed, even if it is empty.
+func (a *Application) generateDescribedCompletion(context *ParseContext) {
+	words := []string{}
+	for _, arg := range context.rawArgs {
+		if arg != "--completion-bash" && arg != "--completion-descriptions" {
+			words = append(words, arg)
+		}
+	}
+	current := ""
+	if n := len(words); n > 0 {
+		words, current = words[:n-1], words[n-1]
+	}
+	for _, candidate := range a.candidates(words, current) {
+		switch candidate.Type {
+		case CandidateFiles:
+			fmt.Fprintln(a.outputWriter, completeFilesDirective+candidate.Value)
+			continue
+		case CandidateDirectories:
+			fmt.Fprintln(a.outputWriter, completeDirsDirective)
+			continue
+		}
+		if candidate.Description != "" {
+			fmt.Fprintf(a.outputWriter, "%s\t%s\n", candidate.Value, candidate.Description)
+		} else {
+			fmt.Fprintln(a.outputWriter, candidate.Value)
+		}
+	}
 }
 
 func envarTransform(name string) string {
@@ -1,12 +1,16 @@
 package kingpin
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"io/ioutil"
 
 	"github.com/tj/assert"
 
 	"sort"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 )
@@ -227,6 +231,26 @@ func TestDefaultEnvars(t *testing.T) {
 	assert.Equal(t, "SOME_APP_A_1_FLAG", f2.envar)
 }
 
+func TestPrefixedEnvar(t *testing.T) {
+	a := New("some-app", "").Terminate(nil)
+	db := a.Command("db", "").PrefixedEnvar()
+	dryRun := db.Flag("dry-run", "")
+	dryRun.Bool()
+	migrate := db.Command("migrate", "")
+	steps := migrate.Flag("steps", "")
+	steps.Int()
+	explicit := migrate.Flag("explicit", "").Envar("EXPLICIT")
+	explicit.String()
+	run := a.Command("run", "").Flag("fast", "")
+	run.Bool()
+	_, err := a.Parse([]string{"db", "migrate"})
+	assert.NoError(t, err)
+	assert.Equal(t, "SOME_APP_DB_DRY_RUN", dryRun.envar)
+	assert.Equal(t, "SOME_APP_DB_MIGRATE_STEPS", steps.envar)
+	assert.Equal(t, "EXPLICIT", explicit.envar)
+	assert.Equal(t, "", run.envar)
+}
+
 func TestBashCompletionOptionsWithEmptyApp(t *testing.T) {
 	a := newTestApp()
 	context, err := a.ParseContext([]string{"--completion-bash"})
@@ -402,3 +426,312 @@ func TestBashCompletionOptions(t *testing.T) {
 	}
 
 }
+
+func TestBashCompletionFlagSyntax(t *testing.T) {
+	a := newTestApp()
+	a.Flag("all", "").Short('a').Bool()
+	a.Flag("verbose", "").Short('v').Bool()
+	a.Flag("format", "").Short('f').Enum("json", "text")
+
+	cases := []struct {
+		Args            string
+		ExpectedOptions []string
+	}{
+		{"--completion-bash --format=", []string{"--format=json", "--format=text"}},
+		{"--completion-bash --format=j", []string{"--format=json", "--format=text"}},
+		{"--completion-bash --format =", []string{"json", "text"}},
+		{"--completion-bash --format = j", []string{"json", "text"}},
+		{"--completion-bash -v", []string{"-v", "-va", "-vf", "-vh"}},
+		{"--completion-bash -av", []string{"-av", "-avf", "-avh"}},
+		{"--completion-bash -avf", []string{"-avf"}},
+		{"--completion-bash -avfj", []string{"-avfjson", "-avftext"}},
+		{"--completion-bash -avf ", []string{"json", "text"}},
+	}
+	for _, c := range cases {
+		context, _ := a.ParseContext(strings.Split(c.Args, " "))
+		args := a.completionOptions(context)
+		sort.Strings(args)
+		assert.Equal(t, c.ExpectedOptions, args, "Input was: [%v]", c.Args)
+	}
+}
+
+func TestValidateContext(t *testing.T) {
+	app := newTestApp()
+	app.Flag("tls", "").Bool()
+	deploy := app.Command("deploy", "")
+	deploy.Flag("port", "").Default("80").Int()
+	calls := []string{}
+	deploy.Validate(func(*Cmd) error {
+		calls = append(calls, "cmd")
+		return nil
+	})
+	app.ValidateContext(func(ctx *ParseContext) error {
+		calls = append(calls, "ctx")
+		if ctx.Value("tls") == true && ctx.Value("port") == 80 {
+			return fmt.Errorf("--tls requires a --port other than 80")
+		}
+		return nil
+	})
+
+	_, err := app.Parse([]string{"--tls", "deploy"})
+	assert.EqualError(t, err, "--tls requires a --port other than 80")
+	assert.Equal(t, "cmd", calls[0])
+	assert.Equal(t, "ctx", calls[len(calls)-1])
+
+	var ctx *ParseContext
+	app.ValidateContext(func(c *ParseContext) error {
+		ctx = c
+		return nil
+	})
+	_, err = app.Parse([]string{"--tls", "deploy", "--port=443"})
+	assert.NoError(t, err)
+	assert.True(t, ctx.IsSet("port"))
+	assert.True(t, ctx.IsSet("tls"))
+	assert.Nil(t, ctx.Value("missing"))
+
+	_, err = app.Parse([]string{"--no-tls", "deploy"})
+	assert.NoError(t, err)
+	assert.False(t, ctx.IsSet("port"))
+	assert.Equal(t, 80, ctx.Value("port"))
+}
+
+func TestCmdValidateContext(t *testing.T) {
+	app := newTestApp()
+	app.Flag("cluster", "").String()
+	deploy := app.Command("deploy", "")
+	deploy.Flag("replicas", "").Default("1").Int()
+	deploy.Flag("timeout", "").Default("1m").Duration()
+	deploy.Flag("zone", "").Strings()
+	deploy.Flag("canary", "").OptionalFloat64()
+	deploy.Flag("dry-run", "").Bool()
+	deploy.Arg("service", "").String()
+	var seen []interface{}
+	deploy.ValidateContext(func(ctx *ParseContext) error {
+		seen = []interface{}{ctx.GetString("cluster"), ctx.GetInt("replicas"), ctx.GetDuration("timeout"), ctx.GetStrings("zone"), ctx.GetFloat64("canary"), ctx.GetBool("dry-run"), ctx.GetString("service"), ctx.GetInt("cluster")}
+		if ctx.GetInt("replicas") > 1 && ctx.GetString("cluster") == "" {
+			return fmt.Errorf("--replicas requires --cluster")
+		}
+		return nil
+	})
+	app.Command("other", "")
+
+	_, err := app.Parse([]string{"deploy", "--replicas=3"})
+	assert.EqualError(t, err, "--replicas requires --cluster")
+
+	_, err = app.Parse([]string{"--cluster=prod", "deploy", "--replicas=3", "--zone=a", "--canary=0.5", "--dry-run", "web"})
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"prod", 3, time.Minute, []string{"a"}, 0.5, true, "web", 0}, seen)
+
+	seen = nil
+	_, err = app.Parse([]string{"other"})
+	assert.NoError(t, err)
+	assert.Nil(t, seen)
+}
+
+func TestStdoutStderr(t *testing.T) {
+	stdout := bytes.NewBuffer(nil)
+	stderr := bytes.NewBuffer(nil)
+	app := newTestApp().Stdout(stdout).Stderr(stderr)
+	app.Command("run", "").Action(func(ctx *ParseContext) error {
+		fmt.Fprint(ctx.Stdout(), "out")
+		fmt.Fprint(ctx.Stderr(), "err")
+		return nil
+	})
+	_, err := app.Parse([]string{"run"})
+	assert.NoError(t, err)
+	assert.Equal(t, "out", stdout.String())
+	assert.Equal(t, "err", stderr.String())
+
+	stdout.Reset()
+	stderr.Reset()
+	app.Parse([]string{"--help"})
+	assert.Contains(t, stderr.String(), "Output usage information.")
+	app.Parse([]string{"--completion-script-bash"})
+	assert.Contains(t, stdout.String(), "complete")
+}
+
+func TestActionOrder(t *testing.T) {
+	app := newTestApp()
+	order := []string{}
+	record := func(name string) Action {
+		return func(*ParseContext) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	app.PreAction(record("app pre")).Action(record("app")).PostAction(func(ctx *ParseContext) error {
+		order = append(order, fmt.Sprintf("app post: %v", ctx.ActionError()))
+		return nil
+	})
+	outer := app.Command("outer", "").PreAction(record("outer pre")).Action(record("outer")).PostAction(record("outer post"))
+	inner := outer.Command("inner", "").PreAction(record("inner pre")).PostAction(record("inner post"))
+	inner.Action(func(*ParseContext) error {
+		order = append(order, "inner")
+		return fmt.Errorf("failed")
+	})
+	app.Flag("flag", "").PreAction(record("flag pre")).Action(record("flag")).PostAction(record("flag post")).Bool()
+
+	_, err := app.Parse([]string{"--flag", "outer", "inner"})
+	assert.EqualError(t, err, "failed")
+	assert.Equal(t, []string{
+		"app pre", "outer pre", "inner pre", "flag pre",
+		"app", "flag", "outer", "inner",
+		"flag post", "inner post", "outer post", "app post: failed",
+	}, order)
+}
+
+func TestParseWithContext(t *testing.T) {
+	type key struct{}
+	app := newTestApp()
+	var got interface{}
+	app.Command("run", "").ActionCtx(func(ctx context.Context, pc *ParseContext) error {
+		got = ctx.Value(key{})
+		return ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "value"))
+	_, err := app.ParseWithContext(ctx, []string{"run"})
+	assert.NoError(t, err)
+	assert.Equal(t, "value", got)
+
+	cancel()
+	_, err = app.ParseWithContext(ctx, []string{"run"})
+	assert.Equal(t, context.Canceled, err)
+
+	_, err = app.Parse([]string{"run"})
+	assert.NoError(t, err)
+	assert.Equal(t, nil, got)
+}
+
+func TestExecuteParseContext(t *testing.T) {
+	app := newTestApp()
+	verbose := app.Flag("verbose", "")
+	verboseValue := verbose.Bool()
+	ran := false
+	app.Command("run", "").Action(func(*ParseContext) error {
+		ran = true
+		return nil
+	})
+
+	context, err := app.ParseContext([]string{"--verbose", "run"})
+	assert.NoError(t, err)
+	assert.Equal(t, "run", context.SelectedCommand.FullCommand())
+	assert.Equal(t, verbose, context.Elements[0].Clause)
+	assert.False(t, *verboseValue)
+	assert.False(t, ran)
+
+	command, err := app.Execute(context)
+	assert.NoError(t, err)
+	assert.Equal(t, "run", command)
+	assert.True(t, *verboseValue)
+	assert.True(t, ran)
+
+	context, err = app.ParseContext([]string{"--nope"})
+	assert.Error(t, err)
+	_, err = app.Execute(context)
+	assert.EqualError(t, err, "unknown long flag '--nope'")
+}
+
+func TestUseMiddleware(t *testing.T) {
+	app := newTestApp()
+	order := []string{}
+	wrap := func(name string) Middleware {
+		return func(next Action) Action {
+			return func(context *ParseContext) (err error) {
+				order = append(order, name+" before")
+				defer func() {
+					if r := recover(); r != nil {
+						err = fmt.Errorf("recovered: %v", r)
+					}
+					order = append(order, name+" after")
+				}()
+				return next(context)
+			}
+		}
+	}
+	app.Use(wrap("outer")).Use(wrap("inner"))
+	app.PreAction(func(*ParseContext) error {
+		order = append(order, "pre")
+		return nil
+	})
+	app.Command("run", "").Action(func(*ParseContext) error {
+		order = append(order, "run")
+		panic("boom")
+	})
+
+	_, err := app.Parse([]string{"run"})
+	assert.EqualError(t, err, "recovered: boom")
+	assert.Equal(t, []string{"pre", "outer before", "inner before", "run", "inner after", "outer after"}, order)
+}
+
+func TestParseContextConcurrent(t *testing.T) {
+	app := newTestApp()
+	app.Flag("verbose", "").Short('v').Bool()
+	app.Command("run", "").Default().Arg("args", "").Strings()
+	app.Command("ls", "").Alias("list")
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				for args, want := range map[string]string{"-v run a b": "run", "list": "ls", "-v": "run"} {
+					context, err := app.ParseContext(strings.Fields(args))
+					if err == nil && context.SelectedCommand.FullCommand() != want {
+						err = fmt.Errorf("%q selected %q", args, context.SelectedCommand.FullCommand())
+					}
+					if err != nil {
+						errs <- err
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestParseConcurrent(t *testing.T) {
+	app := newTestApp()
+	app.Flag("verbose", "").Short('v').Bool()
+	run := app.Command("run", "")
+	run.Flag("name", "").String()
+	run.Flag("tag", "").Strings()
+	run.Arg("args", "").Strings()
+	errs := make(chan error, 8*20)
+	run.Action(func(ctx *ParseContext) error {
+		name := ctx.GetString("name")
+		if tags := ctx.GetStrings("tag"); len(tags) != 1 || tags[0] != name {
+			errs <- fmt.Errorf("--name=%s has tags %v", name, tags)
+		}
+		if args := ctx.GetStrings("args"); len(args) != 2 || args[1] != name {
+			errs <- fmt.Errorf("--name=%s has args %v", name, args)
+		}
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				name := fmt.Sprintf("%d-%d", i, j)
+				if _, err := app.Parse([]string{"-v", "run", "--name", name, "--tag", name, "a", name}); err != nil {
+					errs <- err
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -1,7 +1,10 @@
 package kingpin
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"time"
 )
 
 type argGroup struct {
@@ -38,19 +41,21 @@ func (a *argGroup) Arg(name, help string) *ArgClause {
 func (a *argGroup) init() error {
 	required := 0
 	seen := map[string]struct{}{}
-	previousArgMustBeLast := false
+	var variadic *ArgClause
 	for i, arg := range a.args {
-		if previousArgMustBeLast {
-			return fmt.Errorf("Args() can't be followed by another argument '%s'", arg.name)
+		// Arguments following a repeatable one are allocated from the end of
+		// the command line, so must each take exactly one value.
+		if variadic != nil && (arg.consumesRemainder() || !arg.required) {
+			return fmt.Errorf("Args() '%s' can only be followed by required arguments taking a single value, not '%s'", variadic.name, arg.name)
 		}
 		if arg.consumesRemainder() {
-			previousArgMustBeLast = true
+			variadic = arg
 		}
 		if _, ok := seen[arg.name]; ok {
 			return fmt.Errorf("duplicate argument '%s'", arg.name)
 		}
 		seen[arg.name] = struct{}{}
-		if arg.required && required != i {
+		if arg.required && required != i && (variadic == nil || variadic == arg) {
 			return fmt.Errorf("required arguments found after non-required")
 		}
 		if arg.required {
@@ -68,10 +73,18 @@ type ArgClause struct {
 	parserMixin
 	completionsMixin
 	envarMixin
+	transformMixin
+	validateMixin
+	occurrenceMixin
 	name          string
 	help          string
 	defaultValues []string
 	required      bool
+	hidden        bool
+	deprecated    string
+	placeholder   string
+	// Expand the environment in defaultValues, see Application.ExpandDefaults().
+	expandDefaults bool
 }
 
 func newArg(name, help string) *ArgClause {
@@ -86,28 +99,59 @@ func (a *ArgClause) setDefault() error {
 	if a.HasEnvarValue() {
 		if v, ok := a.value.(remainderArg); !ok || !v.IsCumulative() {
 			// Use the value as-is
-			return a.value.Set(a.GetEnvarValue())
+			return a.setValue(a.GetEnvarValue())
 		}
-		for _, value := range a.GetSplitEnvarValue() {
-			if err := a.value.Set(value); err != nil {
-				return err
-			}
-		}
-		return nil
+		return a.setValues(a.GetSplitEnvarValue())
+	}
+
+	return a.setStoredValues(a.defaults())
+}
+
+// The default values, expanded if enabled.
+func (a *ArgClause) defaults() []string {
+	if a.expandDefaults {
+		return expandValues(a.defaultValues)
 	}
+	return a.defaultValues
+}
 
-	if len(a.defaultValues) > 0 {
-		for _, defaultValue := range a.defaultValues {
-			if err := a.value.Set(defaultValue); err != nil {
-				return err
-			}
+// Set each of values on the argument in turn.
+func (a *ArgClause) setValues(values []string) error {
+	for _, value := range values {
+		if err := a.setValue(value); err != nil {
+			return err
 		}
-		return nil
 	}
+	return nil
+}
 
+// Set each of values, which were not typed by the user, on the argument in
+// turn.
+func (a *ArgClause) setStoredValues(values []string) error {
+	for _, value := range values {
+		if err := a.set(value, false); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Transform value then set it on the argument.
+func (a *ArgClause) setValue(value string) error {
+	return a.set(value, true)
+}
+
+func (a *ArgClause) set(value string, input bool) error {
+	value, err := a.transform(value, input)
+	if err != nil {
+		return err
+	}
+	if err := a.validate(value); err != nil {
+		return errorf(MsgInvalidArgValue, value, a.name, err)
+	}
+	return a.value.Set(value)
+}
+
 func (a *ArgClause) needsValue() bool {
 	haveDefault := len(a.defaultValues) > 0
 	return a.required && !(haveDefault || a.HasEnvarValue())
@@ -126,18 +170,133 @@ func (a *ArgClause) Required() *ArgClause {
 	return a
 }
 
+func (a *ArgClause) ExtendedDuration() (target *time.Duration) {
+	a.addHintActionBuiltin(func() []string {
+		return extendedDurationHints
+	})
+	return a.parserMixin.ExtendedDuration()
+}
+
+func (a *ArgClause) ExtendedDurationVar(target *time.Duration) {
+	a.parserMixin.ExtendedDurationVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return extendedDurationHints
+	})
+}
+
+func (a *ArgClause) IP() (target *net.IP) {
+	a.addHintActionBuiltin(func() []string {
+		return ipHints
+	})
+	return a.parserMixin.IP()
+}
+
+func (a *ArgClause) IPVar(target *net.IP) {
+	a.parserMixin.IPVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return ipHints
+	})
+}
+
+func (a *ArgClause) CIDR() (target **net.IPNet) {
+	a.addHintActionBuiltin(func() []string {
+		return cidrHints
+	})
+	return a.parserMixin.CIDR()
+}
+
+func (a *ArgClause) CIDRVar(target **net.IPNet) {
+	a.parserMixin.CIDRVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return cidrHints
+	})
+}
+
+func (a *ArgClause) HostPort() (target *string) {
+	a.addHintActionBuiltin(func() []string {
+		return hostPortHints
+	})
+	return a.parserMixin.HostPort()
+}
+
+func (a *ArgClause) HostPortVar(target *string) {
+	a.parserMixin.HostPortVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return hostPortHints
+	})
+}
+
+// PlaceHolder sets the place-holder shown for the argument in the help, in
+// place of the default "<name>".
+func (a *ArgClause) PlaceHolder(placeholder string) *ArgClause {
+	a.placeholder = placeholder
+	return a
+}
+
+// Hidden hides the argument from usage but still allows it to be used.
+func (a *ArgClause) Hidden() *ArgClause {
+	a.hidden = true
+	return a
+}
+
+// Deprecated hides the argument from usage and warns, with the given
+// message, whenever it is used, eg.
+//
+//     app.Arg("host", "").Deprecated("use --host instead").String()
+func (a *ArgClause) Deprecated(message string) *ArgClause {
+	a.hidden = true
+	a.deprecated = message
+	return a
+}
+
+// CaseInsensitive makes an Enum() or Enums() argument match its options
+// regardless of case, storing the option as it was declared.
+func (a *ArgClause) CaseInsensitive() *ArgClause {
+	a.fold = true
+	return a
+}
+
+// KeyValueSeparator sets the separator between keys and values of map
+// arguments, such as StringMap(), in place of the default of ":" or "=".
+func (a *ArgClause) KeyValueSeparator(separator string) *ArgClause {
+	a.separator = separator
+	return a
+}
+
 // Default values for this argument. They *must* be parseable by the value of the argument.
 func (a *ArgClause) Default(values ...string) *ArgClause {
 	a.defaultValues = values
 	return a
 }
 
+// Transform registers a function to normalise values before they are parsed.
+// Transforms are applied in the order they are registered.
+func (a *ArgClause) Transform(transform Transformer) *ArgClause {
+	a.addTransform(transform)
+	return a
+}
+
+// Trim removes leading and trailing white space from values.
+func (a *ArgClause) Trim() *ArgClause {
+	return a.Transform(trimTransform)
+}
+
+// ExpandEnv replaces ${var} or $var in values with environment variables.
+func (a *ArgClause) ExpandEnv() *ArgClause {
+	return a.Transform(expandEnvTransform)
+}
+
+// ToLower converts values to lower case.
+func (a *ArgClause) ToLower() *ArgClause {
+	return a.Transform(toLowerTransform)
+}
+
 // Envar overrides the default value(s) for a flag from an environment variable,
 // if it is set. Several default values can be provided by using new lines to
-// separate them.
-func (a *ArgClause) Envar(name string) *ArgClause {
-	a.envar = name
-	a.noEnvar = false
+// separate them. Fallback environment variables may follow the name, see
+// FlagClause.Envar().
+func (a *ArgClause) Envar(name string, fallbacks ...string) *ArgClause {
+	a.setEnvar(name, fallbacks)
 	return a
 }
 
@@ -145,6 +304,7 @@ func (a *ArgClause) Envar(name string) *ArgClause {
 // Most useful in conjunction with app.DefaultEnvars().
 func (a *ArgClause) NoEnvar() *ArgClause {
 	a.envar = ""
+	a.fallbacks = nil
 	a.noEnvar = true
 	return a
 }
@@ -154,17 +314,38 @@ func (a *ArgClause) Action(action Action) *ArgClause {
 	return a
 }
 
+// ActionCtx is like Action(), but the callback also receives the
+// context.Context passed to Application.ParseWithContext().
+func (a *ArgClause) ActionCtx(action func(ctx context.Context, pc *ParseContext) error) *ArgClause {
+	a.addAction(withContext(action))
+	return a
+}
+
 func (a *ArgClause) PreAction(action Action) *ArgClause {
 	a.addPreAction(action)
 	return a
 }
 
+// PostAction is called after the command's actions if the argument was given,
+// whether or not they succeeded.
+func (a *ArgClause) PostAction(action Action) *ArgClause {
+	a.addPostAction(action)
+	return a
+}
+
 // HintAction registers a HintAction (function) for the arg to provide completions
 func (a *ArgClause) HintAction(action HintAction) *ArgClause {
 	a.addHintAction(action)
 	return a
 }
 
+// HintActionCtx registers a ContextHintAction for the arg to provide
+// completions that depend on the rest of the command line.
+func (a *ArgClause) HintActionCtx(action ContextHintAction) *ArgClause {
+	a.addContextHintAction(action)
+	return a
+}
+
 // HintOptions registers any number of options for the flag to provide completions
 func (a *ArgClause) HintOptions(options ...string) *ArgClause {
 	a.addHintAction(func() []string {
@@ -173,12 +354,30 @@ func (a *ArgClause) HintOptions(options ...string) *ArgClause {
 	return a
 }
 
+// OptionsLimit sets the number of enum options listed in help before the
+// list is truncated, overriding DefaultOptionsLimit. A negative limit lists
+// every option.
+func (a *ArgClause) OptionsLimit(limit int) *ArgClause {
+	a.optionsLimit = limit
+	return a
+}
+
 func (a *ArgClause) init() error {
 	if a.required && len(a.defaultValues) > 0 {
 		return fmt.Errorf("required argument '%s' with unusable default value", a.name)
 	}
+	if a.value == nil && a.unknownType != "" {
+		return unknownTypeError(a.unknownType, "arg '"+a.name+"'")
+	}
+	if a.valueErr != nil {
+		return fmt.Errorf("arg '%s': %s", a.name, a.valueErr)
+	}
+	a.applyValueOptions()
 	if a.value == nil {
 		return fmt.Errorf("no parser defined for arg '%s'", a.name)
 	}
-	return nil
+	if _, ok := a.value.(*optionalValue); ok && len(a.defaultValues) > 0 {
+		return fmt.Errorf("optional argument '%s' with default value that would never leave it nil", a.name)
+	}
+	return a.checkOccurrences(a.value, "arg '"+a.name+"'")
 }
@@ -82,3 +82,33 @@ func TestSubcommandArgRequiredWithEnvar(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 123, *flag)
 }
+
+func TestVariadicArgFollowedByRequiredArg(t *testing.T) {
+	app := newTestApp()
+	cp := app.Command("cp", "")
+	src := cp.Arg("src", "").Required().Strings()
+	dest := cp.Arg("dest", "").Required().String()
+	force := cp.Flag("force", "").Bool()
+
+	_, err := app.Parse([]string{"cp", "a", "--force", "b", "c", "dir"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, *src)
+	assert.Equal(t, "dir", *dest)
+	assert.True(t, *force)
+
+	app = newTestApp()
+	app.Arg("src", "").Required().Strings()
+	app.Arg("dest", "").Required().String()
+	_, err = app.Parse([]string{"a"})
+	assert.EqualError(t, err, "required argument 'dest' not provided")
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "required argument 'src' not provided")
+}
+
+func TestVariadicArgFollowedByOptionalArgErrors(t *testing.T) {
+	app := newTestApp()
+	app.Arg("src", "").Strings()
+	app.Arg("dest", "").String()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "Args() 'src' can only be followed by required arguments taking a single value, not 'dest'")
+}
@@ -0,0 +1,287 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Candidate types reported by the __complete-json entry point.
+const (
+	CandidateCommand = "command"
+	CandidateFlag    = "flag"
+	CandidateValue   = "value"
+	// The shell should complete file names matching the glob in Value, or
+	// directory names. See HintFiles() and HintDirectories().
+	CandidateFiles       = "files"
+	CandidateDirectories = "directories"
+)
+
+// A Candidate is a possible completion of the word under the cursor.
+type Candidate struct {
+	Value       string `json:"value"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+}
+
+// Implements the hidden "__complete-json <cursor> [<word>...]" entry point,
+// which writes a JSON array of candidates for completing words[cursor] to
+// the output writer. Words after the cursor are ignored.
+//
+// eg.
+//
+//     $ app __complete-json 1 --ou
+//     [{"value":"--output","type":"flag","description":"Output format."}]
+func (a *Application) completeJSON(args []string) error {
+	words, current, err := completionWords("__complete-json", args)
+	if err != nil {
+		return err
+	}
+	candidates := a.candidates(words, current)
+	if candidates == nil {
+		candidates = []Candidate{}
+	}
+	return json.NewEncoder(a.outputWriter).Encode(candidates)
+}
+
+// CompletionDirective tells the completion script how to treat the
+// candidates written by the hidden "__complete" entry point. Directives are
+// combined with bitwise or.
+type CompletionDirective int
+
+const (
+	// Do not add a space after the completed word, eg. for partial paths.
+	CompletionNoSpace CompletionDirective = 1 << iota
+	// Complete the names of files matching the globs given as candidates,
+	// and of directories, with the shell's file completion.
+	CompletionFilterFileExt
+	// Complete the names of directories with the shell's file completion.
+	CompletionFilterDirs
+)
+
+// Implements the hidden "__complete <cursor> [<word>...]" entry point used
+// by the bash and zsh completion scripts. Like "__complete-json", it
+// completes words[cursor], but writes a line for each candidate, with any
+// description separated by a tab, followed by ":" and the
+// CompletionDirective, eg.
+//
+//     $ app __complete 1 deploy --config=
+//     *.yaml
+//     :2
+//
+// As the scripts only pass words through, new completion features do not
+// require users to regenerate them.
+func (a *Application) complete(args []string) error {
+	words, current, err := completionWords("__complete", args)
+	if err != nil {
+		return err
+	}
+	var directive CompletionDirective
+	lines := []string{}
+	partial := 0
+	for _, candidate := range a.candidates(words, current) {
+		switch candidate.Type {
+		case CandidateFiles:
+			directive |= CompletionFilterFileExt
+			lines = append(lines, candidate.Value)
+			continue
+		case CandidateDirectories:
+			directive |= CompletionFilterDirs
+			continue
+		}
+		if strings.HasSuffix(candidate.Value, "/") || strings.HasSuffix(candidate.Value, "=") {
+			partial++
+		}
+		line := candidate.Value
+		if candidate.Description != "" {
+			line += "\t" + strings.Replace(candidate.Description, "\n", " ", -1)
+		}
+		lines = append(lines, line)
+	}
+	// Completing a partial value is followed by more of it, not a new word.
+	if partial > 0 && partial == len(lines) {
+		directive |= CompletionNoSpace
+	}
+	for _, line := range lines {
+		fmt.Fprintln(a.outputWriter, line)
+	}
+	fmt.Fprintf(a.outputWriter, ":%d\n", directive)
+	return nil
+}
+
+// Split the arguments of a completion entry point into the words before the
+// cursor and the word being completed.
+func completionWords(entryPoint string, args []string) (words []string, current string, err error) {
+	if len(args) == 0 {
+		return nil, "", fmt.Errorf("usage: %s <cursor> [<word>...]", entryPoint)
+	}
+	words = args[1:]
+	cursor, err := strconv.Atoi(args[0])
+	if err != nil || cursor < 0 || cursor > len(words) {
+		return nil, "", fmt.Errorf("invalid cursor position '%s'", args[0])
+	}
+	if cursor < len(words) {
+		current = words[cursor]
+	}
+	return words[:cursor], current, nil
+}
+
+// Find candidates for completing current, given the preceding words.
+func (a *Application) candidates(words []string, current string) []Candidate {
+	// Complete the value of a flag given as the previous word.
+	if n := len(words); n > 0 {
+		context, _ := a.parseContext(true, words[:n-1])
+		if flag := pendingFlag(context, words[n-1]); flag != nil {
+			context.applyElementValues()
+			return filterCandidates(valueCandidates(flag.resolveCompletionsFor(context, current), ""), current)
+		}
+	}
+
+	context, _ := a.parseContext(true, words)
+	if context == nil {
+		return nil
+	}
+	context.applyElementValues()
+
+	// Complete the value of --flag=value.
+	if strings.HasPrefix(current, "--") && strings.Contains(current, "=") {
+		parts := strings.SplitN(current, "=", 2)
+		flag, ok := context.flags.long[parts[0][2:]]
+		if !ok {
+			return nil
+		}
+		return filterCandidates(valueCandidates(flag.resolveCompletionsFor(context, parts[1]), parts[0]+"="), current)
+	}
+
+	// Complete a cluster of short flags, eg. "-xv" or "-xvfVALUE".
+	if prefix, flag, value, ok := shortFlagCluster(context.flags, current); ok {
+		if flag != nil && value != "" {
+			return filterCandidates(valueCandidates(flag.resolveCompletionsFor(context, value), prefix), current)
+		}
+		return shortFlagCandidates(context.flags, current, flag)
+	}
+
+	if strings.HasPrefix(current, "-") {
+		candidates := []Candidate{}
+		for _, flag := range context.flags.flagOrder {
+			if !flag.hidden && context.flagInScope(flag) {
+				candidates = append(candidates, Candidate{Value: "--" + flag.name, Type: CandidateFlag, Description: flag.help})
+			}
+		}
+		return filterCandidates(candidates, current)
+	}
+
+	var next *ArgClause
+	argsSatisfied := 0
+	for _, element := range context.Elements {
+		if arg, ok := element.Clause.(*ArgClause); ok {
+			if arg.consumesRemainder() {
+				next = arg
+			} else {
+				argsSatisfied++
+			}
+		}
+	}
+	if next == nil && argsSatisfied < len(context.arguments.args) {
+		next = context.arguments.args[argsSatisfied]
+	}
+	if next != nil {
+		candidates := valueCandidates(next.resolveCompletionsFor(context, current), "")
+		for i := range candidates {
+			candidates[i].Description = next.help
+		}
+		return filterCandidates(candidates, current)
+	}
+	cmds := a.cmdGroup
+	if context.SelectedCommand != nil {
+		cmds = context.SelectedCommand.cmdGroup
+	}
+	candidates := []Candidate{}
+	for _, name := range cmds.completionNames() {
+		candidates = append(candidates, Candidate{Value: name, Type: CandidateCommand, Description: cmds.commands[name].help})
+	}
+	if cmd := context.SelectedCommand; cmd != nil {
+		candidates = append(candidates, valueCandidates(cmd.contextCompletions(context, current), "")...)
+	}
+	return filterCandidates(candidates, current)
+}
+
+// The flag awaiting a value, if word is a flag that takes one.
+func pendingFlag(context *ParseContext, word string) *FlagClause {
+	if context == nil || !strings.HasPrefix(word, "-") || strings.Contains(word, "=") {
+		return nil
+	}
+	if strings.HasPrefix(word, "--") {
+		flag := context.flags.long[word[2:]]
+		if flag == nil || isBoolValue(flag.value) {
+			return nil
+		}
+		return flag
+	}
+	if _, flag, value, ok := shortFlagCluster(context.flags, word); ok && value == "" {
+		return flag
+	}
+	return nil
+}
+
+// Split a cluster of short flags such as "-xvf" or "-xvfVALUE" into the
+// prefix up to and including the first flag that takes a value, that flag,
+// and the value attached to it. The flag is nil if all the flags are
+// boolean. ok is false if word is not a cluster of known short flags.
+func shortFlagCluster(flags *flagGroup, word string) (prefix string, flag *FlagClause, value string, ok bool) {
+	if len(word) < 2 || word[0] != '-' || word[1] == '-' {
+		return "", nil, "", false
+	}
+	for i, r := range word[1:] {
+		short, found := flags.short[string(r)]
+		if !found {
+			return "", nil, "", false
+		}
+		if !isBoolValue(short.value) {
+			end := 1 + i + utf8.RuneLen(r)
+			return word[:end], short, word[end:], true
+		}
+	}
+	return word, nil, "", true
+}
+
+// Candidates for completing a cluster of short flags: the cluster itself
+// and, unless it ends with a flag awaiting a value, the cluster extended by
+// each short flag not already in it.
+func shortFlagCandidates(flags *flagGroup, cluster string, pending *FlagClause) []Candidate {
+	last, _ := utf8.DecodeLastRuneInString(cluster)
+	candidates := []Candidate{{Value: cluster, Type: CandidateFlag, Description: flags.short[string(last)].help}}
+	if pending != nil {
+		return candidates
+	}
+	for _, flag := range flags.flagOrder {
+		if flag.shorthand != 0 && !flag.hidden && !strings.ContainsRune(cluster[1:], flag.shorthand) {
+			candidates = append(candidates, Candidate{Value: cluster + string(flag.shorthand), Type: CandidateFlag, Description: flag.help})
+		}
+	}
+	return candidates
+}
+
+func valueCandidates(values []string, prefix string) []Candidate {
+	candidates := make([]Candidate, len(values))
+	for i, value := range values {
+		if isFileDirective(value) {
+			candidates[i] = fileCandidate(value)
+			continue
+		}
+		candidates[i] = Candidate{Value: prefix + value, Type: CandidateValue}
+	}
+	return candidates
+}
+
+func filterCandidates(candidates []Candidate, prefix string) []Candidate {
+	out := []Candidate{}
+	for _, candidate := range candidates {
+		if candidate.Type == CandidateFiles || candidate.Type == CandidateDirectories || strings.HasPrefix(candidate.Value, prefix) {
+			out = append(out, candidate)
+		}
+	}
+	return out
+}
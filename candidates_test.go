@@ -0,0 +1,109 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func completeJSONApp(w *bytes.Buffer) *Application {
+	app := newTestApp().OutputWriter(w)
+	app.Flag("verbose", "Be verbose.").Bool()
+	app.Flag("format", "Output format.").Short('f').Enum("json", "text")
+	deploy := app.Command("deploy", "Deploy a service.")
+	deploy.Flag("region", "Region.").HintOptions("eu-west-1", "us-east-1").String()
+	deploy.Arg("service", "Service to deploy.").HintOptions("api", "web").String()
+	app.Command("status", "Show status.")
+	return app
+}
+
+func TestCompleteJSON(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"0"}, `[{"value":"help","type":"command","description":"Show help for a command."},{"value":"deploy","type":"command","description":"Deploy a service."},{"value":"status","type":"command","description":"Show status."}]`},
+		{[]string{"0", "de"}, `[{"value":"deploy","type":"command","description":"Deploy a service."}]`},
+		{[]string{"1", "deploy", "--r"}, `[{"value":"--region","type":"flag","description":"Region."}]`},
+		{[]string{"2", "deploy", "--region", "eu"}, `[{"value":"eu-west-1","type":"value"}]`},
+		{[]string{"1", "deploy", "--region=us"}, `[{"value":"--region=us-east-1","type":"value"}]`},
+		{[]string{"1", "deploy"}, `[{"value":"api","type":"value","description":"Service to deploy."},{"value":"web","type":"value","description":"Service to deploy."}]`},
+		{[]string{"1", "-f"}, `[{"value":"json","type":"value"},{"value":"text","type":"value"}]`},
+		{[]string{"0", "-ft"}, `[{"value":"-ftext","type":"value"}]`},
+		{[]string{"0", "-f"}, `[{"value":"-f","type":"flag","description":"Output format."}]`},
+		{[]string{"0", "--v", "ignored"}, `[{"value":"--verbose","type":"flag","description":"Be verbose."}]`},
+	} {
+		w := &bytes.Buffer{}
+		app := completeJSONApp(w)
+		_, err := app.Parse(append([]string{"__complete-json"}, test.args...))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected+"\n", w.String(), "%v", test.args)
+	}
+}
+
+func TestCompleteJSONInvalidCursor(t *testing.T) {
+	app := completeJSONApp(&bytes.Buffer{})
+	_, err := app.Parse([]string{"__complete-json", "3", "deploy"})
+	assert.Error(t, err)
+}
+
+func TestCompleteJSONContextHints(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	region := app.Flag("region", "").Default("us").String()
+	buckets := map[string][]string{"us": {"us-logs"}, "eu": {"eu-logs", "eu-data"}}
+	cp := app.Command("cp", "")
+	cp.Arg("bucket", "").HintActionCtx(func(ctx *ParseContext, prefix string) []string {
+		return buckets[*region]
+	}).String()
+	ls := app.Command("ls", "")
+	ls.HintActionCtx(func(ctx *ParseContext, prefix string) []string {
+		return []string{prefix + "/a", prefix + "/b"}
+	})
+
+	_, err := app.Parse([]string{"__complete-json", "2", "--region=eu", "cp", "eu-d"})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"value":"eu-data","type":"value"}]`+"\n", w.String())
+
+	w.Reset()
+	_, err = app.Parse([]string{"__complete-json", "1", "ls", "s3://logs"})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"value":"s3://logs/a","type":"value"},{"value":"s3://logs/b","type":"value"}]`+"\n", w.String())
+}
+
+func TestComplete(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"0", "de"}, "deploy\tDeploy a service.\n:0\n"},
+		{[]string{"1", "deploy", "--region=eu"}, "--region=eu-west-1\n:0\n"},
+		{[]string{"1", "--config"}, "*.yaml\n:2\n"},
+		{[]string{"1", "--cache"}, ":4\n"},
+	} {
+		w := &bytes.Buffer{}
+		app := completeJSONApp(w)
+		app.Flag("config", "").HintFiles("*.yaml").String()
+		app.Flag("cache", "").HintDirectories().String()
+		_, err := app.Parse(append([]string{"__complete"}, test.args...))
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, w.String(), "%v", test.args)
+	}
+}
+
+func TestCompletionDescriptions(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"deploy", "--r"}, "--region\tRegion.\n"},
+		{[]string{"st"}, "status\tShow status.\n"},
+		{[]string{"deploy", "--region", ""}, "eu-west-1\nus-east-1\n"},
+	} {
+		w := &bytes.Buffer{}
+		app := completeJSONApp(w)
+		app.Parse(append([]string{"--completion-bash", "--completion-descriptions"}, test.args...))
+		assert.Equal(t, test.expected, w.String(), "%v", test.args)
+	}
+}
@@ -1,14 +1,17 @@
 package kingpin
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"reflect"
 	"strings"
 )
 
 // Example of command usage.
 type Example struct {
-	Usage string
-	Help  string
+	Usage string `json:"usage"`
+	Help  string `json:"help,omitempty"`
 }
 
 type cmdMixin struct {
@@ -16,7 +19,16 @@ type cmdMixin struct {
 	*argGroup
 	*cmdGroup
 	actionMixin
-	examples []Example
+	examples     []Example
+	constraints  []Constraint
+	relations    []flagRelation    // See MutuallyExclusive() and RequireTogether().
+	owners       map[string]string // Module that registered each command and flag.
+	registerErrs []error           // Conflicts found by register().
+	structErrs   []error           // Invalid fields found by Struct().
+	injected     []reflect.Value   // Functions passed to ActionInjected().
+	injectErrs   []error           // Invalid functions passed to ActionInjected().
+	mixedArgs    bool              // See AllowArgsWithCommands().
+	unknownFlags *[]string         // See CollectUnknownFlags().
 }
 
 // Example adds an example of the command's usage for help output.
@@ -50,20 +62,19 @@ func (c *cmdMixin) CmdCompletion(context *ParseContext) []string {
 				argsSatisfied++
 			}
 		case *Cmd:
-			options = append(options, clause.completionAlts...)
+			options = append(options, context.completionAlts[clause]...)
 		default:
 		}
 	}
 
 	if argsSatisfied < len(c.argGroup.args) {
 		// Since not all args have been satisfied, show options for the current one
-		options = append(options, c.argGroup.args[argsSatisfied].resolveCompletions()...)
+		options = append(options, c.argGroup.args[argsSatisfied].resolveCompletionsFor(context, "")...)
 	} else {
 		// If all args are satisfied, then go back to completing commands
-		for _, cmd := range c.cmdGroup.commandOrder {
-			if !cmd.hidden {
-				options = append(options, cmd.name)
-			}
+		options = append(options, c.cmdGroup.completionNames()...)
+		if cmd := context.SelectedCommand; cmd != nil && &cmd.cmdMixin == c {
+			options = append(options, cmd.contextCompletions(context, "")...)
 		}
 	}
 
@@ -71,6 +82,10 @@ func (c *cmdMixin) CmdCompletion(context *ParseContext) []string {
 }
 
 func (c *cmdMixin) FlagCompletion(flagName string, flagValue string) (choices []string, flagMatch bool, optionMatch bool) {
+	return c.flagCompletion(nil, flagName, flagValue)
+}
+
+func (c *cmdMixin) flagCompletion(context *ParseContext, flagName string, flagValue string) (choices []string, flagMatch bool, optionMatch bool) {
 	// Check if flagName matches a known flag.
 	// If it does, show the options for the flag
 	// Otherwise, show all flags
@@ -81,7 +96,7 @@ func (c *cmdMixin) FlagCompletion(flagName string, flagValue string) (choices []
 		// Loop through each flag and determine if a match exists
 		if flag.name == flagName {
 			// User typed entire flag. Need to look for flag options.
-			options = flag.resolveCompletions()
+			options = flag.resolveCompletionsFor(context, flagValue)
 			if len(options) == 0 {
 				// No Options to Choose From, Assume Match.
 				return options, true, true
@@ -104,7 +119,7 @@ func (c *cmdMixin) FlagCompletion(flagName string, flagValue string) (choices []
 			return options, true, !isPrefix && matched
 		}
 
-		if !flag.hidden {
+		if !flag.hidden && context.flagInScope(flag) {
 			options = append(options, "--"+flag.name)
 		}
 	}
@@ -129,10 +144,14 @@ func (c *cmdGroup) defaultSubcommand() *Cmd {
 	return nil
 }
 
-func (c *cmdGroup) cmdNames() []string {
-	names := make([]string, 0, len(c.commandOrder))
+// The names and aliases of visible commands.
+func (c *cmdGroup) visibleNames() []string {
+	names := []string{}
 	for _, cmd := range c.commandOrder {
-		names = append(names, cmd.name)
+		if !cmd.hidden {
+			names = append(names, cmd.name)
+			names = append(names, cmd.aliases...)
+		}
 	}
 	return names
 }
@@ -209,14 +228,24 @@ type CmdValidator func(*Cmd) error
 // and either subcommands or positional arguments.
 type Cmd struct {
 	cmdMixin
-	app            *Application
-	name           string
-	aliases        []string
-	help           string
-	isDefault      bool
-	validator      CmdValidator
-	hidden         bool
-	completionAlts []string
+	app           *Application
+	name          string
+	aliases       []string
+	help          string
+	isDefault     bool
+	validator     CmdValidator
+	ctxValidators []ContextValidator
+	hidden        bool
+	deprecated    string
+	interspersed  *bool  // Overrides the parent's setting, if set.
+	passThrough   string // See PassThroughAfter().
+	contextHints  []ContextHintAction
+	external      string    // Path of the executable, see ExternalCommands().
+	category      string    // See Category().
+	usageTemplate string    // See UsageTemplate().
+	usageWriter   io.Writer // See UsageWriter().
+	helpLevel     int       // See HelpLevel().
+	prefixedEnvar bool      // See PrefixedEnvar().
 }
 
 func newCommand(app *Application, name, help string) *Cmd {
@@ -269,28 +298,148 @@ func (c *Cmd) Action(action Action) *Cmd {
 	return c
 }
 
+// ActionCtx is like Action(), but the callback also receives the
+// context.Context passed to Application.ParseWithContext().
+func (c *Cmd) ActionCtx(action func(ctx context.Context, pc *ParseContext) error) *Cmd {
+	c.addAction(withContext(action))
+	return c
+}
+
+// UsageTemplate sets the template used for the help of this command and its
+// subcommands, in place of the application's. See Application.UsageTemplate().
+func (c *Cmd) UsageTemplate(template string) *Cmd {
+	c.usageTemplate = template
+	return c
+}
+
+// UsageWriter sets the io.Writer that help for this command and its
+// subcommands is written to, in place of the application's.
+func (c *Cmd) UsageWriter(w io.Writer) *Cmd {
+	c.usageWriter = w
+	return c
+}
+
+// Category lists the command under the given heading in help, rather than
+// with the other commands, eg.
+//
+//     app.Command("container", "Manage containers.").Category("Management Commands")
+//
+// Commands in the same category are listed together, in order of definition.
+// See Application.CategoryOrder() to order the categories.
+func (c *Cmd) Category(title string) *Cmd {
+	c.category = title
+	return c
+}
+
 func (c *Cmd) PreAction(action Action) *Cmd {
 	c.addPreAction(action)
 	return c
 }
 
+// PostAction is called after the command's actions, whether or not they
+// succeeded. See Action for the order in which actions run.
+func (c *Cmd) PostAction(action Action) *Cmd {
+	c.addPostAction(action)
+	return c
+}
+
+// PrefixedEnvar gives each flag of the command and its sub-commands that does
+// not already have an envar a default one named after the application, the
+// command and the flag. For example, the flag "dry-run" of the command
+// "db migrate" in the application "app" defaults to "APP_DB_MIGRATE_DRY_RUN".
+func (c *Cmd) PrefixedEnvar() *Cmd {
+	c.prefixedEnvar = true
+	return c
+}
+
+// The prefix of the default envars of the command's flags.
+func (c *Cmd) defaultEnvarPrefix() string {
+	for cmd := c; cmd != nil; cmd = cmd.parent {
+		if cmd.prefixedEnvar {
+			return c.app.Name + "_" + c.FullCommand()
+		}
+	}
+	return c.app.defaultEnvarPrefix()
+}
+
 func (c *Cmd) init() error {
-	if err := c.flagGroup.init(c.app.defaultEnvarPrefix()); err != nil {
+	if err := c.flagGroup.init(c.defaultEnvarPrefix()); err != nil {
 		return err
 	}
-	if c.argGroup.have() && c.cmdGroup.have() {
+	if c.argGroup.have() && c.cmdGroup.have() && !c.mixedArgs {
 		return fmt.Errorf("can't mix Arg()s with Command()s")
 	}
 	if err := c.argGroup.init(); err != nil {
 		return err
 	}
+	if c.passThrough != "" && c.argGroup.GetArg(c.passThrough) == nil {
+		return fmt.Errorf("PassThroughAfter(%q) on command '%s' does not name an argument", c.passThrough, c.FullCommand())
+	}
 	if err := c.cmdGroup.init(); err != nil {
 		return err
 	}
 	return nil
 }
 
+// AllowArgsWithCommands allows the command to have both Arg()s and
+// Command()s, as in "git <pathspec>" and "git status". The first positional
+// value selects a subcommand if it names one, otherwise it and any following
+// values are matched to the arguments. Selecting a subcommand is then
+// optional.
+func (c *Cmd) AllowArgsWithCommands() *Cmd {
+	c.mixedArgs = true
+	return c
+}
+
+// Interspersed controls if flags can be interspersed with positional
+// arguments of this command and its subcommands, overriding
+// Application.Interspersed().
+func (c *Cmd) Interspersed(interspersed bool) *Cmd {
+	c.interspersed = &interspersed
+	return c
+}
+
+// PassThroughAfter stops flag parsing once the named argument has been
+// matched, so that every remaining word, including those that look like
+// flags, is passed to the following arguments verbatim. This suits wrapper
+// commands, eg.
+//
+//     exec := app.Command("exec", "Run a command.").PassThroughAfter("command")
+//     exec.Arg("command", "Command to run.").Required().String()
+//     exec.Arg("args", "Arguments for the command.").Strings()
+//
+// With this, "app exec ls -la" passes "-la" to the args argument.
+func (c *Cmd) PassThroughAfter(arg string) *Cmd {
+	c.passThrough = arg
+	return c
+}
+
+// HintActionCtx registers a ContextHintAction providing additional
+// completions for the word following the command, alongside its
+// subcommands.
+func (c *Cmd) HintActionCtx(action ContextHintAction) *Cmd {
+	c.contextHints = append(c.contextHints, action)
+	return c
+}
+
+// Completions from the command's ContextHintActions.
+func (c *Cmd) contextCompletions(context *ParseContext, prefix string) []string {
+	var hints []string
+	for _, hintAction := range c.contextHints {
+		hints = append(hints, hintAction(context, prefix)...)
+	}
+	return hints
+}
+
 func (c *Cmd) Hidden() *Cmd {
 	c.hidden = true
 	return c
 }
+
+// Deprecated hides the command from usage and warns, with the given message,
+// whenever it is used.
+func (c *Cmd) Deprecated(message string) *Cmd {
+	c.hidden = true
+	c.deprecated = message
+	return c
+}
@@ -35,6 +35,8 @@ func (f *{{.|ValueName}}) Get() interface{} { return ({{.Type}})(*f.v) }
 
 func (f *{{.|ValueName}}) String() string { return {{.|Format}} }
 
+func (f *{{.|ValueName}}) storage() interface{} { return f.v }
+
 {{if .Help}}
 // {{.Help}}
 {{else}}\
@@ -64,6 +66,20 @@ func (p *parserMixin) {{.|Plural}}Var(target *[]{{.Type}}) {
 	}))
 }
 
+{{if not (.|Nilable)}}
+// Optional{{.|Name}} parses the next command-line value as {{.Type}}, leaving target nil unless a value is provided.
+func (p *parserMixin) Optional{{.|Name}}() (target **{{.Type}}) {
+	target = new(*{{.Type}})
+	p.Optional{{.|Name}}Var(target)
+	return
+}
+
+func (p *parserMixin) Optional{{.|Name}}Var(target **{{.Type}}) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return new{{.|Name}}Value(v.(*{{.Type}}))
+	}))
+}
+{{end}}
 {{end}}
 `
 )
@@ -106,13 +122,17 @@ func main() {
 			if v.Format != "" {
 				return v.Format
 			}
-			return "fmt.Sprintf(\"%v\", *f)"
+			return "fmt.Sprintf(\"%v\", *f.v)"
 		},
 		"ValueName": func(v *Value) string {
 			name := valueName(v)
 			return strings.ToLower(name[0:1]) + name[1:] + "Value"
 		},
 		"Name": valueName,
+		// Types which can already be nil don't need an Optional variant.
+		"Nilable": func(v *Value) bool {
+			return strings.HasPrefix(v.Type, "*") || strings.HasPrefix(v.Type, "[]") || v.Type == "net.IP"
+		},
 		"Plural": func(v *Value) string {
 			if v.Plural != "" {
 				return v.Plural
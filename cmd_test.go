@@ -1,6 +1,7 @@
 package kingpin
 
 import (
+	"bytes"
 	"sort"
 	"strings"
 
@@ -289,6 +290,27 @@ func TestCmdCompletion(t *testing.T) {
 	assert.Equal(t, []string{"sub1", "sub2"}, complete(t, app, "two"))
 }
 
+func TestHelpCmdCompletion(t *testing.T) {
+	app := newTestApp().HelpTopic("environment", "", "")
+	app.Command("one", "")
+	two := app.Command("two", "")
+	two.Command("sub1", "")
+	two.Command("sub2", "")
+
+	assert.Equal(t, []string{"environment", "help", "one", "two"}, complete(t, app, "help"))
+	assert.Equal(t, []string{"sub1", "sub2"}, complete(t, app, "help", "two", ""))
+	assert.Empty(t, complete(t, app, "help", "one", ""))
+}
+
+func TestCumulativeArgCmdCompletion(t *testing.T) {
+	app := newTestApp()
+	files := app.Command("files", "")
+	files.Arg("file", "").HintOptions("a", "b").Strings()
+
+	assert.Equal(t, []string{"a", "b"}, complete(t, app, "files"))
+	assert.Empty(t, complete(t, app, "files", "a", ""))
+}
+
 func TestHiddenCmdCompletion(t *testing.T) {
 	app := newTestApp()
 
@@ -372,3 +394,120 @@ func TestDefaultCmdCompletion(t *testing.T) {
 	// With both args of a default sub cmd, should get no completions
 	assert.Empty(t, complete(t, app, "arg1", "arg2"))
 }
+
+func TestMissingSubcommandPolicy(t *testing.T) {
+	newApp := func(policy MissingCommandPolicy) (*Application, *bytes.Buffer, *int) {
+		w := bytes.NewBuffer(nil)
+		status := -1
+		app := New("test", "").MissingCommand(policy).UsageWriter(w).ErrorWriter(w).Terminate(func(s int) { status = s })
+		remote := app.Command("remote", "Manage remotes.")
+		remote.Command("add", "Add a remote.")
+		return app, w, &status
+	}
+
+	app, _, _ := newApp(MissingCommandError)
+	_, err := app.Parse([]string{"remote"})
+	assert.Error(t, err)
+
+	app, w, status := newApp(MissingCommandHelp)
+	app.Parse([]string{"remote"})
+	assert.Equal(t, 0, *status)
+	assert.Contains(t, w.String(), "Add a remote.")
+
+	app, w, status = newApp(MissingCommandUsage)
+	app.Parse([]string{"remote"})
+	assert.Equal(t, 2, *status)
+	assert.Equal(t, "usage: test remote <command> [<args> ...]\nRun 'test remote --help' for more information.\n", w.String())
+}
+
+func TestCmdInterspersed(t *testing.T) {
+	app := newTestApp()
+	verbose := app.Flag("verbose", "").Bool()
+	run := app.Command("run", "").Interspersed(false)
+	script := run.Arg("script", "").String()
+	args := run.Arg("args", "").Strings()
+	list := app.Command("list", "")
+	all := list.Flag("all", "").Bool()
+	pattern := list.Arg("pattern", "").String()
+
+	_, err := app.Parse([]string{"run", "--verbose", "x.sh", "--verbose", "-a"})
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+	assert.Equal(t, "x.sh", *script)
+	assert.Equal(t, []string{"--verbose", "-a"}, *args)
+
+	_, err = app.Parse([]string{"list", "foo", "--all"})
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", *pattern)
+	assert.True(t, *all)
+}
+
+func TestCmdPassThroughAfter(t *testing.T) {
+	app := newTestApp()
+	exec := app.Command("exec", "").PassThroughAfter("command")
+	dir := exec.Flag("dir", "").String()
+	command := exec.Arg("command", "").Required().String()
+	args := exec.Arg("args", "").Strings()
+
+	_, err := app.Parse([]string{"exec", "--dir=/tmp", "ls", "-la", "--dir", "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp", *dir)
+	assert.Equal(t, "ls", *command)
+	assert.Equal(t, []string{"-la", "--dir", "x"}, *args)
+
+	app = newTestApp()
+	app.Command("exec", "").PassThroughAfter("nope").Arg("command", "").String()
+	_, err = app.Parse([]string{"exec"})
+	assert.EqualError(t, err, `PassThroughAfter("nope") on command 'exec' does not name an argument`)
+}
+
+func TestCmdAllowArgsWithCommands(t *testing.T) {
+	newApp := func() (*Application, *[]string) {
+		app := newTestApp()
+		repo := app.Command("repo", "").AllowArgsWithCommands()
+		paths := repo.Arg("pathspec", "").Strings()
+		repo.Command("status", "")
+		return app, paths
+	}
+
+	app, paths := newApp()
+	selected, err := app.Parse([]string{"repo", "status"})
+	assert.NoError(t, err)
+	assert.Equal(t, "repo status", selected)
+	assert.Equal(t, 0, len(*paths))
+
+	app, paths = newApp()
+	selected, err = app.Parse([]string{"repo", "a.go", "status"})
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", selected)
+	assert.Equal(t, []string{"a.go", "status"}, *paths)
+
+	app, _ = newApp()
+	selected, err = app.Parse([]string{"repo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "repo", selected)
+
+	app = newTestApp()
+	app.Command("repo", "").Command("status", "")
+	app.GetCommand("repo").Arg("pathspec", "").String()
+	_, err = app.Parse([]string{"repo"})
+	assert.EqualError(t, err, "can't mix Arg()s with Command()s")
+}
+
+func TestCmdCollectUnknownFlags(t *testing.T) {
+	var forward []string
+	app := newTestApp()
+	run := app.Command("run", "").CollectUnknownFlags(&forward)
+	verbose := run.Flag("verbose", "").Short('v').Bool()
+	image := run.Arg("image", "").String()
+	app.Command("ls", "")
+
+	_, err := app.Parse([]string{"run", "--rm", "-v", "-it", "--name=web", "--label=a=b", "nginx"})
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+	assert.Equal(t, "nginx", *image)
+	assert.Equal(t, []string{"--rm", "-it", "--name=web", "--label=a=b"}, forward)
+
+	_, err = app.Parse([]string{"ls", "--rm"})
+	assert.EqualError(t, err, "unknown long flag '--rm'")
+}
@@ -0,0 +1,88 @@
+package kingpin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ColorMode controls whether error and help output is colored.
+type ColorMode int
+
+const (
+	// ColorAuto colors output when writing to a terminal and the NO_COLOR
+	// environment variable is not set.
+	ColorAuto ColorMode = iota
+	// ColorAlways colors output unconditionally.
+	ColorAlways
+	// ColorNever disables color.
+	ColorNever
+)
+
+// ANSI escape sequences used when coloring output.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiRed    = "\x1b[1;31m"
+	ansiYellow = "\x1b[1;33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// Color sets when errors and help are rendered with color. The default is
+// ColorAuto. See HelpTheme() for the colors used in help.
+func (a *Application) Color(mode ColorMode) *Application {
+	a.colorMode = mode
+	return a
+}
+
+func (a *Application) colorEnabled(w io.Writer) bool {
+	switch a.colorMode {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(w)
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Write an error message to the error writer. If color is enabled the
+// "error:" label is red, token is highlighted within message, and hint is
+// rendered on its own line.
+func (a *Application) writeError(message, token, hint string) {
+	if !a.colorEnabled(a.errorWriter) {
+		fmt.Fprintf(a.errorWriter, "%s: error: %s\n", a.Name, message)
+		if hint != "" {
+			fmt.Fprintf(a.errorWriter, "%s: hint: %s\n", a.Name, hint)
+		}
+		return
+	}
+	if token != "" {
+		message = strings.Replace(message, token, ansiYellow+token+ansiReset, 1)
+	}
+	fmt.Fprintf(a.errorWriter, "%s%s:%s %serror:%s %s\n", ansiBold, a.Name, ansiReset, ansiRed, ansiReset, message)
+	if hint != "" {
+		fmt.Fprintf(a.errorWriter, "%s%s:%s %shint:%s %s\n", ansiBold, a.Name, ansiReset, ansiCyan, ansiReset, hint)
+	}
+}
+
+// Write err, prefixed by prefix, to the error writer.
+func (a *Application) writeErr(prefix string, err error) {
+	if e, ok := err.(*Error); ok {
+		a.writeError(prefix+e.Error(), e.Token, e.Hint)
+		return
+	}
+	a.writeError(prefix+err.Error(), "", "")
+}
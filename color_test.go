@@ -0,0 +1,62 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestColoredErrors(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().ErrorWriter(w).Color(ColorAlways)
+	_, err := app.Parse([]string{"--nope"})
+	app.FatalIfError(err, "")
+	assert.Equal(t, "\x1b[1mtest:\x1b[0m \x1b[1;31merror:\x1b[0m unknown long flag '\x1b[1;33m--nope\x1b[0m'\n", w.String())
+
+	w.Reset()
+	app.writeErr("", &Error{Kind: MsgRequiredFlag, Args: []interface{}{"name"}, Hint: "set $NAME"})
+	assert.Equal(t, "\x1b[1mtest:\x1b[0m \x1b[1;31merror:\x1b[0m required flag --name not provided\n\x1b[1mtest:\x1b[0m \x1b[36mhint:\x1b[0m set $NAME\n", w.String())
+}
+
+func TestColorDisabled(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().ErrorWriter(w)
+	_, err := app.Parse([]string{"--nope"})
+	app.FatalIfError(err, "")
+	assert.Equal(t, "test: error: unknown long flag '--nope'\n", w.String())
+
+	w.Reset()
+	app.Color(ColorAuto)
+	assert.False(t, app.colorEnabled(w))
+	app.writeErr("", &Error{Kind: MsgRequiredFlag, Args: []interface{}{"name"}, Hint: "set $NAME"})
+	assert.Equal(t, "test: error: required flag --name not provided\ntest: hint: set $NAME\n", w.String())
+}
+
+func TestNoColorEnvironment(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	app := newTestApp()
+	assert.False(t, app.colorEnabled(nil))
+	app.Color(ColorAlways)
+	assert.True(t, app.colorEnabled(nil))
+}
+
+func TestHelpTheme(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().Writer(w).HelpTheme(Theme{
+		Heading: ColorBold.And(ColorUnderline),
+		Command: ColorGreen,
+		Flag:    ColorCyan,
+	})
+	app.Command("run", "Run it.")
+
+	app.Usage(nil)
+	assert.NotContains(t, w.String(), "\x1b[")
+
+	w.Reset()
+	app.Color(ColorAlways).Usage(nil)
+	help := w.String()
+	assert.Contains(t, help, "\x1b[1;4mCommands:\x1b[0m")
+	assert.Contains(t, help, "\x1b[32mrun\x1b[0m   Run it.")
+	assert.Contains(t, help, "\x1b[36m-h, --help\x1b[0m")
+}
@@ -3,9 +3,53 @@ package kingpin
 // HintAction is a function type who is expected to return a slice of possible
 // command line arguments.
 type HintAction func() []string
+
+// ContextHintAction is like HintAction, but is passed the command line parsed
+// so far and the prefix being completed, so that completions can depend on
+// other flags and arguments.
+type ContextHintAction func(context *ParseContext, prefix string) []string
+
+// CompletionOptions controls which commands are offered by shell completion.
+type CompletionOptions struct {
+	// Offer the aliases of commands as well as their names.
+	IncludeAliases bool
+	// Offer Hidden() commands.
+	IncludeHidden bool
+}
+
+// CompletionOptions sets which commands are offered by shell completion. By
+// default only the names of visible commands are, eg.
+//
+//     app.CompletionOptions(kingpin.CompletionOptions{IncludeAliases: true})
+func (a *Application) CompletionOptions(options CompletionOptions) *Application {
+	a.completionOpts = options
+	return a
+}
+
+// The names of the commands offered by completion, see CompletionOptions.
+func (c *cmdGroup) completionNames() []string {
+	options := c.app.completionOpts
+	names := []string{}
+	for _, cmd := range c.commandOrder {
+		if cmd.hidden && !options.IncludeHidden {
+			continue
+		}
+		names = append(names, cmd.name)
+		if options.IncludeAliases {
+			names = append(names, cmd.aliases...)
+		}
+	}
+	return names
+}
+
 type completionsMixin struct {
 	hintActions        []HintAction
 	builtinHintActions []HintAction
+	contextHintActions []ContextHintAction
+	hintCaches         []*hintCache
+	optionsLimit       int    // See OptionsLimit()
+	hintGlob           string // See HintFiles()
+	hintDirectories    bool   // See HintDirectories()
 }
 
 func (a *completionsMixin) addHintAction(action HintAction) {
@@ -17,11 +61,21 @@ func (a *completionsMixin) addHintActionBuiltin(action HintAction) {
 	a.builtinHintActions = append(a.builtinHintActions, action)
 }
 
+func (a *completionsMixin) addContextHintAction(action ContextHintAction) {
+	a.contextHintActions = append(a.contextHintActions, action)
+}
+
 func (a *completionsMixin) resolveCompletions() []string {
+	return a.resolveCompletionsFor(nil, "")
+}
+
+// Resolve completions, including those from ContextHintActions if context
+// is not nil.
+func (a *completionsMixin) resolveCompletionsFor(context *ParseContext, prefix string) []string {
 	var hints []string
 
 	options := a.builtinHintActions
-	if len(a.hintActions) > 0 {
+	if len(a.hintActions) > 0 || len(a.contextHintActions) > 0 {
 		// User specified their own hintActions. Use those instead.
 		options = a.hintActions
 	}
@@ -29,5 +83,33 @@ func (a *completionsMixin) resolveCompletions() []string {
 	for _, hintAction := range options {
 		hints = append(hints, hintAction()...)
 	}
+	if context != nil {
+		for _, hintAction := range a.contextHintActions {
+			hints = append(hints, hintAction(context, prefix)...)
+		}
+	}
+	if directive := a.fileDirective(); directive != "" && len(a.hintActions) == 0 && len(a.contextHintActions) == 0 {
+		return []string{directive}
+	}
 	return hints
 }
+
+// Set the values of the flags and arguments given on the command line, so
+// that ContextHintActions can inspect them. Errors are ignored, as the
+// command line being completed is incomplete.
+func (p *ParseContext) applyElementValues() {
+	p.app.valuesLock.Lock()
+	defer p.app.valuesLock.Unlock()
+	for _, element := range p.Elements {
+		switch clause := element.Clause.(type) {
+		case *FlagClause:
+			if clause.setValue(*element.Value) == nil {
+				p.setSource(clause, SourceArgs)
+			}
+		case *ArgClause:
+			if clause.setValue(*element.Value) == nil {
+				p.setSource(clause, SourceArgs)
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package kingpin
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/tj/assert"
@@ -76,3 +78,83 @@ func TestAddHintActionBuiltin(t *testing.T) {
 	args := a.resolveCompletions()
 	assert.Equal(t, []string{"opt1", "opt2"}, args)
 }
+
+func TestFishCompletionScript(t *testing.T) {
+	app := New("my-app", "")
+	app.Flag("verbose", "Verbose output.").Short('v').Bool()
+	remote := app.Command("remote", "Manage remotes.")
+	add := remote.Command("add", "Add a remote.")
+	add.Flag("proto", "Protocol to use.").Enum("https", "ssh")
+	add.Flag("name", "Remote's name.").String()
+	add.Flag("key", "Key file.").HintFiles("*.pem").String()
+	app.Command("secret", "").Hidden()
+
+	assert.NoError(t, app.init())
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, app.writeFishCompletion(buf))
+	script := buf.String()
+	assert.Contains(t, script, "set -g __fish_my_app_commands 'help' 'remote' 'remote add'\n")
+	assert.Contains(t, script, "complete -c 'my-app' -l 'verbose' -s 'v' -d 'Verbose output.'\n")
+	assert.Contains(t, script, "complete -c 'my-app' -n '__fish_my_app_is \\'\\'' -a 'remote' -d 'Manage remotes.'\n")
+	assert.Contains(t, script, "complete -c 'my-app' -n '__fish_my_app_is \\'remote\\'' -a 'add' -d 'Add a remote.'\n")
+	assert.Contains(t, script, "complete -c 'my-app' -n '__fish_my_app_in \\'remote add\\'' -l 'proto' -x -a 'https ssh' -d 'Protocol to use.'\n")
+	assert.Contains(t, script, "-l 'name' -r -d 'Remote\\'s name.'\n")
+	assert.Contains(t, script, "-l 'key' -r -F -d 'Key file.'\n")
+	assert.NotContains(t, script, "secret")
+}
+
+func TestPowerShellCompletionScript(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	app := New("app", "").OutputWriter(buf).Terminate(nil)
+	app.Command("run", "")
+	app.Parse([]string{"--completion-script-powershell"})
+	assert.Contains(t, buf.String(), "Register-ArgumentCompleter -Native -CommandName 'app'")
+	assert.Contains(t, buf.String(), "'__complete-json'")
+}
+
+func TestHintFiles(t *testing.T) {
+	newApp := func(w *bytes.Buffer) *Application {
+		app := newTestApp().OutputWriter(w)
+		app.Flag("config", "").HintFiles("*.yaml").String()
+		app.Flag("dir", "").HintDirectories().String()
+		app.Flag("format", "").HintFiles("").HintOptions("json").String()
+		app.Arg("input", "").HintFiles("").String()
+		return app
+	}
+
+	for args, expected := range map[string]string{
+		"--config":       "__kingpin_complete_files *.yaml",
+		"--config=./con": "__kingpin_complete_files *.yaml",
+		"--dir":          "__kingpin_complete_dirs",
+		"--format":       "json",
+		"":               "__kingpin_complete_files *",
+	} {
+		w := &bytes.Buffer{}
+		app := newApp(w)
+		app.Parse(append([]string{"--completion-bash"}, strings.Fields(args)...))
+		assert.Equal(t, expected, w.String(), args)
+	}
+
+	w := &bytes.Buffer{}
+	_, err := newApp(w).Parse([]string{"__complete-json", "1", "--config", "./c"})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"value":"*.yaml","type":"files"}]`+"\n", w.String())
+
+	w.Reset()
+	newApp(w).Parse([]string{"--completion-bash", "--completion-descriptions", "--dir", ""})
+	assert.Equal(t, "__kingpin_complete_dirs\n", w.String())
+}
+
+func TestCompletionOptions(t *testing.T) {
+	complete := func(options CompletionOptions) string {
+		w := &bytes.Buffer{}
+		app := newTestApp().OutputWriter(w).CompletionOptions(options)
+		app.Command("list", "").Alias("ls")
+		app.Command("debug", "").Hidden()
+		app.Parse([]string{"--completion-bash"})
+		return w.String()
+	}
+	assert.Equal(t, "help\nlist", complete(CompletionOptions{}))
+	assert.Equal(t, "help\nlist\nls", complete(CompletionOptions{IncludeAliases: true}))
+	assert.Equal(t, "help\nlist\ndebug", complete(CompletionOptions{IncludeHidden: true}))
+}
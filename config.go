@@ -0,0 +1,262 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConfigFile reads flag defaults from a config file mapping long flag names
+// to values. The format is chosen by extension:
+//
+//     .json          {"region": "eu-west-1", "tags": ["a", "b"]}
+//     .yaml, .yml    region: eu-west-1
+//     .toml          region = "eu-west-1"
+//
+// Only flat documents are supported; YAML lists may be written in either
+// block or flow style, and TOML arrays must fit on a single line.
+//
+// Config values take precedence over flag defaults, while profiles,
+// environment variables and the command line take precedence over the
+// config; see Resolvers() for the full order. Files that do not exist are ignored. If several files are given,
+// later files take precedence over earlier ones.
+func (a *Application) ConfigFile(path string) *Application {
+	a.configFiles = append(a.configFiles, path)
+	return a
+}
+
+// Read and merge the values of every config file.
+func (a *Application) loadConfigFiles(context *ParseContext) (map[string][]string, error) {
+	if len(a.configFiles) == 0 {
+		return nil, nil
+	}
+	known := map[string]bool{}
+	a.eachCmdMixin(func(c *cmdMixin) {
+		for name := range c.flagGroup.long {
+			known[name] = true
+		}
+	})
+	values := map[string][]string{}
+	context.configFiles = map[string]string{}
+	for _, path := range a.configFiles {
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, errorf(MsgInvalidConfigFile, path, err)
+		}
+		config, err := parseConfig(path, string(data))
+		if err != nil {
+			return nil, errorf(MsgInvalidConfigFile, path, err)
+		}
+		for flag, value := range config {
+			if !known[flag] {
+				return nil, errorf(MsgInvalidConfigFile, path, fmt.Sprintf("unknown flag '%s'", flag))
+			}
+			if err := checkConfigValues(context.flags.long[flag], value); err != nil {
+				return nil, errorf(MsgInvalidConfigFile, path, fmt.Sprintf("invalid value for '%s': %s", flag, err))
+			}
+			values[flag] = value
+			context.configFiles[flag] = path
+		}
+	}
+	return values, nil
+}
+
+// Check values for flag, if it is in scope, with a new Value of its
+// registered type, so that errors are reported against the config file.
+func checkConfigValues(flag *FlagClause, values []string) error {
+	if flag == nil {
+		return nil
+	}
+	factory, ok := valueFactoryOf(flag.value)
+	if !ok {
+		return nil
+	}
+	for _, value := range values {
+		if err := factory().Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseConfig(path, data string) (map[string][]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return parseJSONConfig(data)
+	case ".yaml", ".yml":
+		return parseYAMLConfig(data)
+	case ".toml":
+		return parseTOMLConfig(data)
+	}
+	return nil, fmt.Errorf("unsupported format, expected .json, .yaml, .yml or .toml")
+}
+
+func parseJSONConfig(data string) (map[string][]string, error) {
+	object := map[string]interface{}{}
+	dec := json.NewDecoder(strings.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&object); err != nil {
+		return nil, err
+	}
+	values := map[string][]string{}
+	for flag, value := range object {
+		v, err := jsonValues(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for '%s': %s", flag, err)
+		}
+		values[flag] = v
+	}
+	return values, nil
+}
+
+func parseYAMLConfig(data string) (map[string][]string, error) {
+	values := map[string][]string{}
+	list := "" // Key of the block list being read, if any.
+	for i, line := range strings.Split(data, "\n") {
+		line = stripConfigComment(line)
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			if list == "" {
+				return nil, fmt.Errorf("line %d: unexpected list item", i+1)
+			}
+			value, err := unquoteConfigValue(strings.TrimSpace(trimmed[1:]))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			values[list] = append(values[list], value)
+			continue
+		}
+		if isIndented(line) {
+			return nil, fmt.Errorf("line %d: nested values are not supported", i+1)
+		}
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", i+1)
+		}
+		key, err := unquoteConfigValue(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		list = ""
+		value := strings.TrimSpace(parts[1])
+		if value == "" {
+			list = key
+			values[key] = []string{}
+			continue
+		}
+		if values[key], err = parseConfigValue(value); err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+	}
+	return values, nil
+}
+
+func parseTOMLConfig(data string) (map[string][]string, error) {
+	values := map[string][]string{}
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(stripConfigComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("line %d: tables are not supported", i+1)
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected 'key = value'", i+1)
+		}
+		key, err := unquoteConfigValue(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		if values[key], err = parseConfigValue(strings.TrimSpace(parts[1])); err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+	}
+	return values, nil
+}
+
+// Parse a scalar or a single-line list such as [a, "b c"].
+func parseConfigValue(value string) ([]string, error) {
+	if !strings.HasPrefix(value, "[") {
+		v, err := unquoteConfigValue(value)
+		if err != nil {
+			return nil, err
+		}
+		return []string{v}, nil
+	}
+	if !strings.HasSuffix(value, "]") {
+		return nil, fmt.Errorf("unterminated list %s", value)
+	}
+	out := []string{}
+	for _, item := range splitConfigList(value[1 : len(value)-1]) {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		v, err := unquoteConfigValue(item)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+	}
+	return out, nil
+}
+
+func unquoteConfigValue(value string) (string, error) {
+	switch {
+	case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+		return strconv.Unquote(value)
+	case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+		return strings.Replace(value[1:len(value)-1], "''", "'", -1), nil
+	}
+	return value, nil
+}
+
+// Split s on commas outside of quotes.
+func splitConfigList(s string) []string {
+	out := []string{}
+	start := 0
+	quote := rune(0)
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || i == 0 || s[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			out = append(out, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(out, s[start:])
+}
+
+// Remove a trailing "# comment" from line, ignoring "#" within quotes.
+func stripConfigComment(line string) string {
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote && (quote == '\'' || line[i-1] != '\\') {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
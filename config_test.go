@@ -0,0 +1,82 @@
+package kingpin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func writeConfigFile(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	path := filepath.Join(dir, name)
+	assert.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestConfigFileFormats(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.json": `{"region": "eu-west-1", "tag": ["a", "b # c"], "force": true}`,
+		"config.yaml": "# Defaults\nregion: eu-west-1 # comment\ntag:\n  - a\n  - 'b # c'\nforce: true\n",
+		"config.yml":  "region: \"eu-west-1\"\ntag: [a, \"b # c\"]\nforce: true\n",
+		"config.toml": "region = \"eu-west-1\"\ntag = [\"a\", 'b # c']\nforce = true # comment\n",
+	} {
+		path := writeConfigFile(t, name, content)
+		defer os.RemoveAll(filepath.Dir(path))
+		app := newTestApp().ConfigFile(path)
+		region := app.Flag("region", "").Default("us-east-1").String()
+		tags := app.Flag("tag", "").Strings()
+		force := app.Flag("force", "").Bool()
+		_, err := app.Parse([]string{})
+		assert.NoError(t, err, name)
+		assert.Equal(t, "eu-west-1", *region, name)
+		assert.Equal(t, []string{"a", "b # c"}, *tags, name)
+		assert.True(t, *force, name)
+	}
+}
+
+func TestConfigFilePrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "region: eu-west-1\nzone: a\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	os.Setenv("TEST_CONFIG_ZONE", "b")
+	defer os.Unsetenv("TEST_CONFIG_ZONE")
+	app := newTestApp().ConfigFile(path).ConfigFile(filepath.Join(filepath.Dir(path), "missing.yaml"))
+	region := app.Flag("region", "").String()
+	zone := app.Flag("zone", "").Envar("TEST_CONFIG_ZONE").String()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", *region)
+	assert.Equal(t, "b", *zone)
+
+	_, err = app.Parse([]string{"--region=us-east-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "us-east-1", *region)
+}
+
+func TestConfigFileUnknownFlag(t *testing.T) {
+	path := writeConfigFile(t, "config.toml", "regoin = \"eu-west-1\"\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	app := newTestApp().ConfigFile(path)
+	app.Flag("region", "").String()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "invalid config file '"+path+"': unknown flag 'regoin'")
+}
+
+func TestConfigFileInvalidValue(t *testing.T) {
+	RegisterValueType("ipnet", func() Value { return &ipNetValue{} })
+	path := writeConfigFile(t, "config.toml", "timeout = \"soon\"\nnetwork = \"10.0.0.0/8\"\n")
+	defer os.RemoveAll(filepath.Dir(path))
+	app := newTestApp().ConfigFile(path)
+	app.Flag("timeout", "").Duration()
+	network := app.Flag("network", "").Type("ipnet")
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "invalid config file '"+path+"': invalid value for 'timeout': time: invalid duration \"soon\"")
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("network = \"10.0.0.0\"\n"), 0600))
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "invalid config file '"+path+"': invalid value for 'network': invalid CIDR address: 10.0.0.0")
+	assert.Equal(t, "<nil>", network.String())
+}
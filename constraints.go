@@ -0,0 +1,269 @@
+package kingpin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Constraint checks a relationship between flags and arguments after
+// parsing. See Requires(), Conflicts() and AtLeastOne().
+type Constraint interface {
+	Check(context *ParseContext) error
+}
+
+// ConstraintFunc is a function implementing the Constraint interface.
+type ConstraintFunc func(context *ParseContext) error
+
+// Check calls f(context).
+func (f ConstraintFunc) Check(context *ParseContext) error {
+	return f(context)
+}
+
+// A constraint between the named flags and arguments, which must exist. See
+// checkConstraintNames().
+type namedConstraint struct {
+	names []string
+	check ConstraintFunc
+}
+
+func (n namedConstraint) Check(context *ParseContext) error {
+	return n.check(context)
+}
+
+// Requires is satisfied if name is not set, or if all of others are set.
+func Requires(name string, others ...string) Constraint {
+	return namedConstraint{append([]string{name}, others...), func(context *ParseContext) error {
+		if !context.IsSet(name) {
+			return nil
+		}
+		missing := []string{}
+		for _, other := range others {
+			if !context.IsSet(other) {
+				missing = append(missing, context.displayName(other))
+			}
+		}
+		if len(missing) > 0 {
+			return errorf(MsgRequires, context.displayName(name), strings.Join(missing, ", "))
+		}
+		return nil
+	}}
+}
+
+// Conflicts is satisfied if at most one of names is set.
+func Conflicts(names ...string) Constraint {
+	return namedConstraint{names, func(context *ParseContext) error {
+		set := []string{}
+		for _, name := range names {
+			if context.IsSet(name) {
+				set = append(set, context.displayName(name))
+			}
+		}
+		if len(set) > 1 {
+			return errorf(MsgConflicts, set[0], strings.Join(set[1:], ", "))
+		}
+		return nil
+	}}
+}
+
+// RequiredTogether is satisfied if either none or all of names are set.
+func RequiredTogether(names ...string) Constraint {
+	return namedConstraint{names, func(context *ParseContext) error {
+		for _, name := range names {
+			if context.IsSet(name) {
+				return Requires(name, names...).Check(context)
+			}
+		}
+		return nil
+	}}
+}
+
+// AtLeastOne is satisfied if any of names is set.
+func AtLeastOne(names ...string) Constraint {
+	return namedConstraint{names, func(context *ParseContext) error {
+		all := make([]string, len(names))
+		for i, name := range names {
+			if context.IsSet(name) {
+				return nil
+			}
+			all[i] = context.displayName(name)
+		}
+		return errorf(MsgAtLeastOne, strings.Join(all, ", "))
+	}}
+}
+
+// ConstraintErrors holds every constraint violated by a parse.
+type ConstraintErrors []error
+
+func (c ConstraintErrors) Error() string {
+	out := make([]string, len(c))
+	for i, err := range c {
+		out[i] = err.Error()
+	}
+	return strings.Join(out, "; ")
+}
+
+// Constrain adds constraints, checked after parsing, between the flags and
+// arguments of the application. eg.
+//
+//     app.Constrain(
+//       kingpin.Requires("tls-cert", "tls-key"),
+//       kingpin.Conflicts("json", "quiet"),
+//       kingpin.AtLeastOne("file", "stdin"),
+//     )
+func (a *Application) Constrain(constraints ...Constraint) *Application {
+	a.constraints = append(a.constraints, constraints...)
+	return a
+}
+
+// Constrain adds constraints checked when this command is selected. They may
+// refer to the command's own flags and arguments and those of its parents.
+func (c *Cmd) Constrain(constraints ...Constraint) *Cmd {
+	c.constraints = append(c.constraints, constraints...)
+	return c
+}
+
+// MutuallyExclusive adds a constraint that at most one of the named flags is
+// given, and notes the constraint in the help of each flag. eg.
+//
+//     app.MutuallyExclusive("json", "yaml", "table")
+func (a *Application) MutuallyExclusive(names ...string) *Application {
+	a.mutuallyExclusive(names)
+	return a
+}
+
+// MutuallyExclusive adds a constraint that at most one of the named flags is
+// given when this command is selected, and notes the constraint in the help
+// of each flag.
+func (c *Cmd) MutuallyExclusive(names ...string) *Cmd {
+	c.mutuallyExclusive(names)
+	return c
+}
+
+// RequireTogether adds a constraint that the named flags are either all
+// given or not at all, and notes the constraint in the help of each flag.
+// eg.
+//
+//     app.RequireTogether("username", "password")
+func (a *Application) RequireTogether(names ...string) *Application {
+	a.requireTogether(names)
+	return a
+}
+
+// RequireTogether adds a constraint that the named flags are either all
+// given or not at all when this command is selected, and notes the
+// constraint in the help of each flag.
+func (c *Cmd) RequireTogether(names ...string) *Cmd {
+	c.requireTogether(names)
+	return c
+}
+
+// A relationship between flags, noted in the help of each.
+type flagRelation struct {
+	names []string
+	note  string // eg. "Mutually exclusive with"
+}
+
+func (c *cmdMixin) mutuallyExclusive(names []string) {
+	c.constraints = append(c.constraints, Conflicts(names...))
+	c.relations = append(c.relations, flagRelation{names, "Mutually exclusive with"})
+}
+
+func (c *cmdMixin) requireTogether(names []string) {
+	c.constraints = append(c.constraints, RequiredTogether(names...))
+	c.relations = append(c.relations, flagRelation{names, "Must be given with"})
+}
+
+// Note each relationship between flags in the help of its flags.
+func (c *cmdMixin) annotateRelations() {
+	for _, relation := range c.relations {
+		for _, name := range relation.names {
+			flag, ok := c.flagGroup.long[name]
+			if !ok {
+				continue
+			}
+			others := []string{}
+			for _, other := range relation.names {
+				if other != name {
+					others = append(others, "--"+other)
+				}
+			}
+			flag.help = appendHelp(flag.help, relation.note+" "+strings.Join(others, ", ")+".")
+		}
+	}
+}
+
+// Check the constraints of the application and each selected command,
+// reporting all violations together.
+func (a *Application) checkConstraints(context *ParseContext) error {
+	constraints := a.constraints
+	for _, element := range context.Elements {
+		if cmd, ok := element.Clause.(*Cmd); ok {
+			constraints = append(constraints, cmd.constraints...)
+		}
+	}
+	errs := ConstraintErrors{}
+	for _, err := range context.occurrenceErrors() {
+		errs = append(errs, a.localize(err))
+	}
+	for _, constraint := range constraints {
+		if err := constraint.Check(context); err != nil {
+			errs = append(errs, a.localize(err))
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return errs
+}
+
+// Check that the constraints of c, and of its subcommands, only name flags
+// and arguments defined by c or its parents in scope, as a constraint naming
+// anything else would never be satisfied or violated.
+func checkConstraintNames(c *cmdMixin, scope []*cmdMixin) error {
+	scope = append(scope[:len(scope):len(scope)], c)
+	for _, constraint := range c.constraints {
+		named, ok := constraint.(namedConstraint)
+		if !ok {
+			continue
+		}
+		for _, name := range named.names {
+			if !definedIn(scope, name) {
+				return fmt.Errorf("constraint refers to unknown flag or argument %q", name)
+			}
+		}
+	}
+	for _, cmd := range c.cmdGroup.commandOrder {
+		if err := checkConstraintNames(&cmd.cmdMixin, scope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Whether any of scope defines a flag or argument with the given name.
+func definedIn(scope []*cmdMixin, name string) bool {
+	for _, c := range scope {
+		if c.flagGroup.long[name] != nil {
+			return true
+		}
+		for _, arg := range c.argGroup.args {
+			if arg.name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// How the flag or argument with the given name is referred to in errors.
+func (p *ParseContext) displayName(name string) string {
+	if clause, _ := p.lookup(name); clause != nil {
+		if _, ok := clause.(*ArgClause); ok {
+			return "<" + name + ">"
+		}
+	}
+	return "--" + name
+}
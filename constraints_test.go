@@ -0,0 +1,139 @@
+package kingpin
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func constraintsApp() *Application {
+	app := newTestApp()
+	app.Flag("tls-cert", "").String()
+	app.Flag("tls-key", "").String()
+	app.Flag("json", "").Bool()
+	app.Flag("quiet", "").Bool()
+	app.Flag("file", "").String()
+	app.Flag("stdin", "").Bool()
+	app.Constrain(
+		Requires("tls-cert", "tls-key"),
+		Conflicts("json", "quiet"),
+		AtLeastOne("file", "stdin"),
+	)
+	return app
+}
+
+func TestConstraints(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"--stdin"}, ""},
+		{[]string{"--file=x", "--tls-cert=c", "--tls-key=k"}, ""},
+		{[]string{"--stdin", "--tls-cert=c"}, "--tls-cert requires --tls-key"},
+		{[]string{"--stdin", "--json", "--quiet"}, "--json conflicts with --quiet"},
+		{[]string{}, "at least one of --file, --stdin is required"},
+		{[]string{"--json", "--quiet"}, "--json conflicts with --quiet; at least one of --file, --stdin is required"},
+	} {
+		_, err := constraintsApp().Parse(test.args)
+		if test.err == "" {
+			assert.NoError(t, err, "%v", test.args)
+		} else {
+			assert.EqualError(t, err, test.err, "%v", test.args)
+		}
+	}
+}
+
+func TestCommandConstraints(t *testing.T) {
+	app := newTestApp()
+	app.Flag("verbose", "").Bool()
+	cmd := app.Command("copy", "")
+	cmd.Arg("src", "").String()
+	cmd.Flag("recursive", "").Bool()
+	cmd.Constrain(Requires("recursive", "src", "verbose"))
+	app.Command("other", "")
+
+	_, err := app.Parse([]string{"copy", "--recursive"})
+	assert.EqualError(t, err, "--recursive requires <src>, --verbose")
+	_, err = app.Parse([]string{"--verbose", "copy", "--recursive", "dir"})
+	assert.NoError(t, err)
+	_, err = app.Parse([]string{"other"})
+	assert.NoError(t, err)
+}
+
+func TestMutuallyExclusive(t *testing.T) {
+	app := newTestApp()
+	list := app.Command("list", "")
+	list.Flag("json", "JSON output.").Bool()
+	list.Flag("yaml", "").Bool()
+	list.Flag("table", "").Bool()
+	list.MutuallyExclusive("json", "yaml", "table")
+
+	_, err := app.Parse([]string{"list", "--json"})
+	assert.NoError(t, err)
+	_, err = app.Parse([]string{"list", "--json", "--table"})
+	assert.EqualError(t, err, "--json conflicts with --table")
+	assert.Equal(t, "JSON output. Mutually exclusive with --yaml, --table.", list.GetFlag("json").help)
+	assert.Equal(t, "Mutually exclusive with --json, --table.", list.GetFlag("yaml").help)
+}
+
+func TestRequireTogether(t *testing.T) {
+	app := newTestApp()
+	login := app.Command("login", "")
+	login.Flag("username", "User name.").String()
+	login.Flag("password", "").String()
+	login.RequireTogether("username", "password")
+
+	_, err := app.Parse([]string{"login"})
+	assert.NoError(t, err)
+	_, err = app.Parse([]string{"login", "--password=x"})
+	assert.EqualError(t, err, "--password requires --username")
+	_, err = app.Parse([]string{"login", "--username=u", "--password=x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "User name. Must be given with --password.", login.GetFlag("username").help)
+}
+
+func TestOccurrences(t *testing.T) {
+	newApp := func() *Application {
+		app := newTestApp()
+		app.Flag("tag", "Tags.").MaxOccurrences(2).Strings()
+		app.Arg("file", "Files to process.").MinOccurrences(1).MaxOccurrences(3).Strings()
+		return app
+	}
+
+	_, err := newApp().Parse([]string{"a"})
+	assert.NoError(t, err)
+	_, err = newApp().Parse([]string{})
+	assert.EqualError(t, err, "<file> requires at least 1 values, got 0")
+	_, err = newApp().Parse([]string{"a", "b", "c", "d", "--tag=x", "--tag=y", "--tag=z"})
+	assert.EqualError(t, err, "--tag accepts at most 2 values, got 3; <file> accepts at most 3 values, got 4")
+
+	app := newApp()
+	model := app.Model()
+	assert.Equal(t, "Files to process. Between 1 and 3 values.", model.Args[0].HelpWithOptions())
+	assert.Equal(t, "<file>", model.ArgSummary())
+	assert.Equal(t, "Tags. At most 2 values.", app.GetFlag("tag").Model().HelpWithOptions())
+}
+
+func TestOccurrencesRequireRepeatableValue(t *testing.T) {
+	app := newTestApp()
+	app.Flag("name", "").MinOccurrences(1).String()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "--name: MinOccurrences() and MaxOccurrences() require a repeatable value")
+}
+
+func TestConstraintsRequireKnownNames(t *testing.T) {
+	app := newTestApp()
+	app.Flag("json", "").Bool()
+	app.Constrain(Conflicts("json", "yml"))
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, `constraint refers to unknown flag or argument "yml"`)
+
+	app = newTestApp()
+	app.Flag("cert", "").String()
+	serve := app.Command("serve", "")
+	serve.Flag("key", "").String()
+	serve.Constrain(Requires("cert", "key"))
+	app.Command("other", "").MutuallyExclusive("key", "cert")
+	_, err = app.Parse([]string{"serve"})
+	assert.EqualError(t, err, `constraint refers to unknown flag or argument "key"`)
+}
@@ -0,0 +1,31 @@
+package kingpin
+
+import (
+	"fmt"
+	"text/tabwriter"
+)
+
+// Print every flag in scope with its resolved value and where that value
+// came from, redacting secrets.
+func (a *Application) debugFlags(context *ParseContext) error {
+	w := tabwriter.NewWriter(a.errorWriter, 0, 8, 2, ' ', 0)
+	for _, flag := range context.flags.flagOrder {
+		if flag.hidden {
+			continue
+		}
+		source := context.sourceOf(flag)
+		if source == SourceNone {
+			fmt.Fprintf(w, "--%s\t\t(unset)\n", flag.name)
+			continue
+		}
+		value := flag.value.String()
+		if value != "" {
+			var ok bool
+			if value, ok = a.redact(flag, value); !ok {
+				value = "(redacted)"
+			}
+		}
+		fmt.Fprintf(w, "--%s\t%s\t(%s)\n", flag.name, value, source)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,27 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDebugFlags(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().ErrorWriter(w)
+	app.Flag("user", "").Default("root").String()
+	app.Flag("password", "").Envar("TEST_PASSWORD").Secret().String()
+	app.Flag("host", "").String()
+	app.Flag("port", "").Int()
+	t.Setenv("TEST_PASSWORD", "hunter2")
+
+	_, err := app.Parse([]string{"--debug-flags", "--host=example.com"})
+	assert.NoError(t, err)
+	assert.Equal(t, `--help                   (unset)
+--user      root         (default)
+--password  ******       (envar)
+--host      example.com  (args)
+--port                   (unset)
+`, w.String())
+}
@@ -0,0 +1,67 @@
+package kingpin
+
+import (
+	"fmt"
+)
+
+type defaultFrom struct {
+	name    string
+	compute func(value string) string
+}
+
+// DefaultFrom derives the flag's default from the resolved value of another
+// flag or argument in scope. compute may be nil, in which case the value is
+// used as-is. eg.
+//
+//     app.Flag("cache-dir", "").DefaultFrom("data-dir", func(v string) string {
+//       return filepath.Join(v, "cache")
+//     })
+//
+// Derived defaults are resolved after all other values are known, in
+// dependency order. Envars and profiles still take precedence over them.
+func (f *FlagClause) DefaultFrom(name string, compute func(value string) string) *FlagClause {
+	if compute == nil {
+		compute = func(value string) string { return value }
+	}
+	f.defaultFrom = &defaultFrom{name: name, compute: compute}
+	return f
+}
+
+// Resolve derived defaults for flags that were not otherwise set.
+func (a *Application) setDerivedDefaults(context *ParseContext) error {
+	// 0: unvisited, 1: resolving, 2: resolved.
+	state := map[*FlagClause]int{}
+	var resolve func(flag *FlagClause) error
+	resolve = func(flag *FlagClause) error {
+		switch state[flag] {
+		case 1:
+			return fmt.Errorf("cyclic DefaultFrom() involving --%s", flag.name)
+		case 2:
+			return nil
+		}
+		state[flag] = 1
+		clause, value := context.lookup(flag.defaultFrom.name)
+		if clause == nil {
+			return fmt.Errorf("--%s derives its default from unknown flag or argument '%s'", flag.name, flag.defaultFrom.name)
+		}
+		if source, ok := clause.(*FlagClause); ok && source.defaultFrom != nil && context.sourceOf(source) == SourceNone {
+			if err := resolve(source); err != nil {
+				return err
+			}
+		}
+		if err := flag.set(flag.defaultFrom.compute(value.String()), false); err != nil {
+			return err
+		}
+		context.setSource(flag, SourceDefault)
+		state[flag] = 2
+		return nil
+	}
+	for _, flag := range context.flags.flagOrder {
+		if flag.defaultFrom != nil && context.sourceOf(flag) == SourceNone {
+			if err := resolve(flag); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package kingpin
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDefaultFrom(t *testing.T) {
+	app := newTestApp()
+	logDir := app.Flag("log-dir", "").DefaultFrom("cache-dir", func(v string) string { return filepath.Join(v, "logs") }).String()
+	cacheDir := app.Flag("cache-dir", "").DefaultFrom("data-dir", func(v string) string { return filepath.Join(v, "cache") }).String()
+	dataDir := app.Flag("data-dir", "").Default("/var/lib/app").String()
+
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "/var/lib/app", *dataDir)
+	assert.Equal(t, "/var/lib/app/cache", *cacheDir)
+	assert.Equal(t, "/var/lib/app/cache/logs", *logDir)
+
+	_, err = app.Parse([]string{"--data-dir=/data"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/cache/logs", *logDir)
+
+	_, err = app.Parse([]string{"--data-dir=/data", "--cache-dir=/tmp"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/tmp/logs", *logDir)
+}
+
+func TestDefaultFromEnvarTakesPrecedence(t *testing.T) {
+	app := newTestApp()
+	app.Flag("data-dir", "").Default("/data").String()
+	cacheDir := app.Flag("cache-dir", "").Envar("TEST_CACHE_DIR").DefaultFrom("data-dir", nil).String()
+	t.Setenv("TEST_CACHE_DIR", "/cache")
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "/cache", *cacheDir)
+}
+
+func TestDefaultFromCycle(t *testing.T) {
+	app := newTestApp()
+	app.Flag("a", "").DefaultFrom("b", nil).String()
+	app.Flag("b", "").DefaultFrom("a", nil).String()
+	_, err := app.Parse([]string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cyclic DefaultFrom()")
+}
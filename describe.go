@@ -0,0 +1,236 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MarshalJSON encodes the flag along with its value type and, for enums,
+// the permitted options.
+func (f *FlagModel) MarshalJSON() ([]byte, error) {
+	short := ""
+	if f.Short != 0 {
+		short = string(f.Short)
+	}
+	return json.Marshal(struct {
+		Name        string   `json:"name"`
+		Short       string   `json:"short,omitempty"`
+		Help        string   `json:"help,omitempty"`
+		Type        string   `json:"type"`
+		Enum        []string `json:"enum,omitempty"`
+		Default     []string `json:"default,omitempty"`
+		Envar       string   `json:"envar,omitempty"`
+		Fallbacks   []string `json:"envarFallbacks,omitempty"`
+		PlaceHolder string   `json:"placeholder,omitempty"`
+		Required    bool     `json:"required,omitempty"`
+		Repeatable  bool     `json:"repeatable,omitempty"`
+		Hidden      bool     `json:"hidden,omitempty"`
+		Deprecated  string   `json:"deprecated,omitempty"`
+		Secret      bool     `json:"secret,omitempty"`
+		HelpLevel   int      `json:"helpLevel,omitempty"`
+	}{
+		Name:        f.Name,
+		Short:       short,
+		Help:        f.Help,
+		Type:        valueTypeName(f.Value),
+		Enum:        enumOptions(f.Value),
+		Default:     f.Default,
+		Envar:       f.Envar,
+		Fallbacks:   f.EnvarFallbacks,
+		PlaceHolder: f.PlaceHolder,
+		Required:    f.Required,
+		Repeatable:  isCumulative(f.Value),
+		Hidden:      f.Hidden,
+		Deprecated:  f.Deprecated,
+		Secret:      f.Secret,
+		HelpLevel:   f.HelpLevel,
+	})
+}
+
+// MarshalJSON encodes the argument along with its value type and, for enums,
+// the permitted options.
+func (a *ArgModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name        string   `json:"name"`
+		Help        string   `json:"help,omitempty"`
+		Type        string   `json:"type"`
+		Enum        []string `json:"enum,omitempty"`
+		Default     []string `json:"default,omitempty"`
+		Envar       string   `json:"envar,omitempty"`
+		Fallbacks   []string `json:"envarFallbacks,omitempty"`
+		PlaceHolder string   `json:"placeholder,omitempty"`
+		Required    bool     `json:"required,omitempty"`
+		Repeatable  bool     `json:"repeatable,omitempty"`
+		Hidden      bool     `json:"hidden,omitempty"`
+		Deprecated  string   `json:"deprecated,omitempty"`
+	}{
+		Name:        a.Name,
+		Help:        a.Help,
+		Type:        valueTypeName(a.Value),
+		Enum:        enumOptions(a.Value),
+		Default:     a.Default,
+		Envar:       a.Envar,
+		Fallbacks:   a.EnvarFallbacks,
+		PlaceHolder: a.PlaceHolder,
+		Required:    a.Required,
+		Repeatable:  isCumulative(a.Value),
+		Hidden:      a.Hidden,
+		Deprecated:  a.Deprecated,
+	})
+}
+
+// A short name for the type of value accepted by a Value.
+func valueTypeName(value Value) string {
+	switch value.(type) {
+	case *enumValue, *enumsValue:
+		return "enum"
+	case *counterValue:
+		return "counter"
+	}
+	if name, ok := registeredValueTypeName(value); ok {
+		return name
+	}
+	if typ := valueType(value); typ != nil {
+		return typ.String()
+	}
+	return "string"
+}
+
+func enumOptions(value Value) []string {
+	switch value := value.(type) {
+	case *enumValue:
+		return value.options
+	case *enumsValue:
+		return value.options
+	}
+	return nil
+}
+
+func isCumulative(value Value) bool {
+	r, ok := value.(repeatableFlag)
+	return ok && r.IsCumulative()
+}
+
+// The JSON description of a command, as written by --help-json and
+// __describe.
+type commandDescription struct {
+	Name        string       `json:"name"`
+	FullCommand string       `json:"fullCommand"`
+	Help        string       `json:"help,omitempty"`
+	Aliases     []string     `json:"aliases,omitempty"`
+	Hidden      bool         `json:"hidden,omitempty"`
+	Deprecated  string       `json:"deprecated,omitempty"`
+	Default     bool         `json:"default,omitempty"`
+	Category    string       `json:"category,omitempty"`
+	HelpLevel   int          `json:"helpLevel,omitempty"`
+	Examples    []Example    `json:"examples,omitempty"`
+	Flags       []*FlagModel `json:"flags"`
+	GlobalFlags []*FlagModel `json:"globalFlags,omitempty"` // Only set by __describe.
+	Args        []*ArgModel  `json:"args"`
+	Commands    []*CmdModel  `json:"commands,omitempty"`
+}
+
+// MarshalJSON encodes the command along with its flags, arguments, examples
+// and sub-commands.
+func (c *CmdModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.description())
+}
+
+func (c *CmdModel) description() *commandDescription {
+	return &commandDescription{
+		Name:        c.Name,
+		FullCommand: c.FullCommand,
+		Help:        c.Help,
+		Aliases:     c.Aliases,
+		Hidden:      c.Hidden,
+		Deprecated:  c.Deprecated,
+		Default:     c.Default,
+		Category:    c.Category,
+		HelpLevel:   c.HelpLevel,
+		Examples:    c.Examples,
+		Flags:       nonNilFlags(c.FlagGroupModel),
+		Args:        nonNilArgs(c.ArgGroupModel),
+		Commands:    c.CmdGroupModel.Commands,
+	}
+}
+
+// MarshalJSON encodes the entire application: its metadata, flags, arguments
+// and the full command tree.
+func (a *ApplicationModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Name      string       `json:"name"`
+		Help      string       `json:"help,omitempty"`
+		Version   string       `json:"version,omitempty"`
+		Author    string       `json:"author,omitempty"`
+		Authors   []string     `json:"authors,omitempty"`
+		Copyright string       `json:"copyright,omitempty"`
+		SourceURL string       `json:"sourceURL,omitempty"`
+		Examples  []Example    `json:"examples,omitempty"`
+		Flags     []*FlagModel `json:"flags"`
+		Args      []*ArgModel  `json:"args"`
+		Commands  []*CmdModel  `json:"commands,omitempty"`
+	}{
+		Name:      a.Name,
+		Help:      a.Help,
+		Version:   a.Version,
+		Author:    a.Author,
+		Authors:   a.Authors,
+		Copyright: a.Copyright,
+		SourceURL: a.SourceURL,
+		Examples:  a.Examples,
+		Flags:     nonNilFlags(a.FlagGroupModel),
+		Args:      nonNilArgs(a.ArgGroupModel),
+		Commands:  a.CmdGroupModel.Commands,
+	})
+}
+
+func nonNilFlags(model *FlagGroupModel) []*FlagModel {
+	if model == nil || model.Flags == nil {
+		return []*FlagModel{}
+	}
+	return model.Flags
+}
+
+func nonNilArgs(model *ArgGroupModel) []*ArgModel {
+	if model == nil || model.Args == nil {
+		return []*ArgModel{}
+	}
+	return model.Args
+}
+
+// Implements the hidden --help-json flag, which writes the JSON description
+// of the whole application to the output writer.
+func (a *Application) generateHelpJSON(c *ParseContext) error {
+	enc := json.NewEncoder(a.outputWriter)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(a.Model()); err != nil {
+		return err
+	}
+	a.terminate(0)
+	return nil
+}
+
+// Implements the hidden "__describe [<command>...]" entry point, which writes
+// the JSON description of the given command, as in --help-json, along with
+// the flags inherited from its parents. If no command is given, the
+// application is described as by --help-json.
+func (a *Application) describe(path []string) error {
+	var description interface{} = a.Model()
+	flags, cmds := a.flagGroup, a.cmdGroup
+	globals := []*FlagModel{}
+	for i, name := range path {
+		cmd := cmds.GetCommand(name)
+		if cmd == nil {
+			return fmt.Errorf("unknown command '%s'", strings.Join(path[:i+1], " "))
+		}
+		globals = append(globals, flags.Model().Flags...)
+		flags, cmds = cmd.flagGroup, cmd.cmdGroup
+		command := cmd.Model().description()
+		command.GlobalFlags = globals
+		description = command
+	}
+	enc := json.NewEncoder(a.outputWriter)
+	enc.SetIndent("", "  ")
+	return enc.Encode(description)
+}
@@ -0,0 +1,137 @@
+package kingpin
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDescribeCommand(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	app.Flag("debug", "Debug.").Bool()
+	deploy := app.Command("deploy", "Deploy a service.").Alias("d")
+	deploy.Flag("region", "Region.").Short('r').Required().Enum("eu", "us")
+	deploy.Flag("tag", "Tags.").Strings()
+	deploy.Flag("timeout", "Timeout.").Default("1m").Duration()
+	deploy.Arg("service", "Service.").Required().String()
+	app.Command("status", "Show status.").Command("all", "All services.")
+
+	_, err := app.Parse([]string{"__describe", "deploy"})
+	assert.NoError(t, err)
+
+	actual := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(w.Bytes(), &actual))
+	expected := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"name": "deploy",
+		"fullCommand": "deploy",
+		"help": "Deploy a service.",
+		"aliases": ["d"],
+		"flags": [
+			{"name": "region", "short": "r", "help": "Region.", "type": "enum", "enum": ["eu", "us"], "required": true},
+			{"name": "tag", "help": "Tags.", "type": "string", "repeatable": true},
+			{"name": "timeout", "help": "Timeout.", "type": "time.Duration", "default": ["1m"]}
+		],
+		"globalFlags": [
+			{"name": "help", "short": "h", "help": "Output usage information.", "type": "bool"},
+			{"name": "help-long", "help": "Generate long help.", "type": "bool", "hidden": true},
+			{"name": "help-all", "help": "Generate long help, including all advanced flags and commands.", "type": "bool", "hidden": true},
+			{"name": "help-man", "help": "Generate a man page.", "type": "bool", "hidden": true},
+			{"name": "help-values", "help": "Generate help showing the resolved value and source of each flag.", "type": "bool", "hidden": true},
+			{"name": "help-json", "help": "Generate a JSON description of the application.", "type": "bool", "hidden": true},
+			{"name": "completion-bash", "help": "Output possible completions for the given args.", "type": "bool", "hidden": true},
+			{"name": "completion-descriptions", "help": "Include descriptions in completions, separated by a tab.", "type": "bool", "hidden": true},
+			{"name": "completion-script-bash", "help": "Generate completion script for bash.", "type": "bool", "hidden": true},
+			{"name": "completion-script-zsh", "help": "Generate completion script for ZSH.", "type": "bool", "hidden": true},
+			{"name": "completion-script-fish", "help": "Generate completion script for fish.", "type": "bool", "hidden": true},
+			{"name": "completion-script-powershell", "help": "Generate completion script for PowerShell.", "type": "bool", "hidden": true},
+			{"name": "debug-flags", "help": "Print the resolved value and source of every flag.", "type": "bool", "hidden": true},
+			{"name": "debug", "help": "Debug.", "type": "bool"}
+		],
+		"args": [{"name": "service", "help": "Service.", "type": "string", "required": true}]
+	}`), &expected))
+	assert.Equal(t, expected, actual)
+
+	w.Reset()
+	_, err = app.Parse([]string{"__describe", "status"})
+	assert.NoError(t, err)
+	assert.Contains(t, w.String(), `"commands": [
+    {
+      "name": "all",
+      "fullCommand": "status all",
+      "help": "All services.",
+      "flags": [],
+      "args": []
+    }
+  ]`)
+}
+
+func TestDescribeMatchesHelpJSON(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	app.Flag("debug", "Debug.").Bool()
+	app.Command("deploy", "Deploy a service.").Arg("service", "Service.").String()
+
+	_, err := app.Parse([]string{"--help-json"})
+	assert.NoError(t, err)
+	helpJSON := w.String()
+
+	w.Reset()
+	_, err = app.Parse([]string{"__describe"})
+	assert.NoError(t, err)
+	assert.Equal(t, helpJSON, w.String())
+}
+
+func TestDescribeUnknownCommand(t *testing.T) {
+	app := newTestApp().OutputWriter(&bytes.Buffer{})
+	app.Command("deploy", "")
+	_, err := app.Parse([]string{"__describe", "deploy", "nope"})
+	assert.EqualError(t, err, "unknown command 'deploy nope'")
+}
+
+func TestHelpJSON(t *testing.T) {
+	w := &bytes.Buffer{}
+	terminated := false
+	app := newTestApp().OutputWriter(w).Version("1.0")
+	app.Terminate(func(int) { terminated = true })
+	app.Flag("debug", "Debug.").Envar("APP_DEBUG").Bool()
+	remote := app.Command("remote", "Manage remotes.")
+	add := remote.Command("add", "Add a remote.").Example("test remote add origin", "Add origin.")
+	add.Flag("fetch", "Fetch.").Default("true").Bool()
+	add.Arg("name", "Name.").Required().String()
+
+	_, err := app.Parse([]string{"--help-json"})
+	assert.NoError(t, err)
+	assert.True(t, terminated)
+
+	actual := struct {
+		Name     string
+		Version  string
+		Flags    []map[string]interface{}
+		Commands []struct {
+			Name     string
+			Commands []struct {
+				FullCommand string
+				Examples    []Example
+				Flags       []map[string]interface{}
+				Args        []map[string]interface{}
+			}
+		}
+	}{}
+	assert.NoError(t, json.Unmarshal(w.Bytes(), &actual))
+	assert.Equal(t, "test", actual.Name)
+	assert.Equal(t, "1.0", actual.Version)
+	debug := actual.Flags[len(actual.Flags)-1]
+	assert.Equal(t, "debug", debug["name"])
+	assert.Equal(t, "APP_DEBUG", debug["envar"])
+	remoteJSON := actual.Commands[len(actual.Commands)-1]
+	assert.Equal(t, "remote", remoteJSON.Name)
+	cmd := remoteJSON.Commands[0]
+	assert.Equal(t, "remote add", cmd.FullCommand)
+	assert.Equal(t, []Example{{Usage: "test remote add origin", Help: "Add origin."}}, cmd.Examples)
+	assert.Equal(t, []interface{}{"true"}, cmd.Flags[0]["default"])
+	assert.Equal(t, "name", cmd.Args[0]["name"])
+}
@@ -0,0 +1,90 @@
+package kingpin
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DotEnv loads environment variables from the given files, in KEY=VALUE
+// form, for use by flags and arguments with an Envar(), eg.
+//
+//     # .env
+//     export DATABASE_URL=postgres://localhost/dev
+//     API_TOKEN="s3cret"
+//
+//     app.DotEnv(".env")
+//
+// The process environment is not modified, and variables that are set in it
+// take precedence over the files. Earlier files take precedence over later
+// ones, and files that don't exist are ignored.
+func (a *Application) DotEnv(paths ...string) *Application {
+	a.dotEnv = append(a.dotEnv, paths...)
+	return a
+}
+
+// Load the DotEnv() files and make them available to every flag and argument.
+func (a *Application) applyDotEnv() error {
+	if len(a.dotEnv) == 0 {
+		return nil
+	}
+	env := map[string]string{}
+	for _, path := range a.dotEnv {
+		if err := readDotEnv(path, env); err != nil {
+			return err
+		}
+	}
+	a.eachCmdMixin(func(c *cmdMixin) {
+		for _, flag := range c.flagGroup.flagOrder {
+			flag.dotEnv = env
+		}
+		for _, arg := range c.argGroup.args {
+			arg.dotEnv = env
+		}
+	})
+	return nil
+}
+
+// Read the variables in path into env, without overriding existing entries.
+func readDotEnv(path string, env map[string]string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		i := strings.Index(line, "=")
+		if i <= 0 {
+			return fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			if value, err = strconv.Unquote(value); err != nil {
+				return fmt.Errorf("%s:%d: invalid quoted value for %s", path, n, key)
+			}
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		default:
+			// Strip trailing comments from unquoted values.
+			if j := strings.Index(value, " #"); j >= 0 {
+				value = strings.TrimSpace(value[:j])
+			}
+		}
+		if _, ok := env[key]; !ok {
+			env[key] = value
+		}
+	}
+	return scanner.Err()
+}
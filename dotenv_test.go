@@ -0,0 +1,55 @@
+package kingpin
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDotEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingpin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".env")
+	err = ioutil.WriteFile(path, []byte(`# Local development.
+export DATABASE_URL=postgres://localhost/dev
+API_TOKEN="s3cret\n"
+NAME='a # b'
+REGION=eu # comment
+TEST_DOTENV_SHADOWED=file
+`), 0600)
+	assert.NoError(t, err)
+	os.Setenv("TEST_DOTENV_SHADOWED", "process")
+	defer os.Unsetenv("TEST_DOTENV_SHADOWED")
+
+	app := newTestApp().DotEnv(path, filepath.Join(dir, "missing.env"))
+	url := app.Flag("database-url", "").Envar("DATABASE_URL").String()
+	token := app.Flag("token", "").Envar("API_TOKEN").String()
+	name := app.Flag("name", "").Envar("NAME").String()
+	region := app.Flag("region", "").Envar("REGION").String()
+	shadowed := app.Flag("shadowed", "").Envar("TEST_DOTENV_SHADOWED").String()
+	_, err = app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "postgres://localhost/dev", *url)
+	assert.Equal(t, "s3cret\n", *token)
+	assert.Equal(t, "a # b", *name)
+	assert.Equal(t, "eu", *region)
+	assert.Equal(t, "process", *shadowed)
+	_, set := os.LookupEnv("DATABASE_URL")
+	assert.False(t, set)
+}
+
+func TestDotEnvMalformed(t *testing.T) {
+	f, err := ioutil.TempFile("", "kingpin")
+	assert.NoError(t, err)
+	defer os.Remove(f.Name())
+	f.WriteString("VALID=1\nnot a variable\n")
+	f.Close()
+
+	app := newTestApp().DotEnv(f.Name())
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, f.Name()+":2: expected KEY=VALUE")
+}
@@ -0,0 +1,107 @@
+package kingpin
+
+import (
+	"fmt"
+	"io"
+)
+
+// Describe where the value of clause came from, for display.
+func (p *ParseContext) describeSource(clause interface{}) string {
+	switch p.sourceOf(clause) {
+	case SourceArgs:
+		return "from command line"
+	case SourceJSON:
+		return "from JSON"
+	case SourceEnvar:
+		envar := ""
+		switch clause := clause.(type) {
+		case *FlagClause:
+			envar, _ = clause.lookupEnvar()
+		case *ArgClause:
+			envar, _ = clause.lookupEnvar()
+		}
+		return "from $" + envar
+	case SourceProfile:
+		return "from profile " + p.profile
+	case SourceConfig:
+		if flag, ok := clause.(*FlagClause); ok {
+			return "from " + p.configFiles[flag.name]
+		}
+		return "from config file"
+	case SourceResolver:
+		return "from resolver"
+	case SourcePrompt:
+		return "from prompt"
+	case SourceDefault:
+		return "default"
+	}
+	return "unset"
+}
+
+// WriteEffectiveConfig writes every visible flag in scope with its resolved
+// value and where that value came from, eg.
+//
+//     --region=eu-west-1  (from $APP_REGION)
+//
+// Values of Secret() flags are redacted as configured with Redact().
+func (p *ParseContext) WriteEffectiveConfig(w io.Writer) error {
+	formatTwoColumns(w, 0, 2, guessWidth(w), p.effectiveFlags())
+	return nil
+}
+
+// A row for each visible flag in scope, with its value and source.
+func (p *ParseContext) effectiveFlags() [][2]string {
+	rows := [][2]string{}
+	for _, flag := range p.flags.flagOrder {
+		if flag.hidden || flag == p.app.HelpFlag {
+			continue
+		}
+		rows = append(rows, [2]string{"--" + flag.name + p.effectiveValue(flag), "(" + p.describeSource(flag) + ")"})
+	}
+	return rows
+}
+
+// "=VALUE" if flag has a value, redacted as configured with Redact().
+func (p *ParseContext) effectiveValue(flag *FlagClause) string {
+	if p.sourceOf(flag) == SourceNone {
+		return ""
+	}
+	value, ok := p.app.redact(flag, flag.value.String())
+	if !ok {
+		value = "(redacted)"
+	}
+	return "=" + value
+}
+
+// The flag column of --help-values, eg. "--region=eu-west-1 (from
+// $APP_REGION)".
+func (p *ParseContext) formatFlagValue(haveShort bool, model *FlagModel) string {
+	flag, ok := p.flags.long[model.Name]
+	if !ok {
+		return formatFlag(haveShort, model)
+	}
+	return formatFlagName(haveShort, model) + p.effectiveValue(flag) + " (" + p.describeSource(flag) + ")"
+}
+
+func (a *Application) generateValuesHelp(context *ParseContext) error {
+	context.helpValues = true
+	if err := a.renderUsage(a.outputWriter, context, 2, a.usageTemplateFor(context)); err != nil {
+		return err
+	}
+	a.terminate(0)
+	return nil
+}
+
+// ConfigCommand adds a "config" command with an "effective" subcommand that
+// shows the resolved value of every flag and where it came from, as a quick
+// view of the effective configuration.
+func (a *Application) ConfigCommand() *Cmd {
+	cmd := a.Command("config", "Inspect configuration.")
+	cmd.Command("effective", "Show the resolved value and source of every flag.").Action(func(context *ParseContext) error {
+		if err := context.WriteEffectiveConfig(a.outputWriter); err != nil {
+			return fmt.Errorf("writing effective configuration: %s", err)
+		}
+		return nil
+	})
+	return cmd
+}
@@ -0,0 +1,44 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestConfigEffective(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	app.Flag("region", "").Envar("TEST_APP_REGION").String()
+	app.Flag("timeout", "").Default("30s").Duration()
+	app.Flag("token", "").Secret().String()
+	app.Flag("debug", "").Bool()
+	app.Flag("name", "").String()
+	app.ConfigCommand()
+	t.Setenv("TEST_APP_REGION", "eu-west-1")
+
+	_, err := app.Parse([]string{"--token=abc", "config", "effective"})
+	assert.NoError(t, err)
+	assert.Equal(t, `--region=eu-west-1  (from $TEST_APP_REGION)
+--timeout=30s       (default)
+--token=******      (from command line)
+--debug             (unset)
+--name              (unset)
+`, w.String())
+}
+
+func TestHelpValues(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	app.Flag("region", "Region.").Short('r').Envar("TEST_APP_REGION").String()
+	app.Flag("timeout", "Timeout.").Default("30s").Duration()
+	app.Flag("name", "Name.").String()
+	t.Setenv("TEST_APP_REGION", "eu-west-1")
+
+	_, err := app.Parse([]string{"--help-values"})
+	assert.NoError(t, err)
+	assert.Contains(t, w.String(), "  -r, --region=eu-west-1 (from $TEST_APP_REGION)")
+	assert.Contains(t, w.String(), "      --timeout=30s (default)")
+	assert.Contains(t, w.String(), "      --name (unset)")
+}
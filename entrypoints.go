@@ -0,0 +1,32 @@
+package kingpin
+
+import (
+	"strings"
+)
+
+// Hidden entry points for tool integration, selected by the first
+// command-line argument. They run instead of normal parsing.
+var entryPoints = map[string]func(a *Application, args []string) error{
+	"__complete":      (*Application).complete,
+	"__complete-json": (*Application).completeJSON,
+	"__describe":      (*Application).describe,
+}
+
+// Run the hidden entry point selected by args, if any.
+func (a *Application) runEntryPoint(args []string) (handled bool, err error) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "__") {
+		return false, nil
+	}
+	entryPoint, ok := entryPoints[args[0]]
+	if !ok {
+		return false, nil
+	}
+	if err := a.init(); err != nil {
+		return true, err
+	}
+	if err := entryPoint(a, args[1:]); err != nil {
+		return true, err
+	}
+	a.terminate(0)
+	return true, nil
+}
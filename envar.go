@@ -12,8 +12,16 @@ var (
 )
 
 type envarMixin struct {
-	envar   string
-	noEnvar bool
+	envar     string
+	fallbacks []string          // Envars consulted in order if envar is not set.
+	dotEnv    map[string]string // Variables loaded by Application.DotEnv().
+	noEnvar   bool
+}
+
+func (e *envarMixin) setEnvar(name string, fallbacks []string) {
+	e.envar = name
+	e.fallbacks = fallbacks
+	e.noEnvar = false
 }
 
 func (e *envarMixin) HasEnvarValue() bool {
@@ -21,10 +29,24 @@ func (e *envarMixin) HasEnvarValue() bool {
 }
 
 func (e *envarMixin) GetEnvarValue() string {
+	_, value := e.lookupEnvar()
+	return value
+}
+
+// The name and value of the first of the envar and its fallbacks that is set.
+func (e *envarMixin) lookupEnvar() (name, value string) {
 	if e.noEnvar || e.envar == "" {
-		return ""
+		return "", ""
+	}
+	for _, name := range append([]string{e.envar}, e.fallbacks...) {
+		if value := os.Getenv(name); value != "" {
+			return name, value
+		}
+		if value := e.dotEnv[name]; value != "" {
+			return name, value
+		}
 	}
-	return os.Getenv(e.envar)
+	return "", ""
 }
 
 func (e *envarMixin) GetSplitEnvarValue() []string {
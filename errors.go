@@ -0,0 +1,167 @@
+package kingpin
+
+import (
+	"fmt"
+)
+
+// ErrorKind identifies a class of user-facing parse or validation error.
+type ErrorKind string
+
+// Kinds of user-facing errors. The arguments passed to each message format
+// are listed alongside.
+const (
+	MsgUnknownLongFlag      ErrorKind = "unknown-long-flag"      // flag
+	MsgUnknownShortFlag     ErrorKind = "unknown-short-flag"     // flag
+	MsgExpectedFlagArgument ErrorKind = "expected-flag-argument" // flag
+	MsgExpectedCommand      ErrorKind = "expected-command"       // token
+	MsgUnexpectedToken      ErrorKind = "unexpected-token"       // token
+	MsgUnexpectedArgument   ErrorKind = "unexpected-argument"    // token
+	MsgRequiredFlag         ErrorKind = "required-flag"          // flag name
+	MsgRequiredArgument     ErrorKind = "required-argument"      // arg name
+	MsgRepeatedFlag         ErrorKind = "repeated-flag"          // flag name
+	MsgSubcommandRequired   ErrorKind = "subcommand-required"    // command
+	MsgInvalidArgDefault    ErrorKind = "invalid-arg-default"    // value, arg name
+	MsgUnknownProfile       ErrorKind = "unknown-profile"        // profile, profiles
+	MsgUnknownOutputFormat  ErrorKind = "unknown-output-format"  // format, formats
+	MsgInvalidEnum          ErrorKind = "invalid-enum"           // options, value
+	MsgInvalidKeyValue      ErrorKind = "invalid-key-value"      // value
+	MsgInvalidIP            ErrorKind = "invalid-ip"             // value
+	MsgInvalidTCPAddr       ErrorKind = "invalid-tcp-addr"       // value, error
+	MsgInvalidURL           ErrorKind = "invalid-url"            // error
+	MsgPathNotExist         ErrorKind = "path-not-exist"         // path
+	MsgPathIsDir            ErrorKind = "path-is-dir"            // path
+	MsgPathIsFile           ErrorKind = "path-is-file"           // path
+	MsgInvalidNumber        ErrorKind = "invalid-number"         // value, example
+	MsgInvalidDuration      ErrorKind = "invalid-duration"       // value
+	MsgRequires             ErrorKind = "requires"               // flag, missing flags
+	MsgConflicts            ErrorKind = "conflicts"              // flag, conflicting flags
+	MsgAtLeastOne           ErrorKind = "at-least-one"           // flags
+	MsgInvalidFlagsJSON     ErrorKind = "invalid-flags-json"     // error
+	MsgInvalidConfigFile    ErrorKind = "invalid-config-file"    // path, error
+	MsgInvalidCount         ErrorKind = "invalid-count"          // value
+	MsgAmbiguousFlag        ErrorKind = "ambiguous-flag"         // flag, candidates
+	MsgInvalidByteSize      ErrorKind = "invalid-byte-size"      // value
+	MsgInvalidURLScheme     ErrorKind = "invalid-url-scheme"     // value, schemes
+	MsgInvalidCIDR          ErrorKind = "invalid-cidr"           // value
+	MsgInvalidHostPort      ErrorKind = "invalid-host-port"      // value
+	MsgInvalidTime          ErrorKind = "invalid-time"           // value, layouts
+	MsgNotMatching          ErrorKind = "not-matching"           // value, pattern
+	MsgPathNotReadable      ErrorKind = "path-not-readable"      // path
+	MsgPathNotWritable      ErrorKind = "path-not-writable"      // path
+	MsgCannotCreateDir      ErrorKind = "cannot-create-dir"      // path, error
+	MsgAliasLoop            ErrorKind = "alias-loop"             // alias
+	MsgInvalidFlagValue     ErrorKind = "invalid-flag-value"     // value, flag, error
+	MsgInvalidArgValue      ErrorKind = "invalid-arg-value"      // value, arg, error
+	MsgTooFewValues         ErrorKind = "too-few-values"         // flag, minimum, count
+	MsgTooManyValues        ErrorKind = "too-many-values"        // flag, maximum, count
+	MsgLocalFlag            ErrorKind = "local-flag"             // flag, command
+)
+
+// Messages maps each ErrorKind to a fmt format string.
+type Messages map[ErrorKind]string
+
+// DefaultMessages are the message formats used when an Application does not
+// override them with Messages().
+var DefaultMessages = Messages{
+	MsgUnknownLongFlag:      "unknown long flag '%s'",
+	MsgUnknownShortFlag:     "unknown short flag '%s'",
+	MsgExpectedFlagArgument: "expected argument for flag '%s'",
+	MsgExpectedCommand:      "expected command but got %q",
+	MsgUnexpectedToken:      "unexpected %s",
+	MsgUnexpectedArgument:   "unexpected argument '%s'",
+	MsgRequiredFlag:         "required flag --%s not provided",
+	MsgRequiredArgument:     "required argument '%s' not provided",
+	MsgRepeatedFlag:         "flag '%s' cannot be repeated",
+	MsgSubcommandRequired:   "must select a subcommand of '%s'",
+	MsgInvalidArgDefault:    "invalid default value '%s' for argument '%s'",
+	MsgUnknownProfile:       "unknown profile '%s', expected one of %s",
+	MsgUnknownOutputFormat:  "unknown output format '%s', expected one of %s",
+	MsgInvalidEnum:          "enum value must be one of %s, got '%s'",
+	MsgInvalidKeyValue:      "expected KEY=VALUE got '%s'",
+	MsgInvalidIP:            "'%s' is not an IP address",
+	MsgInvalidTCPAddr:       "'%s' is not a valid TCP address: %s",
+	MsgInvalidURL:           "invalid URL: %s",
+	MsgPathNotExist:         "path '%s' does not exist",
+	MsgPathIsDir:            "'%s' is a directory",
+	MsgPathIsFile:           "'%s' is a file",
+	MsgInvalidNumber:        "invalid number '%s', expected eg. %s",
+	MsgInvalidDuration:      "invalid duration '%s'",
+	MsgRequires:             "%s requires %s",
+	MsgConflicts:            "%s conflicts with %s",
+	MsgAtLeastOne:           "at least one of %s is required",
+	MsgInvalidFlagsJSON:     "invalid flag values JSON: %s",
+	MsgInvalidConfigFile:    "invalid config file '%s': %s",
+	MsgInvalidCount:         "invalid count '%s'",
+	MsgAmbiguousFlag:        "ambiguous flag '%s', could be %s",
+	MsgInvalidByteSize:      "invalid size '%s', expected eg. 512, 10K, 1.5GiB or 2GB",
+	MsgInvalidURLScheme:     "URL '%s' must use one of the schemes %s",
+	MsgInvalidCIDR:          "'%s' is not a CIDR network, eg. 10.0.0.0/8",
+	MsgInvalidHostPort:      "'%s' is not a host:port address",
+	MsgInvalidTime:          "invalid time '%s', expected one of the layouts %s, or a relative time such as -2h or yesterday",
+	MsgNotMatching:          "'%s' does not match the pattern %s",
+	MsgPathNotReadable:      "'%s' is not readable",
+	MsgPathNotWritable:      "'%s' is not writable",
+	MsgCannotCreateDir:      "cannot create directory '%s': %s",
+	MsgAliasLoop:            "alias '%s' expands to itself",
+	MsgInvalidFlagValue:     "invalid value '%s' for flag --%s: %s",
+	MsgInvalidArgValue:      "invalid value '%s' for argument '%s': %s",
+	MsgTooFewValues:         "%s requires at least %d values, got %d",
+	MsgTooManyValues:        "%s accepts at most %d values, got %d",
+	MsgLocalFlag:            "flag '%s' is not accepted after '%s'",
+}
+
+// Error is a user-facing parse or validation error. Its message is looked up
+// by Kind in the Application's Messages, falling back to DefaultMessages.
+type Error struct {
+	Kind ErrorKind
+	Args []interface{}
+	// Token is the offending command-line token, if any. It is highlighted
+	// when errors are colored.
+	Token string
+	// Hint is an optional suggestion shown on its own line after the error.
+	Hint string
+
+	messages Messages
+}
+
+// Kinds whose first argument is the offending command-line token.
+var tokenErrors = map[ErrorKind]bool{
+	MsgUnknownLongFlag:      true,
+	MsgUnknownShortFlag:     true,
+	MsgExpectedFlagArgument: true,
+	MsgExpectedCommand:      true,
+	MsgUnexpectedToken:      true,
+	MsgUnexpectedArgument:   true,
+	MsgAmbiguousFlag:        true,
+}
+
+func errorf(kind ErrorKind, args ...interface{}) *Error {
+	err := &Error{Kind: kind, Args: args}
+	if tokenErrors[kind] && len(args) > 0 {
+		err.Token = fmt.Sprint(args[0])
+	}
+	return err
+}
+
+func (e *Error) Error() string {
+	format, ok := e.messages[e.Kind]
+	if !ok {
+		format = DefaultMessages[e.Kind]
+	}
+	return fmt.Sprintf(format, e.Args...)
+}
+
+// Messages overrides the wording of user-facing errors returned by this
+// application. Kinds not present in messages use DefaultMessages.
+func (a *Application) Messages(messages Messages) *Application {
+	a.messages = messages
+	return a
+}
+
+// Attach the application's message catalog to err, if it is an *Error.
+func (a *Application) localize(err error) error {
+	if e, ok := err.(*Error); ok && a.messages != nil {
+		e.messages = a.messages
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package kingpin
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDefaultErrorMessages(t *testing.T) {
+	app := newTestApp()
+	app.Flag("name", "").Required().String()
+	_, err := app.Parse([]string{"--nope"})
+	assert.Error(t, err)
+	assert.Equal(t, "unknown long flag '--nope'", err.Error())
+	assert.Equal(t, MsgUnknownLongFlag, err.(*Error).Kind)
+
+	_, err = app.Parse([]string{})
+	assert.Equal(t, "required flag --name not provided", err.Error())
+}
+
+func TestOverrideErrorMessages(t *testing.T) {
+	app := newTestApp().Messages(Messages{
+		MsgUnknownLongFlag: "drapeau inconnu %s",
+	})
+	app.Flag("name", "").Required().String()
+	_, err := app.Parse([]string{"--nope"})
+	assert.Equal(t, "drapeau inconnu --nope", err.Error())
+
+	_, err = app.Parse([]string{})
+	assert.Equal(t, "required flag --name not provided", err.Error())
+}
+
+func TestValueErrorMessages(t *testing.T) {
+	app := newTestApp().Messages(Messages{
+		MsgInvalidEnum: "expected %s not %s",
+	})
+	app.Flag("colour", "").Enum("red", "blue")
+	_, err := app.Parse([]string{"--colour=green"})
+	assert.Equal(t, "expected red,blue not green", err.Error())
+}
+
+func TestSuggestions(t *testing.T) {
+	app := newTestApp()
+	app.Flag("verbose", "").Bool()
+	app.Flag("secret-thing", "").Hidden().Bool()
+	app.Command("status", "")
+	app.Command("remote", "").Alias("rmt")
+	app.Command("internal", "").Hidden()
+
+	for _, test := range []struct {
+		args    []string
+		message string
+		hint    string
+	}{
+		{[]string{"stauts"}, `expected command but got "stauts"`, "did you mean 'status'?"},
+		{[]string{"rmtt"}, `expected command but got "rmtt"`, "did you mean 'rmt'?"},
+		{[]string{"internl"}, `expected command but got "internl"`, ""},
+		{[]string{"xyz"}, `expected command but got "xyz"`, ""},
+		{[]string{"--verbos"}, "unknown long flag '--verbos'", "did you mean '--verbose'?"},
+		{[]string{"--secret-thin"}, "unknown long flag '--secret-thin'", ""},
+	} {
+		_, err := app.Parse(test.args)
+		assert.EqualError(t, err, test.message)
+		assert.Equal(t, test.hint, err.(*Error).Hint, "%v", test.args)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("status", "status"))
+	assert.Equal(t, 2, levenshtein("stauts", "status"))
+	assert.Equal(t, 3, levenshtein("kitten", "sitting"))
+	assert.Equal(t, 4, levenshtein("", "help"))
+}
+
+func TestErrorHandler(t *testing.T) {
+	stderr := &bytes.Buffer{}
+	status := -1
+	app := New("test", "").ErrorWriter(stderr).Terminate(func(code int) { status = code })
+	app.ErrorHandler(func(err error) (int, string) {
+		if e, ok := err.(*Error); ok {
+			return 64, "usage: " + e.Error()
+		}
+		return 3, ""
+	})
+	app.Flag("name", "").Required().String()
+	app.Action(func(*ParseContext) error { return errors.New("failed") })
+
+	app.MustParse([]string{})
+	assert.Equal(t, 64, status)
+	assert.Equal(t, "usage: required flag --name not provided\n", stderr.String())
+
+	stderr.Reset()
+	app.MustParse([]string{"--name=x"})
+	assert.Equal(t, 3, status)
+	assert.Equal(t, "", stderr.String())
+}
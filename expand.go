@@ -0,0 +1,56 @@
+package kingpin
+
+import (
+	"os"
+	"strings"
+)
+
+// ExpandDefaults expands environment variables and a leading "~" in the
+// default values of flags and arguments before they are parsed, eg.
+//
+//     app.ExpandDefaults()
+//     app.Flag("config", "Config file.").Default("${XDG_CONFIG_HOME}/app.yaml").String()
+//     app.Flag("cache", "Cache directory.").Default("~/.cache/app").ExistingDir()
+//
+// Both $VAR and ${VAR} are expanded, with unset variables expanding to the
+// empty string. Help shows the default values unexpanded.
+func (a *Application) ExpandDefaults() *Application {
+	a.expandDefaults = true
+	return a
+}
+
+// Mark every flag and argument as having its defaults expanded.
+func (a *Application) applyExpandDefaults() {
+	if !a.expandDefaults {
+		return
+	}
+	a.eachCmdMixin(func(c *cmdMixin) {
+		for _, flag := range c.flagGroup.flagOrder {
+			flag.expandDefaults = true
+		}
+		for _, arg := range c.argGroup.args {
+			arg.expandDefaults = true
+		}
+	})
+}
+
+// Expand environment variables and a leading "~" in each of values.
+func expandValues(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = expandHome(os.ExpandEnv(value))
+	}
+	return out
+}
+
+// Replace a leading "~" or "~/" with the user's home directory.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
@@ -0,0 +1,39 @@
+package kingpin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestExpandDefaults(t *testing.T) {
+	os.Setenv("KINGPIN_TEST_DIR", "/srv")
+	defer os.Unsetenv("KINGPIN_TEST_DIR")
+	home, err := os.UserHomeDir()
+	assert.NoError(t, err)
+
+	app := newTestApp().ExpandDefaults()
+	data := app.Flag("data", "").Default("${KINGPIN_TEST_DIR}/data").String()
+	cache := app.Flag("cache", "").Default("~/.cache/app").String()
+	literal := app.Flag("literal", "").Default("a~b").String()
+	out := app.Arg("out", "").Default("$KINGPIN_TEST_DIR/out").String()
+	_, err = app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "/srv/data", *data)
+	assert.Equal(t, home+"/.cache/app", *cache)
+	assert.Equal(t, "a~b", *literal)
+	assert.Equal(t, "/srv/out", *out)
+
+	// Help shows the defaults as written.
+	flag := app.GetFlag("data").Model()
+	assert.Equal(t, []string{"${KINGPIN_TEST_DIR}/data"}, flag.Default)
+}
+
+func TestDefaultsNotExpandedByDefault(t *testing.T) {
+	app := newTestApp()
+	path := app.Flag("path", "").Default("~/x").String()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "~/x", *path)
+}
@@ -0,0 +1,124 @@
+package kingpin
+
+import (
+	"fmt"
+	"io"
+)
+
+// EnableExplain adds an --explain flag that, rather than running anything,
+// writes the selected command, the resolved value and source of each flag
+// and argument, and the actions that would run to the output writer, then
+// exits, eg.
+//
+//     $ app deploy --replicas=3 --explain
+//     Command: deploy
+//
+//     Flags:
+//       --region=eu-west-1  (from $APP_REGION)
+//       --replicas=3        (from command line)
+//
+//     Actions:
+//       pre-action of the application
+//       action of command 'deploy'
+//
+// This helps to debug the interplay of flags, environment variables and
+// config files.
+func (a *Application) EnableExplain() *FlagClause {
+	a.explain = a.Flag("explain", "Show what would be run, and with which values, without running it.")
+	a.explain.Bool()
+	return a.explain
+}
+
+// Whether --explain was given, and help was not requested.
+func (a *Application) explaining(context *ParseContext) bool {
+	if a.explain == nil || context.sourceOf(a.explain) == SourceNone || context.clauseValue(a.explain, a.explain.value) != true {
+		return false
+	}
+	for _, element := range context.Elements {
+		if element.Clause == a.HelpFlag {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteExplanation writes the selected command, the values of the flags and
+// arguments in scope and the actions that would run to w. See
+// EnableExplain().
+func (p *ParseContext) WriteExplanation(w io.Writer) error {
+	command := "(none)"
+	if p.SelectedCommand != nil {
+		command = p.SelectedCommand.FullCommand()
+	}
+	fmt.Fprintf(w, "Command: %s\n", command)
+	if rows := p.effectiveFlags(); len(rows) > 0 {
+		fmt.Fprintf(w, "\nFlags:\n")
+		formatTwoColumns(w, 2, 2, guessWidth(w), rows)
+	}
+
+	rows := [][2]string{}
+	for _, arg := range p.arguments.args {
+		if arg.hidden {
+			continue
+		}
+		line := "<" + arg.name + ">"
+		if p.sourceOf(arg) != SourceNone {
+			line += "=" + arg.value.String()
+		}
+		rows = append(rows, [2]string{line, "(" + p.describeSource(arg) + ")"})
+	}
+	if len(rows) > 0 {
+		fmt.Fprintf(w, "\nArgs:\n")
+		formatTwoColumns(w, 2, 2, guessWidth(w), rows)
+	}
+
+	fmt.Fprintf(w, "\nActions:\n")
+	actions := p.explainActions()
+	if len(actions) == 0 {
+		fmt.Fprintf(w, "  (none)\n")
+	}
+	for _, action := range actions {
+		fmt.Fprintf(w, "  %s\n", action)
+	}
+	return nil
+}
+
+// Describe each action that would run, in the order documented on Action.
+func (p *ParseContext) explainActions() []string {
+	type owner struct {
+		name    string
+		actions *actionMixin
+	}
+	app := owner{"the application", &p.app.actionMixin}
+	values, commands := []owner{}, []owner{}
+	for _, element := range p.Elements {
+		switch clause := element.Clause.(type) {
+		case *FlagClause:
+			values = append(values, owner{"flag --" + clause.name, &clause.actionMixin})
+		case *ArgClause:
+			values = append(values, owner{"argument <" + clause.name + ">", &clause.actionMixin})
+		case *Cmd:
+			commands = append(commands, owner{"command '" + clause.FullCommand() + "'", &clause.actionMixin})
+		}
+	}
+	reversed := make([]owner, len(commands))
+	for i, command := range commands {
+		reversed[len(commands)-1-i] = command
+	}
+
+	out := []string{}
+	describe := func(kind string, owners []owner, actions func(owner) []Action) {
+		for _, owner := range owners {
+			for range actions(owner) {
+				out = append(out, kind+" of "+owner.name)
+			}
+		}
+	}
+	pre := func(o owner) []Action { return o.actions.preActions }
+	action := func(o owner) []Action { return o.actions.actions }
+	post := func(o owner) []Action { return o.actions.postActions }
+	describe("pre-action", append(append([]owner{app}, commands...), values...), pre)
+	describe("action", append(append([]owner{app}, values...), commands...), action)
+	describe("post-action", append(append(values, reversed...), app), post)
+	return out
+}
@@ -0,0 +1,41 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestExplain(t *testing.T) {
+	w := &bytes.Buffer{}
+	ran := false
+	app := newTestApp().OutputWriter(w)
+	app.EnableExplain()
+	app.Flag("region", "").Default("us-east-1").String()
+	app.PreAction(func(*ParseContext) error { ran = true; return nil })
+	deploy := app.Command("deploy", "").Action(func(*ParseContext) error { ran = true; return nil })
+	deploy.Arg("image", "").String()
+	app.Command("ls", "")
+
+	_, err := app.Parse([]string{"deploy", "--explain", "nginx"})
+	assert.NoError(t, err)
+	assert.False(t, ran)
+	assert.Equal(t, `Command: deploy
+
+Flags:
+  --explain=true      (from command line)
+  --region=us-east-1  (default)
+
+Args:
+  <image>=nginx  (from command line)
+
+Actions:
+  pre-action of the application
+  action of command 'deploy'
+`, w.String())
+
+	_, err = app.Parse([]string{"ls"})
+	assert.NoError(t, err)
+	assert.True(t, ran)
+}
@@ -0,0 +1,101 @@
+package kingpin
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ExternalCommands enables git-style external commands: executables on PATH
+// named "<prefix>-<command>" are added as top-level commands, eg. with the
+// prefix "app", an executable "app-deploy" can be run as
+//
+//     app deploy --env=prod
+//
+// All arguments after the command name are passed to the executable
+// unparsed, along with stdin and the application's output and error
+// writers. External commands are listed in help, and "app deploy --help" and
+// "app help deploy" show the external command's own help. If the executable
+// exits with a non-zero status, the application terminates with the same
+// status.
+//
+// Commands defined by the application take precedence over external ones.
+func (a *Application) ExternalCommands(prefix string) *Application {
+	a.externalPrefix = prefix
+	return a
+}
+
+// Add a command for each external command found on PATH.
+func (a *Application) discoverExternalCommands() {
+	if a.externalPrefix == "" {
+		return
+	}
+	prefix := a.externalPrefix + "-"
+	found := map[string]string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		entries, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, ".exe")
+			}
+			executable := runtime.GOOS == "windows" || entry.Mode()&0111 != 0
+			if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) || entry.IsDir() || !executable {
+				continue
+			}
+			name = strings.TrimPrefix(name, prefix)
+			if _, ok := found[name]; !ok && a.GetCommand(name) == nil {
+				found[name] = filepath.Join(dir, entry.Name())
+			}
+		}
+	}
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		cmd := a.Command(name, "Run the external command "+filepath.Base(found[name])+".")
+		cmd.external = found[name]
+	}
+}
+
+// Run the external command selected by args, if any.
+func (a *Application) runExternalCommand(ctx context.Context, args []string) (command string, ok bool, err error) {
+	if a.externalPrefix == "" {
+		return "", false, nil
+	}
+	// Errors are reported when the command line is parsed.
+	args, _, err = a.expandAliases(a.dispatchArgs(args))
+	if err != nil {
+		return "", false, nil
+	}
+	i := a.commandPosition(args)
+	if i < 0 {
+		return "", false, nil
+	}
+	rest := args[i+1:]
+	cmd := a.GetCommand(args[i])
+	if cmd == a.HelpCommand && cmd != nil && len(rest) > 0 {
+		// "app help <external>" shows the external command's help.
+		cmd, rest = a.GetCommand(rest[0]), []string{"--help"}
+	}
+	if cmd == nil || cmd.external == "" {
+		return "", false, nil
+	}
+	process := exec.CommandContext(ctx, cmd.external, rest...)
+	process.Stdin, process.Stdout, process.Stderr = a.stdin, a.outputWriter, a.errorWriter
+	err = process.Run()
+	if exit, ok := err.(*exec.ExitError); ok {
+		a.terminate(exit.ExitCode())
+	}
+	return cmd.name, true, err
+}
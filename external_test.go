@@ -0,0 +1,60 @@
+package kingpin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestExternalCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	script := "#!/bin/sh\necho \"deploy $*\"\n[ \"$1\" = fail ] && exit 3\nexit 0\n"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test-deploy"), []byte(script), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test-build"), []byte(script), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test-noexec"), []byte(script), 0644))
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	status := 0
+	out := &bytes.Buffer{}
+	app := New("test", "").Terminate(func(code int) { status = code }).Writer(out).OutputWriter(out)
+	app.ExternalCommands("test")
+	built := false
+	app.Command("build", "").Action(func(*ParseContext) error {
+		built = true
+		return nil
+	})
+
+	command, err := app.Parse([]string{"deploy", "--env=prod", "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy", command)
+	assert.Equal(t, "deploy --env=prod x\n", out.String())
+
+	out.Reset()
+	_, err = app.Parse([]string{"help", "deploy"})
+	assert.NoError(t, err)
+	assert.Equal(t, "deploy --help\n", out.String())
+
+	_, err = app.Parse([]string{"deploy", "fail"})
+	assert.Error(t, err)
+	assert.Equal(t, 3, status)
+
+	_, err = app.Parse([]string{"build"})
+	assert.NoError(t, err)
+	assert.True(t, built)
+
+	out.Reset()
+	app.Usage(nil)
+	assert.Contains(t, out.String(), "Run the external command test-deploy.")
+	assert.NotContains(t, out.String(), "noexec")
+}
@@ -0,0 +1,71 @@
+package kingpin
+
+import "strings"
+
+// Lines written by the bash and zsh completion protocol to request the
+// shell's own file or directory completion, see HintFiles().
+const (
+	completeFilesDirective = "__kingpin_complete_files "
+	completeDirsDirective  = "__kingpin_complete_dirs"
+)
+
+// HintFiles completes the value of the flag with the names of files matching
+// pattern, a shell glob such as "*.yaml", using the shell's own file
+// completion. An empty pattern matches all files. Directories are always
+// completed so that paths can be navigated.
+//
+// Like HintOptions(), this replaces any built-in completions.
+func (f *FlagClause) HintFiles(pattern string) *FlagClause {
+	f.hintFiles(pattern)
+	return f
+}
+
+// HintDirectories completes the value of the flag with the names of
+// directories, using the shell's own file completion.
+func (f *FlagClause) HintDirectories() *FlagClause {
+	f.hintDirectories = true
+	return f
+}
+
+// HintFiles completes the argument with the names of files matching
+// pattern. See FlagClause.HintFiles().
+func (a *ArgClause) HintFiles(pattern string) *ArgClause {
+	a.hintFiles(pattern)
+	return a
+}
+
+// HintDirectories completes the argument with the names of directories.
+func (a *ArgClause) HintDirectories() *ArgClause {
+	a.hintDirectories = true
+	return a
+}
+
+func (a *completionsMixin) hintFiles(pattern string) {
+	if pattern == "" {
+		pattern = "*"
+	}
+	a.hintGlob = pattern
+}
+
+// The directive requesting file completion, if any.
+func (a *completionsMixin) fileDirective() string {
+	switch {
+	case a.hintDirectories:
+		return completeDirsDirective
+	case a.hintGlob != "":
+		return completeFilesDirective + a.hintGlob
+	}
+	return ""
+}
+
+func isFileDirective(option string) bool {
+	return option == completeDirsDirective || strings.HasPrefix(option, completeFilesDirective)
+}
+
+// The candidate for a file directive, see HintFiles().
+func fileCandidate(directive string) Candidate {
+	if directive == completeDirsDirective {
+		return Candidate{Type: CandidateDirectories}
+	}
+	return Candidate{Value: strings.TrimPrefix(directive, completeFilesDirective), Type: CandidateFiles}
+}
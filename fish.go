@@ -0,0 +1,133 @@
+package kingpin
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var fishIdentRegexp = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+func (a *Application) generateFishCompletionScript(c *ParseContext) error {
+	if err := a.writeFishCompletion(a.outputWriter); err != nil {
+		return err
+	}
+	a.terminate(0)
+	return nil
+}
+
+// Write a fish completion script for the application. Unlike the bash and
+// zsh scripts, completions are static so they can include descriptions.
+func (a *Application) writeFishCompletion(w io.Writer) error {
+	model := a.Model()
+	prefix := "__fish_" + fishIdentRegexp.ReplaceAllString(a.Name, "_")
+	commands := []*CmdModel{}
+	var walk func(cmds []*CmdModel)
+	options := a.completionOpts
+	walk = func(cmds []*CmdModel) {
+		for _, cmd := range cmds {
+			if !cmd.Hidden || options.IncludeHidden {
+				commands = append(commands, cmd)
+				walk(cmd.Commands)
+			}
+		}
+	}
+	walk(model.Commands)
+
+	paths := make([]string, len(commands))
+	for i, cmd := range commands {
+		paths[i] = fishQuote(cmd.FullCommand)
+	}
+	fmt.Fprintf(w, "# fish completion for %s\n\n", a.Name)
+	fmt.Fprintf(w, "set -g %s_commands %s\n\n", prefix, strings.Join(paths, " "))
+	fmt.Fprintf(w, `# Print the command selected so far.
+function %[1]s_path
+    set -l path ""
+    for word in (commandline -opc)[2..-1]
+        set -l next (string trim -- "$path $word")
+        if contains -- $next $%[1]s_commands
+            set path $next
+        end
+    end
+    echo $path
+end
+
+# Is exactly the given command selected?
+function %[1]s_is
+    set -l path (%[1]s_path)
+    test "$path" = "$argv"
+end
+
+# Is the given command, or one of its subcommands, selected?
+function %[1]s_in
+    set -l path (%[1]s_path)
+    test "$path" = "$argv"; or string match -q -- "$argv *" "$path"
+end
+
+complete -c %[2]s -f
+`, prefix, fishQuote(a.Name))
+
+	writeFlags := func(condition string, flags []*FlagModel) {
+		for _, flag := range flags {
+			if flag.Hidden {
+				continue
+			}
+			line := "complete -c " + fishQuote(a.Name)
+			if condition != "" {
+				line += " -n " + fishQuote(condition)
+			}
+			line += " -l " + fishQuote(flag.Name)
+			if flag.Short != 0 {
+				line += " -s " + fishQuote(string(flag.Short))
+			}
+			if !flag.IsBoolFlag() {
+				if options := enumOptions(flag.Value); len(options) > 0 {
+					line += " -x -a " + fishQuote(strings.Join(options, " "))
+				} else if flag.HintDirectories {
+					line += " -x -a '(__fish_complete_directories)'"
+				} else if flag.HintFiles != "" {
+					line += " -r -F"
+				} else {
+					line += " -r"
+				}
+			}
+			if flag.Help != "" {
+				line += " -d " + fishQuote(flag.Help)
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	writeCommands := func(condition string, cmds []*CmdModel) {
+		for _, cmd := range cmds {
+			if cmd.Hidden && !options.IncludeHidden {
+				continue
+			}
+			names := []string{cmd.Name}
+			if options.IncludeAliases {
+				names = append(names, cmd.Aliases...)
+			}
+			for _, name := range names {
+				line := "complete -c " + fishQuote(a.Name) + " -n " + fishQuote(condition) + " -a " + fishQuote(name)
+				if cmd.Help != "" {
+					line += " -d " + fishQuote(cmd.Help)
+				}
+				fmt.Fprintln(w, line)
+			}
+		}
+	}
+
+	writeFlags("", model.Flags)
+	writeCommands(prefix+"_is ''", model.Commands)
+	for _, cmd := range commands {
+		writeFlags(prefix+"_in "+fishQuote(cmd.FullCommand), cmd.Flags)
+		writeCommands(prefix+"_is "+fishQuote(cmd.FullCommand), cmd.Commands)
+	}
+	return nil
+}
+
+// Quote s as a single-quoted fish string.
+func fishQuote(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return "'" + strings.Replace(s, "'", `\'`, -1) + "'"
+}
@@ -1,8 +1,12 @@
 package kingpin
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type flagGroup struct {
@@ -34,6 +38,31 @@ func (f *flagGroup) Flag(name, help string) *FlagClause {
 	return flag
 }
 
+// FlagGroup is a named section of flags, rendered as a separate block in
+// help.
+type FlagGroup struct {
+	title string
+	flags *flagGroup
+}
+
+// FlagGroup returns a named section that flags can be added to, eg.
+//
+//     conn := app.FlagGroup("Connection options")
+//     host := conn.Flag("host", "Server to connect to.").String()
+//
+// Flags in the section behave exactly like any other flag; only their
+// placement in help differs.
+func (f *flagGroup) FlagGroup(title string) *FlagGroup {
+	return &FlagGroup{title: title, flags: f}
+}
+
+// Flag defines a new flag in the section.
+func (g *FlagGroup) Flag(name, help string) *FlagClause {
+	flag := g.flags.Flag(name, help)
+	flag.group = g.title
+	return flag
+}
+
 func (f *flagGroup) init(defaultEnvarPrefix string) error {
 	if err := f.checkDuplicates(); err != nil {
 		return err
@@ -97,18 +126,38 @@ loop:
 					}
 					flag, ok = f.long[name]
 				}
+				if !ok && context.app != nil && context.app.abbreviations {
+					matches, negated := f.abbreviation(token.Value)
+					if len(matches) > 1 {
+						names := []string{}
+						for _, match := range matches {
+							names = append(names, "--"+match.name)
+						}
+						return nil, errorf(MsgAmbiguousFlag, flagToken, strings.Join(names, ", "))
+					}
+					if len(matches) == 1 {
+						flag, ok, invert = matches[0], true, negated
+					}
+				}
 				if !ok {
-					return nil, fmt.Errorf("unknown long flag '%s'", flagToken)
+					if context.collectUnknownFlag(flagToken) {
+						return nil, nil
+					}
+					return nil, errorf(MsgUnknownLongFlag, flagToken).suggest(flagToken.Value, "'--%s'", f.visibleNames())
 				}
 			} else {
 				flag, ok = f.short[name]
 				if !ok {
-					return nil, fmt.Errorf("unknown short flag '%s'", flagToken)
+					if context.collectUnknownFlag(flagToken) {
+						return nil, nil
+					}
+					return nil, errorf(MsgUnknownShortFlag, flagToken)
 				}
 			}
 
 			context.Next()
 
+			tokens := []*Token{flagToken}
 			fb, ok := flag.value.(boolFlag)
 			if ok && fb.IsBoolFlag() {
 				if invert {
@@ -116,21 +165,35 @@ loop:
 				} else {
 					defaultValue = "true"
 				}
+				// An inline value, eg. --verbose=false or /verbose:false.
+				if token = context.Peek(); token.Type == TokenArg && token.Index == flagToken.Index {
+					context.Next()
+					defaultValue = token.Value
+					if invert {
+						b, err := strconv.ParseBool(token.Value)
+						if err != nil {
+							return nil, errorf(MsgInvalidFlagValue, token.Value, flag.name, "expected true or false")
+						}
+						defaultValue = strconv.FormatBool(!b)
+					}
+					tokens = append(tokens, token)
+				}
 			} else {
 				if invert {
 					context.Push(token)
-					return nil, fmt.Errorf("unknown long flag '%s'", flagToken)
+					return nil, errorf(MsgUnknownLongFlag, flagToken)
 				}
 				token = context.Peek()
 				if token.Type != TokenArg {
 					context.Push(token)
-					return nil, fmt.Errorf("expected argument for flag '%s'", flagToken)
+					return nil, errorf(MsgExpectedFlagArgument, flagToken)
 				}
 				context.Next()
 				defaultValue = token.Value
+				tokens = append(tokens, token)
 			}
 
-			context.matchedFlag(flag, defaultValue)
+			context.matchedFlag(flag, defaultValue, tokens...)
 			return flag, nil
 
 		default:
@@ -140,18 +203,60 @@ loop:
 	return nil, nil
 }
 
+// The names of visible flags.
+func (f *flagGroup) visibleNames() []string {
+	names := []string{}
+	for _, flag := range f.flagOrder {
+		if !flag.hidden {
+			names = append(names, flag.name)
+		}
+	}
+	return names
+}
+
+// The visible flags that name abbreviates, and whether it is negated with
+// "no-".
+func (f *flagGroup) abbreviation(name string) (matches []*FlagClause, invert bool) {
+	withPrefix := func(prefix string) (out []*FlagClause) {
+		for _, flag := range f.flagOrder {
+			if !flag.hidden && strings.HasPrefix(flag.name, prefix) {
+				out = append(out, flag)
+			}
+		}
+		return
+	}
+	matches = withPrefix(name)
+	if len(matches) == 0 && strings.HasPrefix(name, "no-") {
+		matches, invert = withPrefix(name[3:]), true
+	}
+	return matches, invert
+}
+
 // FlagClause is a fluid interface used to build flags.
 type FlagClause struct {
 	parserMixin
 	actionMixin
 	completionsMixin
 	envarMixin
+	transformMixin
+	validateMixin
+	occurrenceMixin
 	name          string
 	shorthand     rune
 	help          string
 	defaultValues []string
 	placeholder   string
 	hidden        bool
+	secret        bool
+	deprecated    string
+	override      bool
+	local         bool // See Local().
+	builtin       bool // Controls kingpin itself, eg. --help; see Handler().
+	defaultFrom   *defaultFrom
+	group         string // Title of the FlagGroup, if any.
+	helpLevel     int    // See HelpLevel().
+	// Expand the environment in defaultValues, see Application.ExpandDefaults().
+	expandDefaults bool
 }
 
 func newFlag(name, help string) *FlagClause {
@@ -166,45 +271,90 @@ func (f *FlagClause) setDefault() error {
 	if f.HasEnvarValue() {
 		if v, ok := f.value.(repeatableFlag); !ok || !v.IsCumulative() {
 			// Use the value as-is
-			return f.value.Set(f.GetEnvarValue())
+			return f.setValue(f.GetEnvarValue())
 		} else {
-			for _, value := range f.GetSplitEnvarValue() {
-				if err := f.value.Set(value); err != nil {
-					return err
-				}
-			}
-			return nil
+			return f.setValues(f.GetSplitEnvarValue())
 		}
 	}
 
-	if len(f.defaultValues) > 0 {
-		for _, defaultValue := range f.defaultValues {
-			if err := f.value.Set(defaultValue); err != nil {
-				return err
-			}
+	return f.setStoredValues(f.defaults())
+}
+
+// The default values, expanded if enabled.
+func (f *FlagClause) defaults() []string {
+	if f.expandDefaults {
+		return expandValues(f.defaultValues)
+	}
+	return f.defaultValues
+}
+
+// Set each of values on the flag in turn.
+func (f *FlagClause) setValues(values []string) error {
+	for _, value := range values {
+		if err := f.setValue(value); err != nil {
+			return err
 		}
-		return nil
 	}
+	return nil
+}
 
+// Set each of values, which were not typed by the user, on the flag in turn.
+func (f *FlagClause) setStoredValues(values []string) error {
+	for _, value := range values {
+		if err := f.set(value, false); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+// Transform value then set it on the flag.
+func (f *FlagClause) setValue(value string) error {
+	return f.set(value, true)
+}
+
+func (f *FlagClause) set(value string, input bool) error {
+	transformed, err := f.transform(value, input)
+	if err == nil {
+		if verr := f.validate(transformed); verr != nil {
+			err = errorf(MsgInvalidFlagValue, transformed, f.name, verr)
+		}
+	}
+	if err == nil {
+		err = f.value.Set(transformed)
+	}
+	if err != nil && f.secret {
+		return maskSecret(err, value)
+	}
+	return err
+}
+
 func (f *FlagClause) needsValue() bool {
-	haveDefault := len(f.defaultValues) > 0
+	haveDefault := len(f.defaultValues) > 0 || f.defaultFrom != nil
 	return f.required && !(haveDefault || f.HasEnvarValue())
 }
 
 func (f *FlagClause) init() error {
-	if f.required && len(f.defaultValues) > 0 {
+	if f.required && (len(f.defaultValues) > 0 || f.defaultFrom != nil) {
 		return fmt.Errorf("required flag '--%s' with default value that will never be used", f.name)
 	}
+	if f.value == nil && f.unknownType != "" {
+		return unknownTypeError(f.unknownType, "--"+f.name)
+	}
+	if f.valueErr != nil {
+		return fmt.Errorf("--%s: %s", f.name, f.valueErr)
+	}
+	f.applyValueOptions()
 	if f.value == nil {
 		return fmt.Errorf("no type defined for --%s (eg. .String())", f.name)
 	}
+	if _, ok := f.value.(*optionalValue); ok && (len(f.defaultValues) > 0 || f.defaultFrom != nil) {
+		return fmt.Errorf("optional flag '--%s' with default value that would never leave it nil", f.name)
+	}
 	if v, ok := f.value.(repeatableFlag); (!ok || !v.IsCumulative()) && len(f.defaultValues) > 1 {
 		return fmt.Errorf("invalid default for '--%s', expecting single value", f.name)
 	}
-	return nil
+	return f.checkOccurrences(f.value, "--"+f.name)
 }
 
 // Dispatch to the given function after the flag is parsed and validated.
@@ -213,17 +363,38 @@ func (f *FlagClause) Action(action Action) *FlagClause {
 	return f
 }
 
+// ActionCtx is like Action(), but the callback also receives the
+// context.Context passed to Application.ParseWithContext().
+func (f *FlagClause) ActionCtx(action func(ctx context.Context, pc *ParseContext) error) *FlagClause {
+	f.addAction(withContext(action))
+	return f
+}
+
 func (f *FlagClause) PreAction(action Action) *FlagClause {
 	f.addPreAction(action)
 	return f
 }
 
+// PostAction is called after the command's actions if the flag was given,
+// whether or not they succeeded.
+func (f *FlagClause) PostAction(action Action) *FlagClause {
+	f.addPostAction(action)
+	return f
+}
+
 // HintAction registers a HintAction (function) for the flag to provide completions
 func (a *FlagClause) HintAction(action HintAction) *FlagClause {
 	a.addHintAction(action)
 	return a
 }
 
+// HintActionCtx registers a ContextHintAction for the flag to provide
+// completions that depend on the rest of the command line.
+func (a *FlagClause) HintActionCtx(action ContextHintAction) *FlagClause {
+	a.addContextHintAction(action)
+	return a
+}
+
 // HintOptions registers any number of options for the flag to provide completions
 func (a *FlagClause) HintOptions(options ...string) *FlagClause {
 	a.addHintAction(func() []string {
@@ -232,6 +403,80 @@ func (a *FlagClause) HintOptions(options ...string) *FlagClause {
 	return a
 }
 
+// OptionsLimit sets the number of enum options listed in help before the
+// list is truncated, overriding DefaultOptionsLimit. A negative limit lists
+// every option.
+func (a *FlagClause) OptionsLimit(limit int) *FlagClause {
+	a.optionsLimit = limit
+	return a
+}
+
+// Common durations offered as completions for ExtendedDuration() values.
+var extendedDurationHints = []string{"1h", "12h", "1d", "7d", "2w", "30d", "90d", "1y"}
+
+func (a *FlagClause) ExtendedDuration() (target *time.Duration) {
+	a.addHintActionBuiltin(func() []string {
+		return extendedDurationHints
+	})
+	return a.parserMixin.ExtendedDuration()
+}
+
+func (a *FlagClause) ExtendedDurationVar(target *time.Duration) {
+	a.parserMixin.ExtendedDurationVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return extendedDurationHints
+	})
+}
+
+// Common values offered as completions for network addresses.
+var (
+	ipHints       = []string{"127.0.0.1", "0.0.0.0", "::1", "::"}
+	cidrHints     = []string{"10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "0.0.0.0/0"}
+	hostPortHints = []string{"localhost:8080", "127.0.0.1:8080", "0.0.0.0:8080", ":8080"}
+)
+
+func (a *FlagClause) IP() (target *net.IP) {
+	a.addHintActionBuiltin(func() []string {
+		return ipHints
+	})
+	return a.parserMixin.IP()
+}
+
+func (a *FlagClause) IPVar(target *net.IP) {
+	a.parserMixin.IPVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return ipHints
+	})
+}
+
+func (a *FlagClause) CIDR() (target **net.IPNet) {
+	a.addHintActionBuiltin(func() []string {
+		return cidrHints
+	})
+	return a.parserMixin.CIDR()
+}
+
+func (a *FlagClause) CIDRVar(target **net.IPNet) {
+	a.parserMixin.CIDRVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return cidrHints
+	})
+}
+
+func (a *FlagClause) HostPort() (target *string) {
+	a.addHintActionBuiltin(func() []string {
+		return hostPortHints
+	})
+	return a.parserMixin.HostPort()
+}
+
+func (a *FlagClause) HostPortVar(target *string) {
+	a.parserMixin.HostPortVar(target)
+	a.addHintActionBuiltin(func() []string {
+		return hostPortHints
+	})
+}
+
 func (a *FlagClause) EnumVar(target *string, options ...string) {
 	a.parserMixin.EnumVar(target, options...)
 	a.addHintActionBuiltin(func() []string {
@@ -246,6 +491,43 @@ func (a *FlagClause) Enum(options ...string) (target *string) {
 	return a.parserMixin.Enum(options...)
 }
 
+// Transform registers a function to normalise values before they are parsed.
+// Transforms are applied in the order they are registered.
+func (f *FlagClause) Transform(transform Transformer) *FlagClause {
+	f.addTransform(transform)
+	return f
+}
+
+// Trim removes leading and trailing white space from values.
+func (f *FlagClause) Trim() *FlagClause {
+	return f.Transform(trimTransform)
+}
+
+// ExpandEnv replaces ${var} or $var in values with environment variables.
+func (f *FlagClause) ExpandEnv() *FlagClause {
+	return f.Transform(expandEnvTransform)
+}
+
+// ToLower converts values to lower case.
+func (f *FlagClause) ToLower() *FlagClause {
+	return f.Transform(toLowerTransform)
+}
+
+// CaseInsensitive makes an Enum() or Enums() flag match its options regardless
+// of case. The option is stored as it was declared, eg. with the options
+// "json" and "yaml", "--format=JSON" stores "json".
+func (f *FlagClause) CaseInsensitive() *FlagClause {
+	f.fold = true
+	return f
+}
+
+// KeyValueSeparator sets the separator between keys and values of map flags,
+// such as StringMap(), in place of the default of ":" or "=".
+func (f *FlagClause) KeyValueSeparator(separator string) *FlagClause {
+	f.separator = separator
+	return f
+}
+
 // Default values for this flag. They *must* be parseable by the value of the flag.
 func (f *FlagClause) Default(values ...string) *FlagClause {
 	f.defaultValues = values
@@ -260,9 +542,13 @@ func (f *FlagClause) OverrideDefaultFromEnvar(envar string) *FlagClause {
 // Envar overrides the default value(s) for a flag from an environment variable,
 // if it is set. Several default values can be provided by using new lines to
 // separate them.
-func (f *FlagClause) Envar(name string) *FlagClause {
-	f.envar = name
-	f.noEnvar = false
+//
+// Fallback environment variables may follow the name, and are consulted in
+// order if it is not set, eg.
+//
+//     app.Flag("token", "API token.").Envar("APP_TOKEN", "TOKEN").String()
+func (f *FlagClause) Envar(name string, fallbacks ...string) *FlagClause {
+	f.setEnvar(name, fallbacks)
 	return f
 }
 
@@ -270,6 +556,7 @@ func (f *FlagClause) Envar(name string) *FlagClause {
 // Most useful in conjunction with app.DefaultEnvars().
 func (f *FlagClause) NoEnvar() *FlagClause {
 	f.envar = ""
+	f.fallbacks = nil
 	f.noEnvar = true
 	return f
 }
@@ -288,6 +575,28 @@ func (f *FlagClause) Hidden() *FlagClause {
 	return f
 }
 
+// Deprecated hides the flag from usage and warns, with the given message,
+// whenever it is used, eg.
+//
+//     app.Flag("colour", "").Deprecated("use --color instead").Bool()
+func (f *FlagClause) Deprecated(message string) *FlagClause {
+	f.hidden = true
+	f.deprecated = message
+	return f
+}
+
+// Secret marks the flag as holding a sensitive value, such as a password or
+// token. Its value is masked wherever kingpin displays it, such as in help,
+// error messages and the output of --debug-flags.
+//
+// If a secret flag is Required() but not provided, and the application is
+// running on a terminal, the user is prompted for the value without echo
+// instead of the parse failing.
+func (f *FlagClause) Secret() *FlagClause {
+	f.secret = true
+	return f
+}
+
 // Required makes the flag required. You can not provide a Default() value to a Required() flag.
 func (f *FlagClause) Required() *FlagClause {
 	f.required = true
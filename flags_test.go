@@ -215,6 +215,24 @@ func TestEnvarOverrideDefault(t *testing.T) {
 	assert.Equal(t, "123", *flag)
 }
 
+func TestEnvarFallbacks(t *testing.T) {
+	os.Setenv("TEST_FALLBACK", "fallback")
+	defer os.Unsetenv("TEST_FALLBACK")
+	app := newTestApp()
+	flag := app.Flag("t", "").Default("default").Envar("TEST_PRIMARY", "TEST_FALLBACK").String()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", *flag)
+	name, _ := app.GetFlag("t").lookupEnvar()
+	assert.Equal(t, "TEST_FALLBACK", name)
+
+	os.Setenv("TEST_PRIMARY", "primary")
+	defer os.Unsetenv("TEST_PRIMARY")
+	_, err = app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "primary", *flag)
+}
+
 func TestFlagMultipleValuesDefault(t *testing.T) {
 	app := newTestApp()
 	a := app.Flag("a", "").Default("default1", "default2").Strings()
@@ -366,3 +384,26 @@ func TestCombinationEnumOptions(t *testing.T) {
 	assert.Equal(t, []string{"opt5", "opt6"}, args)
 
 }
+
+func TestAbbreviatedFlags(t *testing.T) {
+	app := newTestApp().AbbreviatedFlags()
+	verbose := app.Flag("verbose", "").Bool()
+	app.Flag("version-file", "").String()
+	colour := app.Flag("colour", "").Default("true").Bool()
+	output := app.Flag("output", "").String()
+
+	_, err := app.Parse([]string{"--verb", "--no-col", "--out=x"})
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+	assert.False(t, *colour)
+	assert.Equal(t, "x", *output)
+
+	_, err = app.Parse([]string{"--ver"})
+	assert.EqualError(t, err, "ambiguous flag '--ver', could be --verbose, --version-file")
+
+	_, err = app.Parse([]string{"--help-m"})
+	assert.EqualError(t, err, "unknown long flag '--help-m'")
+
+	_, err = newTestApp().Parse([]string{"--he"})
+	assert.EqualError(t, err, "unknown long flag '--he'")
+}
@@ -0,0 +1,50 @@
+package kingpin
+
+// Global makes the flag available to subcommands of the command (or
+// application) defining it, so that it is accepted both before and after
+// them, eg. "app --verbose deploy" and "app deploy --verbose". This is the
+// default, and undoes Local().
+//
+// In the help of a subcommand, inherited flags are listed under "Global
+// Flags:".
+func (f *FlagClause) Global() *FlagClause {
+	f.local = false
+	return f
+}
+
+// Local restricts the flag to the command (or application) defining it, so
+// that it must be given before any of its subcommands and is not listed in
+// their help.
+func (f *FlagClause) Local() *FlagClause {
+	f.local = true
+	return f
+}
+
+// Whether flag may be given at the current point of the command line. Local()
+// flags are only accepted until a subcommand of the command defining them is
+// selected.
+func (p *ParseContext) flagInScope(flag *FlagClause) bool {
+	if p == nil || flag == nil || !flag.local || p.app == nil {
+		return true
+	}
+	group := p.app.flagGroup
+	if p.SelectedCommand != nil {
+		group = p.SelectedCommand.flagGroup
+	}
+	return group.long[flag.name] == flag
+}
+
+// The model of the flags in scope, marking those inherited from parents of
+// the selected command.
+func (p *ParseContext) flagsModel() *FlagGroupModel {
+	m := &FlagGroupModel{}
+	for _, flag := range p.flags.flagOrder {
+		if !p.flagInScope(flag) {
+			continue
+		}
+		model := flag.Model()
+		model.Inherited = p.SelectedCommand != nil && p.SelectedCommand.flagGroup.long[flag.name] != flag
+		m.Flags = append(m.Flags, model)
+	}
+	return m
+}
@@ -0,0 +1,63 @@
+package kingpin
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestLocalFlag(t *testing.T) {
+	app := newTestApp()
+	verbose := app.Flag("verbose", "").Global().Bool()
+	profile := app.Flag("profile", "").Local().String()
+	deploy := app.Command("deploy", "")
+	force := deploy.Flag("force", "").Local().Bool()
+	deploy.Command("s3", "")
+
+	_, err := app.Parse([]string{"--profile=prod", "deploy", "--force", "s3", "--verbose"})
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", *profile)
+	assert.True(t, *force)
+	assert.True(t, *verbose)
+
+	_, err = app.Parse([]string{"deploy", "--profile=prod", "s3"})
+	assert.EqualError(t, err, "flag '--profile' is not accepted after 'deploy'")
+
+	_, err = app.Parse([]string{"deploy", "s3", "--force"})
+	assert.EqualError(t, err, "flag '--force' is not accepted after 'deploy s3'")
+}
+
+func TestGlobalFlagsInHelp(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).Terminate(nil)
+	app.Flag("verbose", "Verbose output.").Bool()
+	app.Flag("profile", "Profile to use.").Local().String()
+	deploy := app.Command("deploy", "")
+	deploy.Flag("force", "Force deployment.").Bool()
+	app.Parse([]string{"deploy", "--help"})
+	usage := buf.String()
+	flags := strings.Index(usage, "Flags:")
+	force := strings.Index(usage, "--force")
+	global := strings.Index(usage, "Global Flags:")
+	verbose := strings.Index(usage, "--verbose")
+	assert.True(t, flags >= 0 && flags < force && force < global && global < verbose, usage)
+	assert.NotContains(t, usage, "--profile")
+
+	buf.Reset()
+	app.Parse([]string{"--help"})
+	assert.NotContains(t, buf.String(), "Global Flags:")
+	assert.Contains(t, buf.String(), "--profile")
+}
+
+func TestLocalFlagCompletion(t *testing.T) {
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	app.Flag("verbose", "").Bool()
+	app.Flag("profile", "").Local().String()
+	app.Command("deploy", "")
+	_, err := app.Parse([]string{"__complete", "1", "deploy", "--"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--help\tOutput usage information.\n--verbose\n:0\n", w.String())
+}
@@ -0,0 +1,110 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// ParseJSON reads flag values from a JSON object mapping long flag names to
+// values, eg.
+//
+//     {"region": "eu-west-1", "tags": ["a", "b"], "force": true}
+//
+// This allows other programs to drive the application without shell quoting
+// hazards. Values apply to flags not given on the command line, and take
+// precedence over environment variables, profiles and defaults. Calling
+// ParseJSON more than once merges the objects.
+func (a *Application) ParseJSON(r io.Reader) error {
+	values, err := decodeFlagsJSON(r)
+	if err != nil {
+		return err
+	}
+	if a.jsonValues == nil {
+		a.jsonValues = map[string][]string{}
+	}
+	for flag, value := range values {
+		a.jsonValues[flag] = value
+	}
+	return nil
+}
+
+// FlagsJSONFlag adds a --flags-json flag that reads flag values from a file
+// as with ParseJSON(). "--flags-json=-" reads from stdin.
+func (a *Application) FlagsJSONFlag() *FlagClause {
+	a.FlagsJSON = a.builtinFlag("flags-json", "Read flag values from a JSON object in FILE (- for stdin).").PlaceHolder("FILE")
+	a.FlagsJSON.String()
+	return a.FlagsJSON
+}
+
+func decodeFlagsJSON(r io.Reader) (map[string][]string, error) {
+	object := map[string]interface{}{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&object); err != nil {
+		return nil, errorf(MsgInvalidFlagsJSON, err)
+	}
+	values := map[string][]string{}
+	for flag, value := range object {
+		v, err := jsonValues(value)
+		if err != nil {
+			return nil, errorf(MsgInvalidFlagsJSON, "--"+flag+": "+err.Error())
+		}
+		values[flag] = v
+	}
+	return values, nil
+}
+
+// Collect the flag values provided with ParseJSON() and --flags-json, the
+// latter taking precedence.
+func (a *Application) flagsJSONValues(context *ParseContext) (map[string][]string, error) {
+	values := map[string][]string{}
+	for flag, value := range a.jsonValues {
+		values[flag] = value
+	}
+	if a.FlagsJSON != nil {
+		for _, element := range context.Elements {
+			if element.Clause != a.FlagsJSON {
+				continue
+			}
+			file, err := a.openFlagsJSON(*element.Value)
+			if err != nil {
+				return nil, err
+			}
+			decoded, err := decodeFlagsJSON(file)
+			file.Close()
+			if err != nil {
+				return nil, err
+			}
+			for flag, value := range decoded {
+				values[flag] = value
+			}
+		}
+	}
+
+	// Every flag must be in scope.
+	names := make([]string, 0, len(values))
+	for flag := range values {
+		names = append(names, flag)
+	}
+	sort.Strings(names)
+	for _, flag := range names {
+		if _, ok := context.flags.long[flag]; !ok {
+			return nil, errorf(MsgUnknownLongFlag, "--"+flag)
+		}
+	}
+	return values, nil
+}
+
+func (a *Application) openFlagsJSON(path string) (io.ReadCloser, error) {
+	if path == "-" {
+		return ioutil.NopCloser(a.stdin), nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errorf(MsgInvalidFlagsJSON, err)
+	}
+	return file, nil
+}
@@ -0,0 +1,47 @@
+package kingpin
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestParseJSON(t *testing.T) {
+	app := newTestApp()
+	region := app.Flag("region", "").Default("us-east-1").String()
+	tags := app.Flag("tag", "").Strings()
+	force := app.Flag("force", "").Bool()
+	count := app.Flag("count", "").Int()
+	assert.NoError(t, app.ParseJSON(strings.NewReader(`{"region": "eu-west-1", "tag": ["a", ""], "force": true, "count": 3}`)))
+
+	os.Setenv("TEST_REGION_ENVAR", "ap-south-1")
+	defer os.Unsetenv("TEST_REGION_ENVAR")
+	app.GetFlag("region").Envar("TEST_REGION_ENVAR")
+
+	_, err := app.Parse([]string{"--count=5"})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", *region)
+	assert.Equal(t, []string{"a", ""}, *tags)
+	assert.True(t, *force)
+	assert.Equal(t, 5, *count)
+}
+
+func TestFlagsJSONFlag(t *testing.T) {
+	app := newTestApp()
+	app.FlagsJSONFlag()
+	name := app.Flag("name", "").String()
+	app.stdin = strings.NewReader(`{"name": "-- odd 'value'"}`)
+	_, err := app.Parse([]string{"--flags-json=-"})
+	assert.NoError(t, err)
+	assert.Equal(t, "-- odd 'value'", *name)
+
+	app.stdin = strings.NewReader(`{"nope": 1}`)
+	_, err = app.Parse([]string{"--flags-json=-"})
+	assert.EqualError(t, err, "unknown long flag '--nope'")
+
+	app.stdin = strings.NewReader(`[`)
+	_, err = app.Parse([]string{"--flags-json=-"})
+	assert.Error(t, err)
+}
@@ -20,6 +20,28 @@ func guessWidth(w io.Writer) int {
 		}
 	}
 
+	if cols, _ := terminalSize(w); cols > 0 {
+		return cols
+	}
+	return 80
+}
+
+// guessHeight returns the number of rows of the terminal w writes to, or 0
+// if w is not a terminal.
+func guessHeight(w io.Writer) int {
+	_, rows := terminalSize(w)
+	if rows == 0 {
+		return 0
+	}
+	if linesStr := os.Getenv("LINES"); linesStr != "" {
+		if lines, err := strconv.Atoi(linesStr); err == nil {
+			return lines
+		}
+	}
+	return rows
+}
+
+func terminalSize(w io.Writer) (cols, rows int) {
 	if t, ok := w.(*os.File); ok {
 		fd := t.Fd()
 		var dimensions [4]uint16
@@ -31,8 +53,8 @@ func guessWidth(w io.Writer) int {
 			uintptr(unsafe.Pointer(&dimensions)),
 			0, 0, 0,
 		); err == 0 {
-			return int(dimensions[1])
+			return int(dimensions[1]), int(dimensions[0])
 		}
 	}
-	return 80
+	return 0, 0
 }
@@ -0,0 +1,58 @@
+package kingpin
+
+// Help levels at which flags and commands are shown. See
+// FlagClause.HelpLevel().
+const (
+	// HelpLevelBasic flags and commands are shown by --help. This is the
+	// default.
+	HelpLevelBasic = 1
+	// HelpLevelLong flags and commands are also shown by --help-long.
+	HelpLevelLong = 2
+	// HelpLevelAll flags and commands are only shown by --help-all.
+	HelpLevelAll = 3
+)
+
+// HelpLevel sets the help level of the flag, so that advanced flags can be
+// left out of --help and progressively revealed by --help-long and
+// --help-all, eg.
+//
+//     app.Flag("gc-percent", "GC target percentage.").HelpLevel(kingpin.HelpLevelLong).Int()
+func (f *FlagClause) HelpLevel(level int) *FlagClause {
+	f.helpLevel = level
+	return f
+}
+
+// HelpLevel sets the help level of the command. See FlagClause.HelpLevel().
+func (c *Cmd) HelpLevel(level int) *Cmd {
+	c.helpLevel = level
+	return c
+}
+
+func (a *Application) generateAllHelp(c *ParseContext) error {
+	c.helpLevel = HelpLevelAll
+	return a.generateLongHelp(c)
+}
+
+// Hide the flags and commands above level.
+func (a *ApplicationModel) hideAbove(level int) {
+	a.FlagGroupModel.hideAbove(level)
+	a.CmdGroupModel.hideAbove(level)
+}
+
+func (f *FlagGroupModel) hideAbove(level int) {
+	for _, flag := range f.Flags {
+		if flag.HelpLevel > level {
+			flag.Hidden = true
+		}
+	}
+}
+
+func (c *CmdGroupModel) hideAbove(level int) {
+	for _, cmd := range c.Commands {
+		if cmd.HelpLevel > level {
+			cmd.Hidden = true
+		}
+		cmd.FlagGroupModel.hideAbove(level)
+		cmd.CmdGroupModel.hideAbove(level)
+	}
+}
@@ -0,0 +1,59 @@
+package kingpin
+
+import (
+	"fmt"
+)
+
+// HelpTopic is a page of long-form documentation shown by "help <name>".
+type HelpTopic struct {
+	Name  string `json:"name"`
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+}
+
+// HelpTopic adds a documentation page that isn't a command, such as a
+// description of the environment variables or config file format an
+// application uses. It is shown by "help <name>", and listed under
+// "Additional help topics:" in the application's usage, eg.
+//
+//     app.HelpTopic("environment", "Environment variables", `
+//     APP_HOME sets the directory state is kept in.
+//     `)
+//
+// Text is wrapped to the terminal like other help, with indented lines
+// preformatted. Help topics are only available to applications with
+// commands, and a command with the same name takes precedence.
+func (a *Application) HelpTopic(name, title, text string) *Application {
+	a.helpTopics = append(a.helpTopics, HelpTopic{Name: name, Title: title, Text: text})
+	return a
+}
+
+// The help topic named by the arguments of the help command, if any.
+func (a *Application) helpTopicFor(args []string) *HelpTopic {
+	if len(args) != 1 || a.GetCommand(args[0]) != nil {
+		return nil
+	}
+	for i, topic := range a.helpTopics {
+		if topic.Name == args[0] {
+			return &a.helpTopics[i]
+		}
+	}
+	return nil
+}
+
+func (a *Application) writeHelpTopic(topic *HelpTopic) {
+	w := a.usageWriter
+	width := a.terminalWidth
+	if width <= 0 {
+		width = guessWidth(w)
+	}
+	title := topic.Title
+	if title == "" {
+		title = topic.Name
+	}
+	if a.colorEnabled(w) {
+		title = a.helpTheme().Heading.apply(title)
+	}
+	fmt.Fprintf(w, "%s\n\n", title)
+	wrapText(w, topic.Text, "  ", "    ", width-2)
+}
@@ -0,0 +1,36 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestHelpTopic(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).Terminate(nil).Terminal(80)
+	app.HelpTopic("environment", "Environment variables", "TEST_HOME sets the directory state is kept in.\n\n    TEST_HOME=/tmp test run\n")
+	app.Command("run", "Run it.")
+
+	app.Parse([]string{"help", "environment"})
+	assert.Equal(t, "Environment variables\n\n  TEST_HOME sets the directory state is kept in.\n  \n    TEST_HOME=/tmp test run\n", buf.String())
+
+	buf.Reset()
+	app.Parse([]string{"--help"})
+	assert.Contains(t, buf.String(), "Additional help topics:\n\n    environment  Environment variables\n")
+
+	buf.Reset()
+	app.Parse([]string{"help", "run"})
+	assert.Contains(t, buf.String(), "Run it.")
+}
+
+func TestHelpTopicShadowedByCommand(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).Terminate(nil)
+	app.HelpTopic("run", "Running", "About running.")
+	app.Command("run", "Run it.")
+	app.Parse([]string{"help", "run"})
+	assert.Contains(t, buf.String(), "Run it.")
+	assert.NotContains(t, buf.String(), "About running.")
+}
@@ -0,0 +1,127 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Used to locate the per-user cache directory. Overridden in tests.
+var hintCacheDir = os.UserCacheDir
+
+// HintCached adds a HintAction whose results are remembered for ttl in a
+// per-user cache file, so that expensive hints such as those listing cloud
+// regions through an API don't slow down shell completion, eg.
+//
+//     app.Flag("region", "Region.").HintCached(time.Hour, listRegions).String()
+//
+// If the cache can't be read or written, fn is called every time.
+func (f *FlagClause) HintCached(ttl time.Duration, fn func() []string) *FlagClause {
+	f.addHintCache(ttl, fn)
+	return f
+}
+
+// HintCached adds a HintAction whose results are cached for ttl. See
+// FlagClause.HintCached().
+func (a *ArgClause) HintCached(ttl time.Duration, fn func() []string) *ArgClause {
+	a.addHintCache(ttl, fn)
+	return a
+}
+
+type hintCache struct {
+	ttl time.Duration
+	fn  func() []string
+	// Path of the cache file relative to the cache directory, set by
+	// Application.init().
+	file string
+}
+
+func (a *completionsMixin) addHintCache(ttl time.Duration, fn func() []string) {
+	cache := &hintCache{ttl: ttl, fn: fn}
+	a.hintCaches = append(a.hintCaches, cache)
+	a.addHintAction(cache.hints)
+}
+
+// Name the cache files of each HintCached() after the application and the
+// command and flag or argument it belongs to.
+func (a *Application) nameHintCaches() {
+	var walk func(c *cmdMixin, path string)
+	walk = func(c *cmdMixin, path string) {
+		for _, flag := range c.flagGroup.flagOrder {
+			nameHintCaches(flag.hintCaches, a.Name, path+"--"+flag.name)
+		}
+		for _, arg := range c.argGroup.args {
+			nameHintCaches(arg.hintCaches, a.Name, path+arg.name)
+		}
+		for _, cmd := range c.cmdGroup.commandOrder {
+			walk(&cmd.cmdMixin, path+cmd.name+".")
+		}
+	}
+	walk(&a.cmdMixin, "")
+}
+
+func nameHintCaches(caches []*hintCache, app, name string) {
+	for i, cache := range caches {
+		file := name
+		if i > 0 {
+			file = fmt.Sprintf("%s.%d", name, i)
+		}
+		cache.file = filepath.Join(safeFileName(app), safeFileName(file)+".json")
+	}
+}
+
+func safeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.', r == '_':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func (h *hintCache) hints() []string {
+	dir, err := hintCacheDir()
+	if err != nil || h.file == "" {
+		return h.fn()
+	}
+	path := filepath.Join(dir, "kingpin", h.file)
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < h.ttl {
+		if data, err := ioutil.ReadFile(path); err == nil {
+			hints := []string{}
+			if json.Unmarshal(data, &hints) == nil {
+				return hints
+			}
+		}
+	}
+	hints := h.fn()
+	storeHints(path, hints)
+	return hints
+}
+
+// Write the cache file atomically, so that concurrent completions never see
+// a partial file. Errors are ignored, leaving the cache stale.
+func storeHints(path string, hints []string) {
+	data, err := json.Marshal(hints)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".hints-")
+	if err != nil {
+		return
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil || os.Rename(tmp.Name(), path) != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,75 @@
+package kingpin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func withHintCacheDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	hintCacheDir = func() (string, error) { return dir, nil }
+	return dir, func() {
+		hintCacheDir = os.UserCacheDir
+		os.RemoveAll(dir)
+	}
+}
+
+func TestHintCached(t *testing.T) {
+	dir, cleanup := withHintCacheDir(t)
+	defer cleanup()
+
+	calls := 0
+	regions := func() []string {
+		calls++
+		return []string{"eu-west-1", "us-east-1"}
+	}
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	deploy := app.Command("deploy", "")
+	deploy.Flag("region", "").HintCached(time.Hour, regions).String()
+	deploy.Arg("zone", "").HintCached(0, regions).String()
+
+	for i := 0; i < 2; i++ {
+		w.Reset()
+		_, err := app.Parse([]string{"__complete", "2", "deploy", "--region", "eu"})
+		assert.NoError(t, err)
+		assert.Equal(t, "eu-west-1\n:0\n", w.String())
+	}
+	assert.Equal(t, 1, calls)
+	_, err := os.Stat(filepath.Join(dir, "kingpin", "test", "deploy.--region.json"))
+	assert.NoError(t, err)
+
+	// Expired entries are refreshed.
+	for i := 0; i < 2; i++ {
+		_, err := app.Parse([]string{"__complete", "1", "deploy", "us"})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, calls)
+}
+
+func TestHintCachedUnavailable(t *testing.T) {
+	hintCacheDir = func() (string, error) { return "", os.ErrNotExist }
+	defer func() { hintCacheDir = os.UserCacheDir }()
+
+	calls := 0
+	w := &bytes.Buffer{}
+	app := newTestApp().OutputWriter(w)
+	app.Flag("region", "").HintCached(time.Hour, func() []string {
+		calls++
+		return []string{"eu-west-1"}
+	}).String()
+	for i := 0; i < 2; i++ {
+		w.Reset()
+		_, err := app.Parse([]string{"__complete", "1", "--region"})
+		assert.NoError(t, err)
+		assert.Equal(t, "eu-west-1\n:0\n", w.String())
+	}
+	assert.Equal(t, 2, calls)
+}
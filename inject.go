@@ -0,0 +1,147 @@
+package kingpin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var (
+	errorType        = reflect.TypeOf((*error)(nil)).Elem()
+	contextType      = reflect.TypeOf((*context.Context)(nil)).Elem()
+	parseContextType = reflect.TypeOf((*ParseContext)(nil))
+	applicationType  = reflect.TypeOf((*Application)(nil))
+)
+
+// Provide registers a constructor for values that can be injected into the
+// parameters of ActionInjected() functions, eg.
+//
+//     dsn := app.Flag("dsn", "Database DSN.").Required().String()
+//     app.Provide(func() (*sql.DB, error) { return sql.Open("postgres", *dsn) })
+//
+//     app.Command("migrate", "Run migrations.").ActionInjected(func(db *sql.DB) error {
+//         return migrate(db)
+//     })
+//
+// The constructor must return a single value, optionally followed by an
+// error. Its own parameters are injected in the same way, and may also be
+// *kingpin.ParseContext, *kingpin.Application or context.Context (see
+// ParseWithContext()). Each constructor is called at most once per parse, and
+// only if a value it provides is needed.
+//
+// Invalid constructors, and parameters that no constructor provides, are
+// reported when the application is initialised.
+func (a *Application) Provide(constructor interface{}) *Application {
+	fn := reflect.ValueOf(constructor)
+	typ := fn.Type()
+	if typ.Kind() != reflect.Func || typ.NumOut() < 1 || typ.NumOut() > 2 || (typ.NumOut() == 2 && typ.Out(1) != errorType) {
+		a.provideErrs = append(a.provideErrs, fmt.Errorf("Provide() expects a function returning a value and optionally an error, got %T", constructor))
+		return a
+	}
+	provided := typ.Out(0)
+	if _, ok := a.providers[provided]; ok || isBuiltinInjectable(provided) {
+		a.provideErrs = append(a.provideErrs, fmt.Errorf("Provide(): %s is already provided", provided))
+		return a
+	}
+	if a.providers == nil {
+		a.providers = map[reflect.Type]reflect.Value{}
+	}
+	a.providers[provided] = fn
+	return a
+}
+
+// ActionInjected is like Action(), but the parameters of fn are resolved from
+// the constructors registered with Application.Provide() after parsing. fn
+// may return an error.
+func (c *Cmd) ActionInjected(fn interface{}) *Cmd {
+	value := reflect.ValueOf(fn)
+	typ := value.Type()
+	if typ.Kind() != reflect.Func || typ.NumOut() > 1 || (typ.NumOut() == 1 && typ.Out(0) != errorType) {
+		c.injectErrs = append(c.injectErrs, fmt.Errorf("%s: ActionInjected() expects a function returning nothing or an error, got %T", c.FullCommand(), fn))
+		return c
+	}
+	c.injected = append(c.injected, value)
+	return c.Action(func(context *ParseContext) error {
+		_, err := context.callInjected(value)
+		return err
+	})
+}
+
+func isBuiltinInjectable(typ reflect.Type) bool {
+	return typ == contextType || typ == parseContextType || typ == applicationType
+}
+
+// Check that every parameter of fn can be injected. seen holds the types
+// being constructed, to detect cycles.
+func (a *Application) checkInjectable(fn reflect.Type, seen []reflect.Type) error {
+	for i := 0; i < fn.NumIn(); i++ {
+		typ := fn.In(i)
+		if isBuiltinInjectable(typ) {
+			continue
+		}
+		for _, s := range seen {
+			if s == typ {
+				return fmt.Errorf("providers for %s depend on each other", typ)
+			}
+		}
+		provider, ok := a.providers[typ]
+		if !ok {
+			return fmt.Errorf("no provider for %s (see Provide())", typ)
+		}
+		if err := a.checkInjectable(provider.Type(), append(seen, typ)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Call fn with its parameters resolved from the application's providers,
+// returning its results with any trailing error split off.
+func (p *ParseContext) callInjected(fn reflect.Value) ([]reflect.Value, error) {
+	typ := fn.Type()
+	in := make([]reflect.Value, typ.NumIn())
+	for i := range in {
+		value, err := p.inject(typ.In(i))
+		if err != nil {
+			return nil, err
+		}
+		in[i] = value
+	}
+	out := fn.Call(in)
+	if n := len(out); n > 0 && typ.Out(n-1) == errorType {
+		if err, _ := out[n-1].Interface().(error); err != nil {
+			return nil, err
+		}
+		out = out[:n-1]
+	}
+	return out, nil
+}
+
+// The value of type typ, constructing it if it has not been already.
+func (p *ParseContext) inject(typ reflect.Type) (reflect.Value, error) {
+	switch typ {
+	case contextType:
+		ctx := p.Context()
+		return reflect.ValueOf(&ctx).Elem(), nil
+	case parseContextType:
+		return reflect.ValueOf(p), nil
+	case applicationType:
+		return reflect.ValueOf(p.app), nil
+	}
+	if value, ok := p.injected[typ]; ok {
+		return value, nil
+	}
+	provider, ok := p.app.providers[typ]
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("no provider for %s", typ)
+	}
+	out, err := p.callInjected(provider)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	if p.injected == nil {
+		p.injected = map[reflect.Type]reflect.Value{}
+	}
+	p.injected[typ] = out[0]
+	return out[0], nil
+}
@@ -0,0 +1,62 @@
+package kingpin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type testClient struct{ addr string }
+
+type testService struct{ client *testClient }
+
+func TestActionInjected(t *testing.T) {
+	app := newTestApp()
+	addr := app.Flag("addr", "").Default("localhost").String()
+	constructed := 0
+	app.Provide(func() *testClient {
+		constructed++
+		return &testClient{addr: *addr}
+	})
+	app.Provide(func(client *testClient) (*testService, error) {
+		return &testService{client: client}, nil
+	})
+
+	var got *testService
+	var gotClient *testClient
+	app.Command("run", "").ActionInjected(func(ctx context.Context, service *testService, client *testClient) error {
+		got, gotClient = service, client
+		return ctx.Err()
+	})
+
+	_, err := app.Parse([]string{"--addr=example.com", "run"})
+	assert.NoError(t, err)
+	assert.Equal(t, "example.com", got.client.addr)
+	assert.True(t, got.client == gotClient)
+	assert.Equal(t, 1, constructed)
+}
+
+func TestActionInjectedProviderError(t *testing.T) {
+	app := newTestApp()
+	app.Provide(func() (*testClient, error) { return nil, fmt.Errorf("connection refused") })
+	app.Command("run", "").ActionInjected(func(*testClient) {})
+	_, err := app.Parse([]string{"run"})
+	assert.EqualError(t, err, "connection refused")
+}
+
+func TestActionInjectedInitErrors(t *testing.T) {
+	app := newTestApp()
+	app.Provide("not a function")
+	app.Command("run", "").ActionInjected(func(*testService) {})
+	_, err := app.Parse([]string{"run"})
+	assert.EqualError(t, err, "Provide() expects a function returning a value and optionally an error, got string; ActionInjected(): no provider for *kingpin.testService (see Provide())")
+
+	app = newTestApp()
+	app.Provide(func(*testService) *testClient { return nil })
+	app.Provide(func(*testClient) *testService { return nil })
+	app.Command("run", "").ActionInjected(func(*testService) {})
+	_, err = app.Parse([]string{"run"})
+	assert.EqualError(t, err, "ActionInjected(): providers for *kingpin.testService depend on each other")
+}
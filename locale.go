@@ -0,0 +1,191 @@
+package kingpin
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// NumberFormat describes how numbers are written on the command line.
+type NumberFormat struct {
+	// Decimal separator, eg. '.' or ','.
+	Decimal rune
+	// Digit group separator, eg. ',', '.', ' ' or '\''. Zero disables grouping.
+	Group rune
+}
+
+// Common number formats.
+var (
+	NumberFormatEnglish  = NumberFormat{Decimal: '.', Group: ','}
+	NumberFormatEuropean = NumberFormat{Decimal: ',', Group: '.'}
+	NumberFormatSwiss    = NumberFormat{Decimal: '.', Group: '\''}
+)
+
+// example renders 1234.56 in this format.
+func (n NumberFormat) example() string {
+	if n.Group == 0 {
+		return fmt.Sprintf("1234%c56", n.Decimal)
+	}
+	return fmt.Sprintf("1%c234%c56", n.Group, n.Decimal)
+}
+
+// NumberFormat opts in to locale-aware parsing of numeric flags and
+// arguments. Numbers on the command line and in envars are accepted with the
+// given decimal and digit group separators, and durations additionally
+// accept the units "d" (24h) and "w" (7d), eg.
+//
+//     app.NumberFormat(kingpin.NumberFormatEuropean)
+//
+//     --ratio=1.234,56 --timeout=1w2d
+//
+// The accepted grammar is appended to the help of each affected flag.
+func (a *Application) NumberFormat(format NumberFormat) *Application {
+	a.numberFormat = &format
+	return a
+}
+
+// Add number and duration transforms to every numeric flag and argument.
+// Numbers are only localized in typed input; defaults and config files are
+// always written with a '.' decimal separator.
+func (a *Application) applyNumberFormat() {
+	if a.numberFormat == nil {
+		return
+	}
+	format := *a.numberFormat
+	a.eachCmdMixin(func(c *cmdMixin) {
+		for _, flag := range c.flagGroup.flagOrder {
+			if !isBoolValue(flag.value) {
+				flag.help = localizeClause(&flag.transformMixin, flag.value, flag.help, format)
+			}
+		}
+		for _, arg := range c.argGroup.args {
+			arg.help = localizeClause(&arg.transformMixin, arg.value, arg.help, format)
+		}
+	})
+}
+
+// Add the transform appropriate for value to t, returning the amended help.
+func localizeClause(t *transformMixin, value Value, help string, format NumberFormat) string {
+	typ := valueType(value)
+	if typ == nil {
+		return help
+	}
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		t.addTransform(parseExtendedDuration)
+		return appendHelp(help, "Durations accept the units ns, us, ms, s, m, h, d (24h), w (7d) and y (365d).")
+	}
+	// Only plain numbers; named numeric types such as units.Base2Bytes have
+	// their own syntax.
+	if typ.PkgPath() != "" {
+		return help
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		t.addInputTransform(func(value string) (string, error) {
+			return normalizeNumber(value, format)
+		})
+		return appendHelp(help, "Numbers are written as "+format.example()+".")
+	}
+	return help
+}
+
+// The type of a single element held by value, if it can be determined.
+func valueType(value Value) reflect.Type {
+	switch value := value.(type) {
+	case *accumulator:
+		return value.typ
+	case *optionalValue:
+		return value.typ
+	case Getter:
+		if v := value.Get(); v != nil {
+			return reflect.TypeOf(v)
+		}
+	}
+	return nil
+}
+
+func isBoolValue(value Value) bool {
+	b, ok := value.(boolFlag)
+	return ok && b.IsBoolFlag()
+}
+
+func appendHelp(help, note string) string {
+	if help == "" {
+		return note
+	}
+	return help + " " + note
+}
+
+// Convert a number written in format into the form accepted by strconv.
+func normalizeNumber(value string, format NumberFormat) (string, error) {
+	s := strings.TrimSpace(value)
+	out := make([]rune, 0, len(s))
+	runes := []rune(s)
+	seenDecimal := false
+	for i, r := range runes {
+		switch {
+		case r == format.Decimal && !seenDecimal:
+			seenDecimal = true
+			out = append(out, '.')
+
+		case r == format.Group && format.Group != 0 && !seenDecimal:
+			// Group separators must sit between digits and be followed by
+			// exactly three of them.
+			digits := 0
+			for j := i + 1; j < len(runes) && unicode.IsDigit(runes[j]); j++ {
+				digits++
+			}
+			if i == 0 || !unicode.IsDigit(runes[i-1]) || digits != 3 {
+				return "", errorf(MsgInvalidNumber, value, format.example())
+			}
+
+		default:
+			out = append(out, r)
+		}
+	}
+	return string(out), nil
+}
+
+var durationUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+	"y": 365 * 24 * time.Hour,
+}
+
+// Rewrite the "d", "w" and "y" units of an extended duration (eg. "1w2d3h")
+// into hours so the result can be parsed by time.ParseDuration.
+func parseExtendedDuration(value string) (string, error) {
+	s := strings.TrimSpace(value)
+	out := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		out, s = s[:1], s[1:]
+	}
+	for s != "" {
+		i := 0
+		for i < len(s) && (s[i] == '.' || (s[i] >= '0' && s[i] <= '9')) {
+			i++
+		}
+		j := i
+		for j < len(s) && !(s[j] == '.' || (s[j] >= '0' && s[j] <= '9')) {
+			j++
+		}
+		number, unit := s[:i], s[i:j]
+		s = s[j:]
+		scale, ok := durationUnits[unit]
+		if !ok {
+			out += number + unit
+			continue
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return "", errorf(MsgInvalidDuration, value)
+		}
+		out += strconv.FormatFloat(n*scale.Hours(), 'f', -1, 64) + "h"
+	}
+	return out, nil
+}
@@ -0,0 +1,73 @@
+package kingpin
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestNumberFormat(t *testing.T) {
+	app := newTestApp().NumberFormat(NumberFormatEuropean)
+	ratio := app.Flag("ratio", "Ratio.").Float64()
+	count := app.Flag("count", "").Int()
+	sizes := app.Flag("size", "").Int64List()
+	timeout := app.Flag("timeout", "").Duration()
+	_, err := app.Parse([]string{"--ratio=1.234,56", "--count=12.000", "--size=1.000", "--size=2", "--timeout=1w2d3h"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1234.56, *ratio)
+	assert.Equal(t, 12000, *count)
+	assert.Equal(t, []int64{1000, 2}, *sizes)
+	assert.Equal(t, 9*24*time.Hour+3*time.Hour, *timeout)
+
+	_, err = app.Parse([]string{"--count=1.00"})
+	assert.Error(t, err)
+	assert.Equal(t, "invalid number '1.00', expected eg. 1.234,56", err.Error())
+
+	assert.Equal(t, "Ratio. Numbers are written as 1.234,56.", app.GetFlag("ratio").help)
+}
+
+func TestNumberFormatOnlyAppliesToInput(t *testing.T) {
+	app := newTestApp().NumberFormat(NumberFormatEuropean)
+	ratio := app.Flag("ratio", "").Default("0.5").Float64()
+	scale := app.Flag("scale", "").Envar("TEST_SCALE").Default("1.5").Float64()
+	weight := app.Arg("weight", "").Default("2.5").Float64()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0.5, *ratio)
+	assert.Equal(t, 1.5, *scale)
+	assert.Equal(t, 2.5, *weight)
+
+	os.Setenv("TEST_SCALE", "0,25")
+	defer os.Unsetenv("TEST_SCALE")
+	_, err = app.Parse([]string{"--ratio=1.000,5"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.5, *ratio)
+	assert.Equal(t, 0.25, *scale)
+}
+
+func TestNumberFormatIsOptIn(t *testing.T) {
+	app := newTestApp()
+	count := app.Flag("count", "").Int()
+	_, err := app.Parse([]string{"--count=1,000"})
+	assert.Error(t, err)
+	_, err = app.Parse([]string{"--count=1000"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, *count)
+}
+
+func TestParseExtendedDuration(t *testing.T) {
+	for in, out := range map[string]string{
+		"1d":      "24h",
+		"1.5d":    "36h",
+		"2w":      "336h",
+		"-1d2h":   "-24h2h",
+		"1h30m":   "1h30m",
+		"1w1d1ms": "168h24h1ms",
+	} {
+		actual, err := parseExtendedDuration(in)
+		assert.NoError(t, err)
+		assert.Equal(t, out, actual)
+	}
+}
@@ -0,0 +1,407 @@
+package kingpin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Metadata included in generated documentation, such as the man page.
+type manMetadata struct {
+	section   string
+	date      time.Time
+	authors   []string
+	copyright string
+	sourceURL string
+	seeAlso   []string
+}
+
+// ManSection sets the manual section of the generated man page. Defaults
+// to "1" (user commands).
+func (a *Application) ManSection(section string) *Application {
+	a.man.section = section
+	return a
+}
+
+// Date sets the date of the generated documentation, typically the release
+// date.
+func (a *Application) Date(date time.Time) *Application {
+	a.man.date = date
+	return a
+}
+
+// Authors lists the authors shown in generated documentation. If not
+// called, the Author() is used.
+func (a *Application) Authors(authors ...string) *Application {
+	a.man.authors = authors
+	return a
+}
+
+// Copyright sets the copyright notice shown in generated documentation.
+func (a *Application) Copyright(copyright string) *Application {
+	a.man.copyright = copyright
+	return a
+}
+
+// SourceURL sets the URL of the application's source, shown in generated
+// documentation.
+func (a *Application) SourceURL(url string) *Application {
+	a.man.sourceURL = url
+	return a
+}
+
+// SeeAlso lists related pages shown in generated documentation, eg.
+// "git(1)".
+func (a *Application) SeeAlso(pages ...string) *Application {
+	a.man.seeAlso = pages
+	return a
+}
+
+func (m *manMetadata) Section() string {
+	if m.section == "" {
+		return "1"
+	}
+	return m.section
+}
+
+func (m *manMetadata) Date() string {
+	if m.date.IsZero() {
+		return ""
+	}
+	return m.date.Format("2006-01-02")
+}
+
+func (a *Application) authors() []string {
+	if len(a.man.authors) == 0 && a.author != "" {
+		return []string{a.author}
+	}
+	return a.man.authors
+}
+
+// The original ManPageTemplate, to tell whether it has been replaced.
+var builtinManPageTemplate = ManPageTemplate
+
+// WriteManPage writes a roff man page for the application to w, describing
+// every visible command, flag, argument and example. This is what
+// --help-man prints. If ManPageTemplate has been replaced, the page is
+// rendered with it instead.
+func (a *Application) WriteManPage(w io.Writer) error {
+	if err := a.init(); err != nil {
+		return err
+	}
+	context, err := a.parseContext(false, nil)
+	if err != nil {
+		return err
+	}
+	return a.writeManPage(w, context)
+}
+
+// WriteManPages writes a man page for the application, and a separate page
+// for each visible command, to dir. Pages are named after the command and
+// the man section, eg. "app.1" and "app-remote-add.1".
+func (a *Application) WriteManPages(dir string) error {
+	if err := a.init(); err != nil {
+		return err
+	}
+	model := a.Model()
+	section := model.Section
+	if err := ioutil.WriteFile(filepath.Join(dir, a.Name+"."+section), a.manPage(model, true), 0644); err != nil {
+		return err
+	}
+	for _, cmd := range visibleCommands(model.Commands) {
+		path := filepath.Join(dir, manPageName(a.Name, cmd)+"."+section)
+		if err := ioutil.WriteFile(path, a.commandManPage(model, cmd), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *Application) generateManPage(c *ParseContext) error {
+	if err := a.writeManPage(a.outputWriter, c); err != nil {
+		return err
+	}
+	a.terminate(0)
+	return nil
+}
+
+func (a *Application) writeManPage(w io.Writer, context *ParseContext) error {
+	if ManPageTemplate != builtinManPageTemplate {
+		return a.renderUsage(w, context, 2, ManPageTemplate)
+	}
+	_, err := w.Write(a.manPage(a.Model(), false))
+	return err
+}
+
+// Render the application's page. If commandPages is true, commands are
+// summarised and referred to their own pages rather than described in full.
+func (a *Application) manPage(model *ApplicationModel, commandPages bool) []byte {
+	r := &roffWriter{}
+	r.header(model.Name, model)
+	r.section("NAME")
+	r.text(model.Name + " - " + firstLine(model.Help))
+	r.section("SYNOPSIS")
+	r.synopsis(model.Name, model.FlagGroupModel, model.ArgGroupModel, model.CmdGroupModel)
+	r.section("DESCRIPTION")
+	r.text(model.Help)
+	r.flags("OPTIONS", model.Flags)
+	r.args(model.Args)
+	commands := visibleCommands(model.Commands)
+	if len(commands) > 0 {
+		r.section("COMMANDS")
+		for _, cmd := range commands {
+			if commandPages {
+				r.tagged(r.bold(cmd.FullCommand), firstLine(cmd.Help)+"\nSee "+manPageName(model.Name, cmd)+"("+model.Section+").")
+				continue
+			}
+			r.raw(".SS " + r.bold(cmd.FullCommand))
+			r.synopsis(model.Name+" "+cmd.FullCommand, cmd.FlagGroupModel, cmd.ArgGroupModel, cmd.CmdGroupModel)
+			r.text(cmd.Help)
+			for _, flag := range visibleFlags(cmd.Flags) {
+				r.tagged(r.flag(flag), flag.HelpWithOptions())
+			}
+			for _, arg := range visibleArgs(cmd.Args) {
+				r.tagged(r.arg(arg), arg.HelpWithOptions())
+			}
+			for _, example := range cmd.Examples {
+				r.example(example)
+			}
+		}
+	}
+	r.examples(model.Examples)
+	seeAlso := model.SeeAlso
+	if commandPages {
+		for _, cmd := range commands {
+			seeAlso = append(seeAlso, manPageName(model.Name, cmd)+"("+model.Section+")")
+		}
+	}
+	r.footer(model, seeAlso)
+	return r.Bytes()
+}
+
+// Render the page of a single command.
+func (a *Application) commandManPage(model *ApplicationModel, cmd *CmdModel) []byte {
+	name := manPageName(model.Name, cmd)
+	r := &roffWriter{}
+	r.header(name, model)
+	r.section("NAME")
+	r.text(name + " - " + firstLine(cmd.Help))
+	r.section("SYNOPSIS")
+	r.synopsis(model.Name+" "+cmd.FullCommand, cmd.FlagGroupModel, cmd.ArgGroupModel, cmd.CmdGroupModel)
+	r.section("DESCRIPTION")
+	r.text(cmd.Help)
+	r.flags("OPTIONS", cmd.Flags)
+	r.args(cmd.Args)
+	if commands := visibleCommands(cmd.Commands); len(commands) > 0 {
+		r.section("COMMANDS")
+		for _, sub := range commands {
+			if sub.Depth == cmd.Depth+1 {
+				r.tagged(r.bold(sub.FullCommand), firstLine(sub.Help))
+			}
+		}
+	}
+	r.flags("GLOBAL OPTIONS", model.Flags)
+	r.examples(cmd.Examples)
+	r.footer(model, append([]string{model.Name + "(" + model.Section + ")"}, model.SeeAlso...))
+	return r.Bytes()
+}
+
+// Visible commands, depth first.
+func visibleCommands(cmds []*CmdModel) []*CmdModel {
+	out := []*CmdModel{}
+	for _, cmd := range cmds {
+		if !cmd.Hidden {
+			out = append(out, cmd)
+			out = append(out, visibleCommands(cmd.Commands)...)
+		}
+	}
+	return out
+}
+
+func visibleFlags(flags []*FlagModel) []*FlagModel {
+	out := []*FlagModel{}
+	for _, flag := range flags {
+		if !flag.Hidden {
+			out = append(out, flag)
+		}
+	}
+	return out
+}
+
+func visibleArgs(args []*ArgModel) []*ArgModel {
+	out := []*ArgModel{}
+	for _, arg := range args {
+		if !arg.Hidden {
+			out = append(out, arg)
+		}
+	}
+	return out
+}
+
+func manPageName(app string, cmd *CmdModel) string {
+	return app + "-" + strings.Replace(cmd.FullCommand, " ", "-", -1)
+}
+
+func firstLine(s string) string {
+	return strings.SplitN(strings.TrimSpace(s), "\n", 2)[0]
+}
+
+// roffWriter builds a man page in roff format.
+type roffWriter struct {
+	bytes.Buffer
+}
+
+// Escape s for use in roff text.
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (r *roffWriter) raw(line string) {
+	r.WriteString(line + "\n")
+}
+
+func (r *roffWriter) bold(s string) string {
+	return `\fB` + roffEscape(s) + `\fR`
+}
+
+func (r *roffWriter) header(name string, model *ApplicationModel) {
+	version := model.Name
+	if model.Version != "" {
+		version += " " + model.Version
+	}
+	r.raw(fmt.Sprintf(`.TH "%s" "%s" "%s" "%s"`, strings.ToUpper(roffEscape(name)), model.Section, model.Date, roffEscape(version)))
+}
+
+func (r *roffWriter) section(title string) {
+	r.raw(`.SH "` + title + `"`)
+}
+
+// Write paragraphs of text.
+func (r *roffWriter) text(s string) {
+	for i, paragraph := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if i > 0 {
+			r.raw(".PP")
+		}
+		if paragraph != "" {
+			r.raw(roffEscape(paragraph))
+		}
+	}
+}
+
+// Write a tagged paragraph. tag must already be escaped.
+func (r *roffWriter) tagged(tag, text string) {
+	r.raw(".TP")
+	r.raw(tag)
+	if text != "" {
+		r.raw(roffEscape(text))
+	}
+}
+
+func (r *roffWriter) synopsis(usage string, flags *FlagGroupModel, args *ArgGroupModel, cmds *CmdGroupModel) {
+	line := r.bold(usage)
+	if summary := flags.FlagSummary(); summary != "" {
+		line += " " + roffEscape(summary)
+	}
+	if len(args.Args) > 0 {
+		line += " " + roffEscape(args.ArgSummary())
+	}
+	if len(visibleCommands(cmds.Commands)) > 0 {
+		line += " " + roffEscape("<command> [<args> ...]")
+	}
+	r.raw(line)
+}
+
+func (r *roffWriter) flag(flag *FlagModel) string {
+	return r.bold(strings.TrimSpace(formatFlag(false, flag)))
+}
+
+func (r *roffWriter) arg(arg *ArgModel) string {
+	tag := `\fI` + roffEscape(arg.Name) + `\fR`
+	if !arg.Required {
+		return "[" + tag + "]"
+	}
+	return tag
+}
+
+func (r *roffWriter) flags(title string, flags []*FlagModel) {
+	flags = visibleFlags(flags)
+	if len(flags) == 0 {
+		return
+	}
+	r.section(title)
+	for _, flag := range flags {
+		r.tagged(r.flag(flag), flag.HelpWithOptions())
+	}
+}
+
+func (r *roffWriter) args(args []*ArgModel) {
+	args = visibleArgs(args)
+	if len(args) == 0 {
+		return
+	}
+	r.section("ARGUMENTS")
+	for _, arg := range args {
+		r.tagged(r.arg(arg), arg.HelpWithOptions())
+	}
+}
+
+func (r *roffWriter) examples(examples []Example) {
+	if len(examples) == 0 {
+		return
+	}
+	r.section("EXAMPLES")
+	for _, example := range examples {
+		r.example(example)
+	}
+}
+
+func (r *roffWriter) example(example Example) {
+	r.raw(".PP")
+	r.text(example.Help)
+	r.raw(".PP")
+	r.raw(".RS 4")
+	r.raw(".nf")
+	r.raw("$ " + roffEscape(example.Usage))
+	r.raw(".fi")
+	r.raw(".RE")
+}
+
+func (r *roffWriter) footer(model *ApplicationModel, seeAlso []string) {
+	if len(model.Authors) > 0 {
+		r.section("AUTHORS")
+		for i, author := range model.Authors {
+			if i > 0 {
+				r.raw(".br")
+			}
+			r.raw(roffEscape(author))
+		}
+	}
+	if model.SourceURL != "" {
+		r.section("SOURCE")
+		r.raw(roffEscape(model.SourceURL))
+	}
+	if model.Copyright != "" {
+		r.section("COPYRIGHT")
+		r.text(model.Copyright)
+	}
+	if len(seeAlso) > 0 {
+		r.section("SEE ALSO")
+		pages := make([]string, len(seeAlso))
+		for i, page := range seeAlso {
+			pages[i] = r.bold(page)
+		}
+		r.raw(strings.Join(pages, ", "))
+	}
+}
@@ -0,0 +1,74 @@
+package kingpin
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func manTestApp() *Application {
+	app := New("app", "Manage things.").Version("1.0").Terminate(nil).SeeAlso("git(1)")
+	app.Flag("dry-run", "Don't change anything.").Bool()
+	remote := app.Command("remote", "Manage remotes.")
+	add := remote.Command("add", "Add a remote.\n\n.dotted paragraph")
+	add.Flag("proto", "Protocol.").Enum("https", "ssh")
+	add.Arg("name", "Remote name.").Required().String()
+	add.Example("app remote add origin", "Add origin.")
+	app.Command("secret", "").Hidden()
+	return app
+}
+
+func TestWriteManPage(t *testing.T) {
+	w := bytes.NewBuffer(nil)
+	assert.NoError(t, manTestApp().WriteManPage(w))
+	page := w.String()
+	assert.Contains(t, page, `.TH "APP" "1" "" "app 1.0"`+"\n")
+	assert.Contains(t, page, ".SH \"NAME\"\napp \\- Manage things.\n")
+	assert.Contains(t, page, ".TP\n\\fB\\-\\-dry\\-run\\fR\nDon't change anything.\n")
+	assert.Contains(t, page, ".SS \\fBremote add\\fR\n\\fBapp remote add\\fR [<flags>] <name>\nAdd a remote.\n.PP\n\\&.dotted paragraph\n")
+	assert.Contains(t, page, ".TP\n\\fB\\-\\-proto=PROTO\\fR\nProtocol. One of: https, ssh.\n")
+	assert.Contains(t, page, ".TP\n\\fIname\\fR\nRemote name.\n")
+	assert.Contains(t, page, "$ app remote add origin\n")
+	assert.NotContains(t, page, "secret")
+}
+
+func TestWriteManPages(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, manTestApp().WriteManPages(dir))
+	names := []string{}
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+	assert.Equal(t, []string{"app-help.1", "app-remote-add.1", "app-remote.1", "app.1"}, names)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "app.1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "\\fBgit(1)\\fR, \\fBapp\\-help(1)\\fR, \\fBapp\\-remote(1)\\fR, \\fBapp\\-remote\\-add(1)\\fR\n")
+	data, err = ioutil.ReadFile(filepath.Join(dir, "app-remote.1"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), ".SH \"COMMANDS\"\n.TP\n\\fBremote add\\fR\nAdd a remote.\n")
+	assert.Contains(t, string(data), ".SH \"GLOBAL OPTIONS\"\n")
+}
+
+func TestManPageCustomTemplate(t *testing.T) {
+	defer func(tmpl string) { ManPageTemplate = tmpl }(ManPageTemplate)
+	ManPageTemplate = `.TH "{{.App.Name}}" "{{.App.Section}}"` + "\n"
+
+	w := bytes.NewBuffer(nil)
+	app := manTestApp().OutputWriter(w)
+	_, err := app.Parse([]string{"--help-man"})
+	assert.NoError(t, err)
+	assert.Equal(t, ".TH \"app\" \"1\"\n", w.String())
+
+	w.Reset()
+	assert.NoError(t, app.WriteManPage(w))
+	assert.Equal(t, ".TH \"app\" \"1\"\n", w.String())
+}
@@ -33,6 +33,43 @@ func (f *FlagGroupModel) FlagSummary() string {
 	return strings.Join(out, " ")
 }
 
+// FlagGroupSection is a titled block of flags in help.
+type FlagGroupSection struct {
+	Title string
+	Flags []*FlagModel
+}
+
+// Sections splits the visible flags into help sections: ungrouped flags
+// under "Flags:", then ungrouped flags inherited from parent commands under
+// "Global Flags:", followed by each FlagGroup in order of definition.
+func (f *FlagGroupModel) Sections() []*FlagGroupSection {
+	sections := []*FlagGroupSection{{Title: "Flags:"}, {Title: "Global Flags:"}}
+	index := map[string]*FlagGroupSection{"": sections[0]}
+	for _, flag := range f.Flags {
+		if flag.Hidden {
+			continue
+		}
+		if flag.Inherited && flag.Group == "" {
+			sections[1].Flags = append(sections[1].Flags, flag)
+			continue
+		}
+		section, ok := index[flag.Group]
+		if !ok {
+			section = &FlagGroupSection{Title: flag.Group + ":"}
+			index[flag.Group] = section
+			sections = append(sections, section)
+		}
+		section.Flags = append(section.Flags, flag)
+	}
+	out := []*FlagGroupSection{}
+	for _, section := range sections {
+		if len(section.Flags) > 0 {
+			out = append(out, section)
+		}
+	}
+	return out
+}
+
 type FlagModel struct {
 	Name        string
 	Help        string
@@ -42,13 +79,38 @@ type FlagModel struct {
 	PlaceHolder string
 	Required    bool
 	Hidden      bool
+	Deprecated  string
+	Secret      bool
 	Value       Value
+	// Envars consulted in order if Envar is not set.
+	EnvarFallbacks []string
+	// Limits on the number of values, if non-zero. See MinOccurrences().
+	MinOccurrences int
+	MaxOccurrences int
+	// Level of help the flag is shown at. See FlagClause.HelpLevel().
+	HelpLevel int
+	// Number of enum options listed in help. See FlagClause.OptionsLimit().
+	OptionsLimit int
+	// Title of the FlagGroup the flag belongs to, if any.
+	Group string
+	// File completion, see FlagClause.HintFiles() and HintDirectories().
+	HintFiles       string
+	HintDirectories bool
+	// Inherited from a parent of the command help is shown for, see
+	// FlagClause.Global().
+	Inherited bool
 }
 
 func (f *FlagModel) String() string {
 	return f.Value.String()
 }
 
+// HelpWithOptions returns the help for the flag followed by its enum
+// options, if any.
+func (f *FlagModel) HelpWithOptions() string {
+	return occurrencesHelp(helpWithOptions(f.Help, enumOptions(f.Value), f.OptionsLimit), f.MinOccurrences, f.MaxOccurrences)
+}
+
 func (f *FlagModel) IsBoolFlag() bool {
 	if fl, ok := f.Value.(boolFlag); ok {
 		return fl.IsBoolFlag()
@@ -65,9 +127,17 @@ func (f *FlagModel) FormatPlaceHolder() string {
 		if len(f.Default) > 1 {
 			ellipsis = "..."
 		}
+		if f.Secret {
+			return secretMask + ellipsis
+		}
 		if _, ok := f.Value.(*stringValue); ok {
 			return strconv.Quote(f.Default[0]) + ellipsis
 		}
+		if _, ok := f.Value.(*byteSizeValue); ok {
+			if n, err := parseByteSize(f.Default[0]); err == nil {
+				return formatByteSize(n) + ellipsis
+			}
+		}
 		return f.Default[0] + ellipsis
 	}
 	return strings.ToUpper(f.Name)
@@ -81,32 +151,128 @@ func (a *ArgGroupModel) ArgSummary() string {
 	depth := 0
 	out := []string{}
 	for _, arg := range a.Args {
-		h := "<" + arg.Name + ">"
+		if arg.Hidden {
+			continue
+		}
+		h := arg.FormatPlaceHolder()
 		if !arg.Required {
-			h = "[" + h
+			h = "[" + h + arg.formatDefault()
 			depth++
 		}
 		out = append(out, h)
 	}
+	if len(out) == 0 {
+		return ""
+	}
 	out[len(out)-1] = out[len(out)-1] + strings.Repeat("]", depth)
 	return strings.Join(out, " ")
 }
 
 type ArgModel struct {
-	Name     string
-	Help     string
-	Default  []string
-	Envar    string
-	Required bool
-	Value    Value
+	Name       string
+	Help       string
+	Default    []string
+	Envar      string
+	Required   bool
+	Hidden     bool
+	Deprecated string
+	Value      Value
+	// Envars consulted in order if Envar is not set.
+	EnvarFallbacks []string
+	// Limits on the number of values, if non-zero. See MinOccurrences().
+	MinOccurrences int
+	MaxOccurrences int
+	// Number of enum options listed in help. See ArgClause.OptionsLimit().
+	OptionsLimit int
+	PlaceHolder  string
+	// File completion, see ArgClause.HintFiles() and HintDirectories().
+	HintFiles       string
+	HintDirectories bool
 }
 
 func (a *ArgModel) String() string {
 	return a.Value.String()
 }
 
+// FormatPlaceHolder returns the place-holder for the argument, "<name>" by
+// default.
+func (a *ArgModel) FormatPlaceHolder() string {
+	if a.PlaceHolder != "" {
+		return a.PlaceHolder
+	}
+	return "<" + a.Name + ">"
+}
+
+// FormatUsage returns the argument as shown in the usage line, eg. "<file>",
+// "<files>..." or "[<dir>=.]". Optional arguments include their defaults.
+func (a *ArgModel) FormatUsage() string {
+	s := a.FormatPlaceHolder()
+	if isCumulative(a.Value) {
+		s += "..."
+	}
+	if a.Required {
+		return s
+	}
+	return "[" + s + a.formatDefault() + "]"
+}
+
+// "=default" for an argument with a non-empty default, otherwise "".
+func (a *ArgModel) formatDefault() string {
+	if len(a.Default) == 0 || (len(a.Default) == 1 && a.Default[0] == "") {
+		return ""
+	}
+	return "=" + strings.Join(a.Default, ",")
+}
+
+// HelpWithOptions returns the help for the argument followed by its enum
+// options, if any.
+func (a *ArgModel) HelpWithOptions() string {
+	return occurrencesHelp(helpWithOptions(a.Help, enumOptions(a.Value), a.OptionsLimit), a.MinOccurrences, a.MaxOccurrences)
+}
+
 type CmdGroupModel struct {
 	Commands []*CmdModel
+
+	categoryOrder []string // See Application.CategoryOrder().
+}
+
+// CmdCategory is a titled block of commands in help. See Cmd.Category().
+type CmdCategory struct {
+	Title string
+	*CmdGroupModel
+}
+
+// Categories splits the visible commands into help sections: commands
+// without a category under title, followed by each Category() in the order
+// given to Application.CategoryOrder(), then in order of definition.
+func (c *CmdGroupModel) Categories(title string) []*CmdCategory {
+	categories := []*CmdCategory{{Title: title, CmdGroupModel: &CmdGroupModel{}}}
+	index := map[string]*CmdCategory{"": categories[0]}
+	add := func(name string) *CmdCategory {
+		category, ok := index[name]
+		if !ok {
+			category = &CmdCategory{Title: name + ":", CmdGroupModel: &CmdGroupModel{}}
+			index[name] = category
+			categories = append(categories, category)
+		}
+		return category
+	}
+	for _, name := range c.categoryOrder {
+		add(name)
+	}
+	for _, cmd := range c.Commands {
+		if !cmd.Hidden {
+			category := add(cmd.Category)
+			category.Commands = append(category.Commands, cmd)
+		}
+	}
+	out := []*CmdCategory{}
+	for _, category := range categories {
+		if len(category.Commands) > 0 {
+			out = append(out, category)
+		}
+	}
+	return out
 }
 
 func (c *CmdGroupModel) FlattenedCommands() (out []*CmdModel) {
@@ -126,7 +292,10 @@ type CmdModel struct {
 	FullCommand string
 	Depth       int
 	Hidden      bool
+	Deprecated  string
 	Default     bool
+	Category    string
+	HelpLevel   int
 	Examples    []Example
 	*FlagGroupModel
 	*ArgGroupModel
@@ -138,11 +307,19 @@ func (c *CmdModel) String() string {
 }
 
 type ApplicationModel struct {
-	Name     string
-	Help     string
-	Version  string
-	Author   string
-	Examples []Example
+	Name      string
+	Help      string
+	Version   string
+	Author    string
+	Examples  []Example
+	Section   string
+	Date      string
+	Authors   []string
+	Copyright string
+	SourceURL string
+	SeeAlso   []string
+	// Documentation pages listed in help, see Application.HelpTopic().
+	HelpTopics []HelpTopic
 	*ArgGroupModel
 	*CmdGroupModel
 	*FlagGroupModel
@@ -158,6 +335,13 @@ func (a *Application) Model() *ApplicationModel {
 		ArgGroupModel:  a.argGroup.Model(),
 		CmdGroupModel:  a.cmdGroup.Model(),
 		Examples:       a.Examples(),
+		Section:        a.man.Section(),
+		Date:           a.man.Date(),
+		Authors:        a.authors(),
+		Copyright:      a.man.copyright,
+		SourceURL:      a.man.sourceURL,
+		SeeAlso:        a.man.seeAlso,
+		HelpTopics:     a.helpTopics,
 	}
 }
 
@@ -171,12 +355,22 @@ func (a *argGroup) Model() *ArgGroupModel {
 
 func (a *ArgClause) Model() *ArgModel {
 	return &ArgModel{
-		Name:     a.name,
-		Help:     a.help,
-		Default:  a.defaultValues,
-		Envar:    a.envar,
-		Required: a.required,
-		Value:    a.value,
+		Name:       a.name,
+		Help:       a.help,
+		Default:    a.defaultValues,
+		Envar:      a.envar,
+		Required:   a.required || a.minOccurs > 0,
+		Hidden:     a.hidden,
+		Deprecated: a.deprecated,
+		Value:      a.value,
+
+		EnvarFallbacks:  a.fallbacks,
+		MinOccurrences:  a.minOccurs,
+		MaxOccurrences:  a.maxOccurs,
+		OptionsLimit:    a.optionsLimit,
+		PlaceHolder:     a.placeholder,
+		HintFiles:       a.hintGlob,
+		HintDirectories: a.hintDirectories,
 	}
 }
 
@@ -189,21 +383,42 @@ func (f *flagGroup) Model() *FlagGroupModel {
 }
 
 func (f *FlagClause) Model() *FlagModel {
+	defaults := f.defaultValues
+	if f.secret {
+		defaults = make([]string, len(f.defaultValues))
+		for i := range defaults {
+			defaults[i] = secretMask
+		}
+	}
 	return &FlagModel{
 		Name:        f.name,
 		Help:        f.help,
 		Short:       rune(f.shorthand),
-		Default:     f.defaultValues,
+		Default:     defaults,
 		Envar:       f.envar,
 		PlaceHolder: f.placeholder,
-		Required:    f.required,
+		Required:    f.required || f.minOccurs > 0,
 		Hidden:      f.hidden,
+		Deprecated:  f.deprecated,
+		Secret:      f.secret,
 		Value:       f.value,
+
+		EnvarFallbacks:  f.fallbacks,
+		MinOccurrences:  f.minOccurs,
+		MaxOccurrences:  f.maxOccurs,
+		HelpLevel:       f.helpLevel,
+		OptionsLimit:    f.optionsLimit,
+		Group:           f.group,
+		HintFiles:       f.hintGlob,
+		HintDirectories: f.hintDirectories,
 	}
 }
 
 func (c *cmdGroup) Model() *CmdGroupModel {
 	m := &CmdGroupModel{}
+	if c.app != nil {
+		m.categoryOrder = c.app.categoryOrder
+	}
 	for _, cm := range c.commandOrder {
 		m.Commands = append(m.Commands, cm.Model())
 	}
@@ -221,7 +436,10 @@ func (c *Cmd) Model() *CmdModel {
 		Help:           c.help,
 		Depth:          depth,
 		Hidden:         c.hidden,
+		Deprecated:     c.deprecated,
 		Default:        c.isDefault,
+		Category:       c.category,
+		HelpLevel:      c.helpLevel,
 		FullCommand:    c.FullCommand(),
 		FlagGroupModel: c.flagGroup.Model(),
 		ArgGroupModel:  c.argGroup.Model(),
@@ -0,0 +1,56 @@
+package kingpin
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MultiCall selects an Application based on the name the binary was invoked
+// as (the base name of os.Args[0]) and parses the remaining command line with
+// it. This allows one binary to be symlinked as several tools, busybox style,
+// with each tool getting its own help and completion.
+//
+// The selected Application is returned along with the parse results.
+func MultiCall(apps map[string]*Application) (*Application, string, error) {
+	return multiCall(apps, os.Args)
+}
+
+func multiCall(apps map[string]*Application, argv []string) (*Application, string, error) {
+	if len(argv) == 0 {
+		return nil, "", fmt.Errorf("no program name to dispatch on")
+	}
+	name := filepath.Base(argv[0])
+	app, ok := apps[name]
+	if !ok {
+		names := make([]string, 0, len(apps))
+		for n := range apps {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return nil, "", fmt.Errorf("unknown program name %q, expected one of %s", name, strings.Join(names, ", "))
+	}
+	command, err := app.Parse(argv[1:])
+	return app, command, err
+}
+
+// DispatchOnArgv0 selects a top-level command based on the name the binary
+// was invoked as. eg. if the application has a "ls" command and the binary is
+// symlinked as "ls", running "ls -l" is equivalent to "app ls -l".
+func (a *Application) DispatchOnArgv0() *Application {
+	a.argv0 = filepath.Base(os.Args[0])
+	return a
+}
+
+// Prefix args with the command selected by DispatchOnArgv0(), if any.
+func (a *Application) dispatchArgs(args []string) []string {
+	if a.argv0 == "" || a.argv0 == a.Name {
+		return args
+	}
+	if _, ok := a.commands[a.argv0]; !ok {
+		return args
+	}
+	return append([]string{a.argv0}, args...)
+}
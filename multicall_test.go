@@ -0,0 +1,43 @@
+package kingpin
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestMultiCallSelectsApplication(t *testing.T) {
+	ls := newTestApp()
+	long := ls.Flag("long", "").Short('l').Bool()
+	cat := newTestApp()
+	files := cat.Arg("files", "").Strings()
+
+	apps := map[string]*Application{"ls": ls, "cat": cat}
+	app, _, err := multiCall(apps, []string{"/usr/bin/ls", "-l"})
+	assert.NoError(t, err)
+	assert.Equal(t, ls, app)
+	assert.True(t, *long)
+
+	app, _, err = multiCall(apps, []string{"cat", "a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, cat, app)
+	assert.Equal(t, []string{"a", "b"}, *files)
+
+	_, _, err = multiCall(apps, []string{"/bin/rm"})
+	assert.Error(t, err)
+}
+
+func TestDispatchOnArgv0(t *testing.T) {
+	app := newTestApp()
+	app.Command("ls", "")
+	app.Command("cat", "").Arg("file", "").String()
+	app.argv0 = "cat"
+	selected, err := app.Parse([]string{"foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, "cat", selected)
+
+	app.argv0 = "test"
+	selected, err = app.Parse([]string{"ls"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ls", selected)
+}
@@ -0,0 +1,115 @@
+package kingpin
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type occurrenceMixin struct {
+	minOccurs int // See MinOccurrences().
+	maxOccurs int // See MaxOccurrences().
+}
+
+// Check that the limits are only applied to repeatable values.
+func (o *occurrenceMixin) checkOccurrences(value Value, name string) error {
+	if (o.minOccurs > 0 || o.maxOccurs > 0) && !isCumulative(value) {
+		return fmt.Errorf("%s: MinOccurrences() and MaxOccurrences() require a repeatable value", name)
+	}
+	if o.maxOccurs > 0 && o.minOccurs > o.maxOccurs {
+		return fmt.Errorf("%s: MinOccurrences(%d) exceeds MaxOccurrences(%d)", name, o.minOccurs, o.maxOccurs)
+	}
+	return nil
+}
+
+// MinOccurrences requires that a repeatable flag is given at least n values,
+// eg.
+//
+//     app.Flag("file", "Files to process.").MinOccurrences(1).MaxOccurrences(5).Strings()
+//
+// The limits are noted in the help for the flag, and a flag with a minimum is
+// shown as required in the usage summary.
+func (f *FlagClause) MinOccurrences(n int) *FlagClause {
+	f.minOccurs = n
+	return f
+}
+
+// MaxOccurrences allows a repeatable flag at most n values. See
+// MinOccurrences().
+func (f *FlagClause) MaxOccurrences(n int) *FlagClause {
+	f.maxOccurs = n
+	return f
+}
+
+// MinOccurrences requires that a repeatable argument is given at least n
+// values. See FlagClause.MinOccurrences().
+func (a *ArgClause) MinOccurrences(n int) *ArgClause {
+	a.minOccurs = n
+	return a
+}
+
+// MaxOccurrences allows a repeatable argument at most n values. See
+// FlagClause.MinOccurrences().
+func (a *ArgClause) MaxOccurrences(n int) *ArgClause {
+	a.maxOccurs = n
+	return a
+}
+
+// Describe the limits on the number of values, for help.
+func occurrencesHelp(help string, min, max int) string {
+	switch {
+	case min > 0 && max > 0:
+		return appendHelp(help, fmt.Sprintf("Between %d and %d values.", min, max))
+	case min > 0:
+		return appendHelp(help, fmt.Sprintf("At least %d values.", min))
+	case max > 0:
+		return appendHelp(help, fmt.Sprintf("At most %d values.", max))
+	}
+	return help
+}
+
+// The number of values held by value.
+func valueCount(value Value) int {
+	getter, ok := value.(Getter)
+	if !ok {
+		return 1
+	}
+	v := reflect.ValueOf(getter.Get())
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return 0
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return v.Len()
+	}
+	return 1
+}
+
+// Check the number of values of every flag and argument in scope.
+func (p *ParseContext) occurrenceErrors() []error {
+	errs := []error{}
+	check := func(clause interface{}, name string, value Value, o occurrenceMixin) {
+		if o.minOccurs == 0 && o.maxOccurs == 0 {
+			return
+		}
+		n := 0
+		if p.sourceOf(clause) != SourceNone {
+			n = valueCount(value)
+		}
+		switch {
+		case n < o.minOccurs:
+			errs = append(errs, errorf(MsgTooFewValues, name, o.minOccurs, n))
+		case o.maxOccurs > 0 && n > o.maxOccurs:
+			errs = append(errs, errorf(MsgTooManyValues, name, o.maxOccurs, n))
+		}
+	}
+	for _, flag := range p.flags.flagOrder {
+		check(flag, "--"+flag.name, flag.value, flag.occurrenceMixin)
+	}
+	for _, arg := range p.arguments.args {
+		check(arg, "<"+arg.name+">", arg.value, arg.occurrenceMixin)
+	}
+	return errs
+}
@@ -0,0 +1,447 @@
+package kingpin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+)
+
+// DefaultOutputFormat is used by ParseContext.Print() when no other format
+// has been selected.
+var DefaultOutputFormat = "table"
+
+// A Formatter renders values passed to ParseContext.Print().
+//
+// arg is the text following "=" in the selected format, if any. eg. for
+// "--output=table=name,age" arg is "name,age".
+type Formatter interface {
+	Format(w io.Writer, v interface{}, arg string) error
+}
+
+// FormatterFunc is a function implementing the Formatter interface.
+type FormatterFunc func(w io.Writer, v interface{}, arg string) error
+
+// Format calls f(w, v, arg).
+func (f FormatterFunc) Format(w io.Writer, v interface{}, arg string) error {
+	return f(w, v, arg)
+}
+
+var builtinFormatters = map[string]Formatter{
+	"json":     FormatterFunc(formatJSON),
+	"yaml":     FormatterFunc(formatYAML),
+	"table":    FormatterFunc(formatTable),
+	"template": FormatterFunc(formatTemplate),
+	"jsonpath": FormatterFunc(formatJSONPath),
+}
+
+// Formatter registers (or replaces) a named output format.
+func (a *Application) Formatter(name string, formatter Formatter) *Application {
+	if a.formatters == nil {
+		a.formatters = map[string]Formatter{}
+		for n, f := range builtinFormatters {
+			a.formatters[n] = f
+		}
+	}
+	a.formatters[name] = formatter
+	return a
+}
+
+func (a *Application) formatter(name string) (Formatter, bool) {
+	if a.formatters == nil {
+		f, ok := builtinFormatters[name]
+		return f, ok
+	}
+	f, ok := a.formatters[name]
+	return f, ok
+}
+
+func (a *Application) formatterNames() []string {
+	formatters := a.formatters
+	if formatters == nil {
+		formatters = builtinFormatters
+	}
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OutputWriter sets the io.Writer used for regular output, such as
+// ParseContext.Print(), generated man pages and completion scripts. Defaults
+// to os.Stdout.
+func (a *Application) OutputWriter(w io.Writer) *Application {
+	a.outputWriter = w
+	return a
+}
+
+// OutputFlag adds an --output (-o) flag selecting the format used by
+// ParseContext.Print(). Formats are json, yaml, table, template and jsonpath,
+// plus any registered with Formatter().
+//
+//     --output=table=name,age           Only include the given columns.
+//     --output=template='{{.Name}}'     Render with a Go text/template.
+//     --output=jsonpath='{.items[0]}'   Select values with a JSONPath subset.
+func (a *Application) OutputFlag() *FlagClause {
+	flag := a.Flag("output", "Output format ("+strings.Join(a.formatterNames(), ", ")+").").
+		Short('o').
+		Default(DefaultOutputFormat).
+		PlaceHolder("FORMAT").
+		HintAction(a.formatterNames)
+	flag.SetValue(&outputValue{app: a})
+	return flag
+}
+
+type outputValue struct {
+	app *Application
+}
+
+func (o *outputValue) Set(value string) error {
+	name := strings.SplitN(value, "=", 2)[0]
+	if _, ok := o.app.formatter(name); !ok {
+		return errorf(MsgUnknownOutputFormat, name, strings.Join(o.app.formatterNames(), ","))
+	}
+	o.app.output = value
+	return nil
+}
+
+func (o *outputValue) Get() interface{} { return o.app.output }
+
+func (o *outputValue) String() string { return o.app.output }
+
+func (o *outputValue) storage() interface{} { return &o.app.output }
+
+// Print renders v to the application's output writer using the format
+// selected by OutputFlag().
+func (p *ParseContext) Print(v interface{}) error {
+	format := DefaultOutputFormat
+	if p.app.output != "" {
+		format = p.app.output
+	}
+	parts := strings.SplitN(format, "=", 2)
+	formatter, ok := p.app.formatter(parts[0])
+	if !ok {
+		return errorf(MsgUnknownOutputFormat, parts[0], strings.Join(p.app.formatterNames(), ","))
+	}
+	arg := ""
+	if len(parts) == 2 {
+		arg = parts[1]
+	}
+	return formatter.Format(p.app.outputWriter, v, arg)
+}
+
+// Convert v into the generic structure produced by encoding/json.
+func toGeneric(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out interface{}
+	err = dec.Decode(&out)
+	return out, err
+}
+
+func formatJSON(w io.Writer, v interface{}, arg string) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func formatYAML(w io.Writer, v interface{}, arg string) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	for _, line := range yamlLines(generic) {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Render a generic value as YAML lines, without any leading indentation.
+func yamlLines(v interface{}) []string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			return []string{"{}"}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		out := []string{}
+		for _, key := range keys {
+			child := yamlLines(v[key])
+			if isYAMLScalar(v[key]) {
+				out = append(out, yamlScalar(key)+": "+child[0])
+				continue
+			}
+			out = append(out, yamlScalar(key)+":")
+			for _, line := range child {
+				out = append(out, "  "+line)
+			}
+		}
+		return out
+
+	case []interface{}:
+		if len(v) == 0 {
+			return []string{"[]"}
+		}
+		out := []string{}
+		for _, item := range v {
+			for i, line := range yamlLines(item) {
+				if i == 0 {
+					out = append(out, "- "+line)
+				} else {
+					out = append(out, "  "+line)
+				}
+			}
+		}
+		return out
+	}
+	return []string{yamlScalar(v)}
+}
+
+func isYAMLScalar(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return true
+}
+
+func yamlScalar(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		if yamlNeedsQuotes(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	case []interface{}, map[string]interface{}:
+		return yamlLines(v)[0]
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func yamlNeedsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s || strings.ContainsAny(s, "\n\t\"'#") ||
+		strings.Contains(s, ": ") || strings.HasSuffix(s, ":") || strings.ContainsAny(s[:1], "-?:,[]{}&*!|>%@`") {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func formatTable(w io.Writer, v interface{}, arg string) error {
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	var rows []interface{}
+	if list, ok := generic.([]interface{}); ok {
+		rows = list
+	} else {
+		rows = []interface{}{generic}
+	}
+
+	var columns []string
+	if arg != "" {
+		columns = strings.Split(arg, ",")
+	} else {
+		columns = tableColumns(v, rows)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	if len(columns) == 0 {
+		fmt.Fprintln(tw, "VALUE")
+		for _, row := range rows {
+			fmt.Fprintln(tw, tableCell(row))
+		}
+		return tw.Flush()
+	}
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = strings.ToUpper(column)
+	}
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fields, _ := row.(map[string]interface{})
+		cells := make([]string, len(columns))
+		for i, column := range columns {
+			cells[i] = tableCell(fields[column])
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+// Determine table columns, preferring struct field order where available.
+func tableColumns(v interface{}, rows []interface{}) []string {
+	t := reflect.TypeOf(v)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Struct {
+		columns := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+			name := strings.Split(field.Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			columns = append(columns, name)
+		}
+		return columns
+	}
+	seen := map[string]bool{}
+	columns := []string{}
+	for _, row := range rows {
+		if fields, ok := row.(map[string]interface{}); ok {
+			for key := range fields {
+				if !seen[key] {
+					seen[key] = true
+					columns = append(columns, key)
+				}
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns
+}
+
+func tableCell(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func formatTemplate(w io.Writer, v interface{}, arg string) error {
+	if arg == "" {
+		return fmt.Errorf("template output requires a template, eg. --output=template='{{.Name}}'")
+	}
+	t, err := template.New("output").Parse(arg)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, v)
+}
+
+func formatJSONPath(w io.Writer, v interface{}, arg string) error {
+	if arg == "" {
+		return fmt.Errorf("jsonpath output requires an expression, eg. --output=jsonpath='{.name}'")
+	}
+	generic, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	results, err := evalJSONPath(generic, arg)
+	if err != nil {
+		return err
+	}
+	values := make([]string, len(results))
+	for i, result := range results {
+		values[i] = tableCell(result)
+	}
+	_, err = fmt.Fprintln(w, strings.Join(values, " "))
+	return err
+}
+
+// Evaluate a subset of JSONPath: {.field.list[0].field} and [*] wildcards.
+func evalJSONPath(v interface{}, expr string) ([]interface{}, error) {
+	expr = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(expr), "{"), "}")
+	expr = strings.TrimPrefix(strings.TrimPrefix(expr, "$"), ".")
+	current := []interface{}{v}
+	if expr == "" {
+		return current, nil
+	}
+	for _, part := range strings.Split(expr, ".") {
+		name := part
+		indexes := []string{}
+		if i := strings.Index(part, "["); i >= 0 {
+			name = part[:i]
+			for _, index := range strings.Split(part[i+1:], "[") {
+				if !strings.HasSuffix(index, "]") {
+					return nil, fmt.Errorf("invalid jsonpath segment '%s'", part)
+				}
+				indexes = append(indexes, strings.TrimSuffix(index, "]"))
+			}
+		}
+		next := []interface{}{}
+		for _, value := range current {
+			if name != "" {
+				fields, ok := value.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				if value, ok = fields[name]; !ok {
+					continue
+				}
+			}
+			selected := []interface{}{value}
+			for _, index := range indexes {
+				var out []interface{}
+				for _, s := range selected {
+					list, ok := s.([]interface{})
+					if !ok {
+						continue
+					}
+					if index == "*" {
+						out = append(out, list...)
+						continue
+					}
+					i, err := strconv.Atoi(index)
+					if err != nil {
+						return nil, fmt.Errorf("invalid jsonpath index '%s'", index)
+					}
+					if i < 0 {
+						i += len(list)
+					}
+					if i >= 0 && i < len(list) {
+						out = append(out, list[i])
+					}
+				}
+				selected = out
+			}
+			next = append(next, selected...)
+		}
+		current = next
+	}
+	return current, nil
+}
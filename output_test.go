@@ -0,0 +1,79 @@
+package kingpin
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type testPerson struct {
+	Name string `json:"name"`
+	Age  int    `json:"age"`
+}
+
+func printWithOutput(t *testing.T, v interface{}, args ...string) string {
+	var buf bytes.Buffer
+	app := newTestApp().OutputWriter(&buf)
+	app.OutputFlag()
+	app.Action(func(ctx *ParseContext) error {
+		return ctx.Print(v)
+	})
+	_, err := app.Parse(args)
+	assert.NoError(t, err)
+	return buf.String()
+}
+
+func TestOutputTable(t *testing.T) {
+	people := []testPerson{{"Alice", 30}, {"Bob", 4}}
+	assert.Equal(t, "NAME   AGE\nAlice  30\nBob    4\n", printWithOutput(t, people))
+	assert.Equal(t, "AGE\n30\n4\n", printWithOutput(t, people, "-o", "table=age"))
+}
+
+func TestOutputJSON(t *testing.T) {
+	out := printWithOutput(t, testPerson{"Alice", 30}, "--output=json")
+	assert.Equal(t, "{\n  \"name\": \"Alice\",\n  \"age\": 30\n}\n", out)
+}
+
+func TestOutputYAML(t *testing.T) {
+	v := map[string]interface{}{
+		"name":  "Alice",
+		"tags":  []string{"a", "true"},
+		"inner": map[string]int{"x": 1},
+	}
+	out := printWithOutput(t, v, "-o", "yaml")
+	assert.Equal(t, "inner:\n  x: 1\nname: Alice\ntags:\n  - a\n  - \"true\"\n", out)
+}
+
+func TestOutputTemplateAndJSONPath(t *testing.T) {
+	people := []testPerson{{"Alice", 30}, {"Bob", 4}}
+	assert.Equal(t, "Alice,Bob,", printWithOutput(t, people, "-o", "template={{range .}}{{.Name}},{{end}}"))
+	assert.Equal(t, "Alice Bob\n", printWithOutput(t, people, "-o", "jsonpath={[*].name}"))
+	assert.Equal(t, "4\n", printWithOutput(t, people, "-o", "jsonpath={[1].age}"))
+}
+
+func TestOutputUnknownFormat(t *testing.T) {
+	app := newTestApp()
+	app.OutputFlag()
+	_, err := app.Parse([]string{"-o", "xml"})
+	assert.Error(t, err)
+}
+
+func TestCustomFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	app := newTestApp().OutputWriter(&buf)
+	app.Formatter("upper", FormatterFunc(func(w io.Writer, v interface{}, arg string) error {
+		_, err := fmt.Fprintln(w, strings.ToUpper(v.(testPerson).Name))
+		return err
+	}))
+	app.OutputFlag()
+	app.Action(func(ctx *ParseContext) error {
+		return ctx.Print(testPerson{Name: "alice"})
+	})
+	_, err := app.Parse([]string{"-o", "upper"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ALICE\n", buf.String())
+}
@@ -0,0 +1,60 @@
+package kingpin
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// PagedHelp pipes help through a pager when it is written to a terminal and
+// does not fit on the screen, as git does. The pager is taken from the PAGER
+// environment variable, falling back to "less". As with git, LESS defaults
+// to "FRX" so less exits if the help fits after all and colors are kept.
+func (a *Application) PagedHelp() *Application {
+	a.pagedHelp = true
+	return a
+}
+
+// The height of the terminal w writes to, or 0 if it is not a terminal.
+// Replaced in tests.
+var terminalHeight = guessHeight
+
+// Run pager with text as its input, writing its output to w and its errors
+// to errW. Replaced in tests.
+var runPager = func(pager string, w, errW io.Writer, text []byte) error {
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = bytes.NewReader(text)
+	cmd.Stdout = w
+	cmd.Stderr = errW
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=FRX")
+	}
+	return cmd.Run()
+}
+
+// A pager buffers usage written to w, so it can be paged if it is too long.
+type pager struct {
+	bytes.Buffer
+	w    io.Writer
+	errW io.Writer // For errors from the pager itself.
+}
+
+// Write the buffered usage to w, through a pager if it is taller than the
+// terminal.
+func (p *pager) flush() error {
+	height := terminalHeight(p.w)
+	if height > 0 && bytes.Count(p.Bytes(), []byte("\n")) >= height {
+		command := strings.TrimSpace(os.Getenv("PAGER"))
+		if command == "" {
+			command = "less"
+		}
+		// If the pager can't be run fall back to writing directly.
+		if command != "cat" && runPager(command, p.w, p.errW, p.Bytes()) == nil {
+			return nil
+		}
+	}
+	_, err := p.WriteTo(p.w)
+	return err
+}
@@ -0,0 +1,48 @@
+package kingpin
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestPagedHelp(t *testing.T) {
+	height, run := terminalHeight, runPager
+	t.Cleanup(func() { terminalHeight, runPager = height, run })
+	os.Setenv("PAGER", "more")
+	defer os.Unsetenv("PAGER")
+	paged := ""
+	var pagerErrW io.Writer
+	runPager = func(pager string, w, errW io.Writer, text []byte) error {
+		paged, pagerErrW = pager, errW
+		_, err := w.Write(text)
+		return err
+	}
+
+	w, errW := &bytes.Buffer{}, &bytes.Buffer{}
+	app := newTestApp().Writer(w).ErrorWriter(errW).PagedHelp()
+	app.Command("run", "Run it.")
+
+	// Fits on the screen.
+	terminalHeight = func(io.Writer) int { return 100 }
+	app.Usage(nil)
+	assert.Equal(t, "", paged)
+	assert.Contains(t, w.String(), "Run it.")
+
+	// Too tall for the screen.
+	w.Reset()
+	terminalHeight = func(io.Writer) int { return 5 }
+	app.Usage(nil)
+	assert.Equal(t, "more", paged)
+	assert.Equal(t, errW, pagerErrW)
+	assert.Contains(t, w.String(), "Run it.")
+
+	// Not a terminal.
+	paged = ""
+	terminalHeight = func(io.Writer) int { return 0 }
+	app.Usage(nil)
+	assert.Equal(t, "", paged)
+}
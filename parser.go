@@ -2,8 +2,11 @@ package kingpin
 
 import (
 	"bufio"
-	"fmt"
+	"context"
+	"errors"
+	"io"
 	"os"
+	"reflect"
 	"strings"
 	"unicode/utf8"
 )
@@ -80,25 +83,72 @@ type ParseElement struct {
 	Clause interface{}
 	// Value is corresponding value for an ArgClause or FlagClause (if any).
 	Value *string
+	// Raw holds the command-line arguments the element was parsed from,
+	// exactly as given (after @file expansion).
+	Raw []string
 }
 
 // ParseContext holds the current context of the parser. When passed to
 // Action() callbacks Elements will be fully populated with *FlagClause,
 // *ArgClause and *Cmd values and their corresponding arguments (if
 // any).
+//
+// Rather than inspecting Elements, actions and validators should generally
+// use SelectedCommand and the accessors: SelectedCommands(), FlagValue(),
+// FlagValues(), ArgValue() and ArgValues() for what was given on the command
+// line, and Value(), IsSet(), SourceOf() and the typed Get methods such as
+// GetString() for the values resolved from all sources.
 type ParseContext struct {
 	SelectedCommand *Cmd
+	app             *Application
 	ignoreDefault   bool
 	argsOnly        bool
 	peek            []*Token
 	argi            int // Index of current command-line arg we're processing.
 	args            []string
 	rawArgs         []string
+	consumed        []string // Arguments consumed so far. Token.Index-1 indexes into this.
 	flags           *flagGroup
 	arguments       *argGroup
 	argumenti       int // Cursor into arguments
 	// Flags, arguments and commands encountered and collected during parse.
-	Elements []*ParseElement
+	Elements    []*ParseElement
+	sources     map[interface{}]ValueSource // Where each flag and arg value came from.
+	profile     string                      // Name of the selected profile, if any.
+	configFiles map[string]string           // Config file each flag's value was read from.
+	actionErr   error                       // See ActionError().
+	ctx         context.Context             // See Context().
+	injected    map[reflect.Type]reflect.Value
+	alias       string // User alias the command line was expanded from, if any.
+	helpLevel   int    // Level of flags and commands shown in help.
+	helpValues  bool   // Show resolved values in help, see --help-values.
+	// Unknown flags collected for each target, see CollectUnknownFlags().
+	unknownTarget *[]string
+	unknownFlags  map[*[]string][]string
+	parseErr      error // Returned by Application.ParseContext().
+	// Commands other than the default subcommand selected, for completion.
+	completionAlts map[*Cmd][]string
+	// Inherited flags redefined by the selected command, see Override().
+	shadowedFlags []*FlagClause
+	// Values of the flags and arguments in scope, see captureValues().
+	values map[interface{}]interface{}
+}
+
+// Context returns the context.Context passed to
+// Application.ParseWithContext(), or context.Background() if the application
+// was parsed with Parse().
+func (p *ParseContext) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+	return p.ctx
+}
+
+// ActionError returns the error, if any, from validating the parsed values or
+// running the actions of the application, commands, flags and arguments. It
+// is intended for use by post-actions.
+func (p *ParseContext) ActionError() error {
+	return p.actionErr
 }
 
 func (p *ParseContext) nextArg() *ArgClause {
@@ -112,11 +162,85 @@ func (p *ParseContext) nextArg() *ArgClause {
 	return arg
 }
 
+// Arguments following a repeatable argument, as in "cp <src>... <dest>",
+// are never reached while parsing as the repeatable argument consumes every
+// value. Reassign the last values it consumed to them, leaving at least one
+// for the repeatable argument if it is required.
+func (p *ParseContext) allocateTrailingArgs() {
+	args := p.arguments.args
+	i := 0
+	for i < len(args) && !args[i].consumesRemainder() {
+		i++
+	}
+	if i >= len(args)-1 {
+		return
+	}
+	variadic, trailing := args[i], args[i+1:]
+	elements := []*ParseElement{}
+	for _, element := range p.Elements {
+		if element.Clause == variadic {
+			elements = append(elements, element)
+		}
+	}
+	n := len(trailing)
+	if available := len(elements); variadic.required && available-1 < n {
+		n = available - 1
+	} else if available < n {
+		n = available
+	}
+	if n < 0 {
+		n = 0
+	}
+	for j, element := range elements[len(elements)-n:] {
+		element.Clause = trailing[j]
+	}
+}
+
 func (p *ParseContext) next() {
+	p.consumed = append(p.consumed, p.args[0])
 	p.argi++
 	p.args = p.args[1:]
 }
 
+// The distinct command-line arguments that tokens were parsed from.
+func (p *ParseContext) raw(tokens ...*Token) []string {
+	out := []string{}
+	last := -1
+	for _, token := range tokens {
+		if token.Index != last && token.Index > 0 && token.Index <= len(p.consumed) {
+			out = append(out, p.consumed[token.Index-1])
+		}
+		last = token.Index
+	}
+	return out
+}
+
+// Stdout returns the writer actions should use for regular output, as set
+// with Application.OutputWriter().
+func (p *ParseContext) Stdout() io.Writer {
+	return p.app.outputWriter
+}
+
+// Stderr returns the writer actions should use for diagnostics, as set with
+// Application.ErrorWriter().
+func (p *ParseContext) Stderr() io.Writer {
+	return p.app.errorWriter
+}
+
+// RawArgs returns the positional arguments of the parse, including any after
+// "--", exactly as they were given on the command line. This allows wrappers
+// to forward arguments byte-for-byte, including empty strings and values that
+// look like flags.
+func (p *ParseContext) RawArgs() []string {
+	out := []string{}
+	for _, element := range p.Elements {
+		if _, ok := element.Clause.(*ArgClause); ok {
+			out = append(out, element.Raw...)
+		}
+	}
+	return out
+}
+
 // HasTrailingArgs returns true if there are unparsed command-line arguments.
 // This can occur if the parser can not match remaining arguments.
 func (p *ParseContext) HasTrailingArgs() bool {
@@ -130,11 +254,16 @@ func tokenize(args []string, ignoreDefault bool) *ParseContext {
 		rawArgs:       args,
 		flags:         newFlagGroup(),
 		arguments:     newArgGroup(),
+
+		completionAlts: map[*Cmd][]string{},
 	}
 }
 
 func (p *ParseContext) mergeFlags(flags *flagGroup) {
 	for _, flag := range flags.flagOrder {
+		if shadowed, ok := p.flags.long[flag.name]; ok && shadowed != flag {
+			p.shadowFlag(shadowed)
+		}
 		if flag.shorthand != 0 {
 			p.flags.short[string(flag.shorthand)] = flag
 		}
@@ -177,6 +306,12 @@ func (p *ParseContext) Next() *Token {
 		return p.Next()
 	}
 
+	if p.app != nil && p.app.windowsFlags && len(arg) > 1 && arg[0] == '/' {
+		if token := p.windowsFlag(arg[1:]); token != nil {
+			return token
+		}
+	}
+
 	if strings.HasPrefix(arg, "--") {
 		parts := strings.SplitN(arg[2:], "=", 2)
 		token := &Token{p.argi, TokenLong, parts[0]}
@@ -195,6 +330,10 @@ func (p *ParseContext) Next() *Token {
 		flag, ok := p.flags.short[short]
 		// Not a known short flag, we'll just return it anyway.
 		if !ok {
+			if p.unknownTarget != nil {
+				// Keep the rest of the argument with it, to be collected as is.
+				return &Token{p.argi, TokenShort, arg[1:]}
+			}
 		} else if fb, ok := flag.value.(boolFlag); ok && fb.IsBoolFlag() {
 			// Bool short flag.
 		} else {
@@ -226,6 +365,31 @@ func (p *ParseContext) Next() *Token {
 	return &Token{p.argi, TokenArg, arg}
 }
 
+// Tokenize a Windows style flag (without the leading "/"), eg. "verbose" or
+// "output:file.txt". Returns nil if s does not name a known flag.
+func (p *ParseContext) windowsFlag(s string) *Token {
+	name, value := s, ""
+	hasValue := false
+	if i := strings.IndexAny(s, ":="); i >= 0 {
+		name, value, hasValue = s[:i], s[i+1:], true
+	}
+	if name == "?" {
+		name = "help"
+	}
+	var token *Token
+	if _, ok := p.flags.long[strings.TrimPrefix(name, "no-")]; ok {
+		token = &Token{p.argi, TokenLong, name}
+	} else if _, ok := p.flags.short[name]; ok {
+		token = &Token{p.argi, TokenShort, name}
+	} else {
+		return nil
+	}
+	if hasValue {
+		p.Push(&Token{p.argi, TokenArg, value})
+	}
+	return token
+}
+
 func (p *ParseContext) Peek() *Token {
 	if len(p.peek) == 0 {
 		return p.Push(p.Next())
@@ -249,12 +413,12 @@ func (p *ParseContext) String() string {
 	return p.SelectedCommand.FullCommand()
 }
 
-func (p *ParseContext) matchedFlag(flag *FlagClause, value string) {
-	p.Elements = append(p.Elements, &ParseElement{Clause: flag, Value: &value})
+func (p *ParseContext) matchedFlag(flag *FlagClause, value string, tokens ...*Token) {
+	p.Elements = append(p.Elements, &ParseElement{Clause: flag, Value: &value, Raw: p.raw(tokens...)})
 }
 
-func (p *ParseContext) matchedArg(arg *ArgClause, value string) {
-	p.Elements = append(p.Elements, &ParseElement{Clause: arg, Value: &value})
+func (p *ParseContext) matchedArg(arg *ArgClause, value string, tokens ...*Token) {
+	p.Elements = append(p.Elements, &ParseElement{Clause: arg, Value: &value, Raw: p.raw(tokens...)})
 }
 
 func (p *ParseContext) matchedCmd(cmd *Cmd) {
@@ -289,6 +453,25 @@ func parse(context *ParseContext, app *Application) (err error) {
 
 	cmds := app.cmdGroup
 	ignoreDefault := context.ignoreDefault
+	interspersed := !app.noInterspersed
+	passThrough := ""
+	mixedArgs := app.mixedArgs
+	context.unknownTarget = app.unknownFlags
+	selectCmd := func(cmd *Cmd) {
+		// Arguments of the parent left unmatched, see AllowArgsWithCommands(),
+		// are not used once a subcommand is selected.
+		context.arguments.args = context.arguments.args[:context.argumenti]
+		context.matchedCmd(cmd)
+		cmds = cmd.cmdGroup
+		if cmd.interspersed != nil {
+			interspersed = *cmd.interspersed
+		}
+		passThrough = cmd.passThrough
+		mixedArgs = cmd.mixedArgs
+		if cmd.unknownFlags != nil {
+			context.unknownTarget = cmd.unknownFlags
+		}
+	}
 
 loop:
 	for !context.EOL() {
@@ -299,43 +482,41 @@ loop:
 			if flag, err := context.flags.parse(context); err != nil {
 				if !ignoreDefault {
 					if cmd := cmds.defaultSubcommand(); cmd != nil {
-						cmd.completionAlts = cmds.cmdNames()
-						context.matchedCmd(cmd)
-						cmds = cmd.cmdGroup
+						context.completionAlts[cmd] = cmds.completionNames()
+						selectCmd(cmd)
 						break
 					}
 				}
 				return err
+			} else if !context.flagInScope(flag) {
+				return errorf(MsgLocalFlag, "--"+flag.name, context.SelectedCommand.FullCommand())
 			} else if flag == HelpFlag {
 				ignoreDefault = true
 			}
 
 		case TokenArg:
-			if cmds.have() {
+			if cmds.have() && (!mixedArgs || cmds.commands[token.String()] != nil) {
 				selectedDefault := false
 				cmd, ok := cmds.commands[token.String()]
 				if !ok {
 					if !ignoreDefault {
 						if cmd = cmds.defaultSubcommand(); cmd != nil {
-							cmd.completionAlts = cmds.cmdNames()
 							selectedDefault = true
 						}
 					}
 					if cmd == nil {
-						return fmt.Errorf("expected command but got %q", token)
+						return errorf(MsgExpectedCommand, token).suggest(token.Value, "'%s'", cmds.visibleNames())
 					}
 				}
 				if cmd == HelpCommand {
 					ignoreDefault = true
 				}
-				cmd.completionAlts = nil
-				context.matchedCmd(cmd)
-				cmds = cmd.cmdGroup
+				selectCmd(cmd)
 				if !selectedDefault {
 					context.Next()
 				}
 			} else if context.arguments.have() {
-				if app.noInterspersed {
+				if !interspersed {
 					// no more flags
 					context.argsOnly = true
 				}
@@ -343,12 +524,22 @@ loop:
 				if arg == nil {
 					break loop
 				}
-				context.matchedArg(arg, token.String())
+				context.matchedArg(arg, token.String(), token)
 				context.Next()
+				if arg.name == passThrough {
+					context.argsOnly = true
+				}
+				if mixedArgs {
+					// Following values are arguments, not subcommands.
+					cmds = newCmdGroup(app)
+				}
 			} else {
 				break loop
 			}
 
+		case TokenError:
+			return errors.New(token.Value)
+
 		case TokenEOL:
 			break loop
 		}
@@ -357,26 +548,63 @@ loop:
 	// Move to innermost default command.
 	for !ignoreDefault {
 		if cmd := cmds.defaultSubcommand(); cmd != nil {
-			cmd.completionAlts = cmds.cmdNames()
-			context.matchedCmd(cmd)
-			cmds = cmd.cmdGroup
+			context.completionAlts[cmd] = cmds.completionNames()
+			selectCmd(cmd)
 		} else {
 			break
 		}
 	}
 
 	if !context.EOL() {
-		return fmt.Errorf("unexpected %s", context.Peek())
+		return errorf(MsgUnexpectedToken, context.Peek())
 	}
 
+	context.allocateTrailingArgs()
+
 	// Set defaults for all remaining args.
 	for arg := context.nextArg(); arg != nil && !arg.consumesRemainder(); arg = context.nextArg() {
-		for _, defaultValue := range arg.defaultValues {
-			if err := arg.value.Set(defaultValue); err != nil {
-				return fmt.Errorf("invalid default value '%s' for argument '%s'", defaultValue, arg.name)
+		for _, defaultValue := range arg.defaults() {
+			if err := arg.set(defaultValue, false); err != nil {
+				return errorf(MsgInvalidArgDefault, defaultValue, arg.name)
 			}
 		}
 	}
 
 	return
 }
+
+// Find the flag or argument in scope with the given name.
+func (p *ParseContext) lookup(name string) (clause interface{}, value Value) {
+	if flag, ok := p.flags.long[name]; ok {
+		return flag, flag.value
+	}
+	for _, arg := range p.arguments.args {
+		if arg.name == name {
+			return arg, arg.value
+		}
+	}
+	return nil, nil
+}
+
+// Value returns the value of the flag or argument in scope with the given
+// name, or nil if there is no such flag or argument. Values implementing
+// Getter return the result of Get(), others their String() form.
+func (p *ParseContext) Value(name string) interface{} {
+	clause, value := p.lookup(name)
+	if value == nil {
+		return nil
+	}
+	return p.clauseValue(clause, value)
+}
+
+// IsSet returns true if the flag or argument with the given name was given a
+// value other than its default, eg. on the command line or from the
+// environment.
+func (p *ParseContext) IsSet(name string) bool {
+	clause, _ := p.lookup(name)
+	if clause == nil {
+		return false
+	}
+	source := p.sourceOf(clause)
+	return source != SourceNone && source != SourceDefault
+}
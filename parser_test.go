@@ -120,3 +120,86 @@ func TestParseContextPush(t *testing.T) {
 	b = c.Next()
 	assert.Equal(t, "bar", b.Value)
 }
+
+func TestParseContextRawArgs(t *testing.T) {
+	app := newTestApp()
+	app.Flag("name", "").String()
+	cmd := app.Command("exec", "")
+	cmd.Arg("command", "").String()
+	cmd.Arg("args", "").Strings()
+	ctx, err := app.ParseContext([]string{"--name=x", "exec", "ls", "--", "", "--all", "-l"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ls", "", "--all", "-l"}, ctx.RawArgs())
+	assert.Equal(t, []string{"--name=x"}, ctx.Elements[0].Raw)
+}
+
+func TestParseContextAccessors(t *testing.T) {
+	app := newTestApp()
+	app.Flag("label", "").Strings()
+	app.Flag("verbose", "").Bool()
+	user := app.Command("user", "")
+	add := user.Command("add", "")
+	add.Arg("name", "").String()
+	add.Arg("groups", "").Strings()
+	ctx, err := app.ParseContext([]string{"--label=a", "user", "add", "--label", "b", "--no-verbose", "bob", "admin", "dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", ctx.FlagValue("label"))
+	assert.Equal(t, []string{"a", "b"}, ctx.FlagValues("label"))
+	assert.Equal(t, "false", ctx.FlagValue("verbose"))
+	assert.Equal(t, "", ctx.FlagValue("missing"))
+	assert.Equal(t, "bob", ctx.ArgValue("name"))
+	assert.Equal(t, []string{"admin", "dev"}, ctx.ArgValues("groups"))
+	assert.Equal(t, []*Cmd{user, add}, ctx.SelectedCommands())
+	assert.Equal(t, add, ctx.SelectedCommand)
+}
+
+func TestWindowsFlags(t *testing.T) {
+	app := newTestApp().AllowWindowsFlags()
+	verbose := app.Flag("verbose", "").Short('v').Bool()
+	output := app.Flag("output", "").Short('o').String()
+	debug := app.Flag("debug", "").Default("true").Bool()
+	path := app.Arg("path", "").String()
+
+	_, err := app.Parse([]string{"/verbose", "/o:out.txt", "/no-debug", "/tmp"})
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+	assert.Equal(t, "out.txt", *output)
+	assert.False(t, *debug)
+	assert.Equal(t, "/tmp", *path)
+
+	_, err = app.Parse([]string{"/output=x", "--verbose"})
+	assert.NoError(t, err)
+	assert.Equal(t, "x", *output)
+
+	_, err = app.Parse([]string{"/verbose:false", "/debug:true", "/tmp"})
+	assert.NoError(t, err)
+	assert.False(t, *verbose)
+	assert.True(t, *debug)
+	assert.Equal(t, "/tmp", *path)
+
+	_, err = app.Parse([]string{"/v:true", "/no-debug:false"})
+	assert.NoError(t, err)
+	assert.True(t, *verbose)
+	assert.True(t, *debug)
+
+	_, err = app.Parse([]string{"/verbose:maybe"})
+	assert.Error(t, err)
+
+	_, err = app.Parse([]string{"--verbose=false", "--debug=true"})
+	assert.NoError(t, err)
+	assert.False(t, *verbose)
+	assert.True(t, *debug)
+
+	app = newTestApp().AllowWindowsFlags()
+	level := app.Flag("level", "").Short('l').Counter()
+	_, err = app.Parse([]string{"/l:3"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *level)
+
+	app = newTestApp()
+	path = app.Arg("path", "").String()
+	app.Flag("verbose", "").Bool()
+	_, err = app.Parse([]string{"/verbose"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/verbose", *path)
+}
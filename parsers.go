@@ -1,9 +1,11 @@
 package kingpin
 
 import (
+	"fmt"
 	"net"
 	"net/url"
 	"os"
+	"regexp"
 	"time"
 
 	"github.com/alecthomas/units"
@@ -14,8 +16,12 @@ type Settings interface {
 }
 
 type parserMixin struct {
-	value    Value
-	required bool
+	value       Value
+	required    bool
+	unknownType string // See Type()
+	valueErr    error  // Invalid value definition, reported by init().
+	separator   string // See KeyValueSeparator().
+	fold        bool   // See CaseInsensitive().
 }
 
 func (p *parserMixin) SetValue(value Value) {
@@ -62,6 +68,42 @@ func (p *parserMixin) TCPVar(target **net.TCPAddr) {
 	p.SetValue(newTCPAddrValue(target))
 }
 
+// TCPAddr (host:port) address. An alias for TCP().
+func (p *parserMixin) TCPAddr() (target **net.TCPAddr) {
+	return p.TCP()
+}
+
+// TCPAddrVar (host:port) address. An alias for TCPVar().
+func (p *parserMixin) TCPAddrVar(target **net.TCPAddr) {
+	p.TCPVar(target)
+}
+
+// CIDR sets the parser to a network in CIDR notation, eg. 10.0.0.0/8.
+func (p *parserMixin) CIDR() (target **net.IPNet) {
+	target = new(*net.IPNet)
+	p.CIDRVar(target)
+	return
+}
+
+// CIDRVar sets the parser to a network in CIDR notation.
+func (p *parserMixin) CIDRVar(target **net.IPNet) {
+	p.SetValue(newCIDRValue(target))
+}
+
+// HostPort sets the parser to a "host:port" address with a numeric port,
+// without resolving the host. Unlike TCP(), the host may be a name that does
+// not (yet) resolve.
+func (p *parserMixin) HostPort() (target *string) {
+	target = new(string)
+	p.HostPortVar(target)
+	return
+}
+
+// HostPortVar sets the parser to a "host:port" address. See HostPort().
+func (p *parserMixin) HostPortVar(target *string) {
+	p.SetValue(newHostPortValue(target))
+}
+
 // ExistingFile sets the parser to one that requires and returns an existing file.
 func (p *parserMixin) ExistingFile() (target *string) {
 	target = new(string)
@@ -83,14 +125,55 @@ func (p *parserMixin) ExistingFileOrDir() (target *string) {
 	return
 }
 
-// File returns an os.File against an existing file.
+// ReadableFile sets the parser to one that requires an existing file that can
+// be opened for reading.
+func (p *parserMixin) ReadableFile() (target *string) {
+	target = new(string)
+	p.ReadableFileVar(target)
+	return
+}
+
+// CreatableFile sets the parser to one that requires a path that can be
+// written: either an existing, writable file, or a new file in an existing,
+// writable directory.
+func (p *parserMixin) CreatableFile() (target *string) {
+	target = new(string)
+	p.CreatableFileVar(target)
+	return
+}
+
+// WritableDir sets the parser to one that requires an existing directory in
+// which files can be created.
+func (p *parserMixin) WritableDir() (target *string) {
+	target = new(string)
+	p.WritableDirVar(target)
+	return
+}
+
+// ExistingDirOrCreate sets the parser to one that requires a directory,
+// creating it (and any missing parents) if it does not exist.
+func (p *parserMixin) ExistingDirOrCreate() (target *string) {
+	target = new(string)
+	p.ExistingDirOrCreateVar(target)
+	return
+}
+
+// File returns an os.File against an existing file. The value "-" selects
+// os.Stdin.
 func (p *parserMixin) File() (target **os.File) {
 	target = new(*os.File)
 	p.FileVar(target)
 	return
 }
 
-// File attempts to open a File with os.OpenFile(flag, perm).
+// OpenFile attempts to open a File with os.OpenFile(flag, perm).
+//
+// The value "-" selects os.Stdin if flag opens the file read-only, or
+// os.Stdout otherwise, so filter-style commands can read from or write to a
+// pipe, eg.
+//
+//     in := app.Arg("input", "Input file, or - for stdin.").Default("-").File()
+//     out := app.Flag("output", "Output file.").Default("-").OpenFile(os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
 func (p *parserMixin) OpenFile(flag int, perm os.FileMode) (target **os.File) {
 	target = new(*os.File)
 	p.OpenFileVar(target, flag, perm)
@@ -106,7 +189,54 @@ func (p *parserMixin) URL() (target **url.URL) {
 
 // StringMap provides key=value parsing into a map.
 func (p *parserMixin) StringMapVar(target *map[string]string) {
-	p.SetValue(newStringMapValue(target))
+	p.SetValue(newMapValue(target, func(v interface{}) Value {
+		return newStringValue(v.(*string))
+	}))
+}
+
+// StringToIntMap provides key=value parsing into a map with integer values,
+// eg. --limit cpu=2 --limit mem=512.
+func (p *parserMixin) StringToIntMap() (target *map[string]int) {
+	target = &(map[string]int{})
+	p.StringToIntMapVar(target)
+	return
+}
+
+// StringToIntMapVar provides key=value parsing into a map with integer values.
+func (p *parserMixin) StringToIntMapVar(target *map[string]int) {
+	p.SetValue(newMapValue(target, func(v interface{}) Value {
+		return newIntValue(v.(*int))
+	}))
+}
+
+// StringToDurationMap provides key=value parsing into a map with
+// time.Duration values, eg. --timeout connect=5s --timeout read=1m.
+func (p *parserMixin) StringToDurationMap() (target *map[string]time.Duration) {
+	target = &(map[string]time.Duration{})
+	p.StringToDurationMapVar(target)
+	return
+}
+
+// StringToDurationMapVar provides key=value parsing into a map with
+// time.Duration values.
+func (p *parserMixin) StringToDurationMapVar(target *map[string]time.Duration) {
+	p.SetValue(newMapValue(target, func(v interface{}) Value {
+		return newDurationValue(v.(*time.Duration))
+	}))
+}
+
+// Apply KeyValueSeparator() and CaseInsensitive() to the value.
+func (p *parserMixin) applyValueOptions() {
+	switch value := p.value.(type) {
+	case *mapValue:
+		if p.separator != "" {
+			value.separator = p.separator
+		}
+	case *enumValue:
+		value.fold = p.fold
+	case *enumsValue:
+		value.fold = p.fold
+	}
 }
 
 // Float sets the parser to a float64 parser.
@@ -124,6 +254,76 @@ func (p *parserMixin) DurationVar(target *time.Duration) {
 	p.SetValue(newDurationValue(target))
 }
 
+// ExtendedDuration sets the parser to a time.Duration parser that also
+// accepts the units d (24h), w (7d) and y (365d), eg. "2w" or "1d12h".
+func (p *parserMixin) ExtendedDuration() (target *time.Duration) {
+	target = new(time.Duration)
+	p.ExtendedDurationVar(target)
+	return
+}
+
+// ExtendedDurationVar sets the parser to a time.Duration parser that also
+// accepts the units d, w and y. See ExtendedDuration().
+func (p *parserMixin) ExtendedDurationVar(target *time.Duration) {
+	p.SetValue(newExtendedDurationValue(target))
+}
+
+// ByteSize parses a number of bytes with an optional SI or IEC unit, eg.
+// 512, 10K, 1.5GiB or 2GB. Single letter units (K, M, G, ...) are powers of
+// 1024, as are KiB, MiB and so on, while kB, MB, GB, ... are powers of 1000.
+//
+// Unlike Bytes(), KB and KiB are not equivalent.
+func (p *parserMixin) ByteSize() (target *int64) {
+	target = new(int64)
+	p.ByteSizeVar(target)
+	return
+}
+
+// ByteSizeVar parses a number of bytes. See ByteSize().
+func (p *parserMixin) ByteSizeVar(target *int64) {
+	p.SetValue(newByteSizeValue(target))
+}
+
+// Time sets the parser to a time.Time parser. Times may be given in RFC3339
+// format, in any of the given layouts (see time.Parse), as "now", "today",
+// "yesterday" or "tomorrow", or relative to now, eg. "-2h" or "+1d".
+//
+// Layouts without a time zone are interpreted in local time.
+func (p *parserMixin) Time(layouts ...string) (target *time.Time) {
+	target = new(time.Time)
+	p.TimeVar(target, layouts...)
+	return
+}
+
+// TimeVar sets the parser to a time.Time parser. See Time().
+func (p *parserMixin) TimeVar(target *time.Time, layouts ...string) {
+	p.SetValue(newTimeValue(target, layouts...))
+}
+
+// StringMatching sets the parser to a string parser that only accepts values
+// matching the regular expression pattern, eg.
+//
+//     name := app.Flag("name", "").StringMatching(`^[a-z][a-z0-9-]*$`)
+//
+// The pattern is compiled once; an invalid pattern is reported when the
+// application is initialised.
+func (p *parserMixin) StringMatching(pattern string) (target *string) {
+	target = new(string)
+	p.StringMatchingVar(target, pattern)
+	return
+}
+
+// StringMatchingVar sets the parser to a string parser that only accepts
+// values matching pattern. See StringMatching().
+func (p *parserMixin) StringMatchingVar(target *string, pattern string) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		p.valueErr = fmt.Errorf("invalid pattern %q: %s", pattern, err)
+		return
+	}
+	p.SetValue(newStringMatchingValue(target, re))
+}
+
 // BytesVar parses numeric byte units. eg. 1.5KB
 func (p *parserMixin) BytesVar(target *units.Base2Bytes) {
 	p.SetValue(newBytesValue(target))
@@ -149,6 +349,27 @@ func (p *parserMixin) ExistingFileOrDirVar(target *string) {
 	p.SetValue(newExistingFileOrDirValue(target))
 }
 
+// ReadableFileVar sets the parser to a readable file. See ReadableFile().
+func (p *parserMixin) ReadableFileVar(target *string) {
+	p.SetValue(newReadableFileValue(target))
+}
+
+// CreatableFileVar sets the parser to a writable file path. See CreatableFile().
+func (p *parserMixin) CreatableFileVar(target *string) {
+	p.SetValue(newCreatableFileValue(target))
+}
+
+// WritableDirVar sets the parser to a writable directory. See WritableDir().
+func (p *parserMixin) WritableDirVar(target *string) {
+	p.SetValue(newWritableDirValue(target))
+}
+
+// ExistingDirOrCreateVar sets the parser to a directory that is created if
+// missing. See ExistingDirOrCreate().
+func (p *parserMixin) ExistingDirOrCreateVar(target *string) {
+	p.SetValue(newExistingDirOrCreateValue(target))
+}
+
 // FileVar opens an existing file.
 func (p *parserMixin) FileVar(target **os.File) {
 	p.SetValue(newFileValue(target, os.O_RDONLY, 0))
@@ -176,6 +397,36 @@ func (p *parserMixin) URLListVar(target *[]*url.URL) {
 	p.SetValue(newURLListValue(target))
 }
 
+// URLWithSchemes provides a valid, parsed url.URL whose scheme is one of
+// schemes (compared case-insensitively), eg.
+//
+//     endpoint := app.Flag("endpoint", "").URLWithSchemes("http", "https")
+func (p *parserMixin) URLWithSchemes(schemes ...string) (target **url.URL) {
+	target = new(*url.URL)
+	p.URLWithSchemesVar(target, schemes...)
+	return
+}
+
+// URLWithSchemesVar provides a valid, parsed url.URL whose scheme is one of
+// schemes.
+func (p *parserMixin) URLWithSchemesVar(target **url.URL, schemes ...string) {
+	p.SetValue(newURLValue(target, schemes...))
+}
+
+// URLListWithSchemes provides a parsed list of url.URL values, each of whose
+// scheme is one of schemes.
+func (p *parserMixin) URLListWithSchemes(schemes ...string) (target *[]*url.URL) {
+	target = new([]*url.URL)
+	p.URLListWithSchemesVar(target, schemes...)
+	return
+}
+
+// URLListWithSchemesVar provides a parsed list of url.URL values, each of
+// whose scheme is one of schemes.
+func (p *parserMixin) URLListWithSchemesVar(target *[]*url.URL, schemes ...string) {
+	p.SetValue(newURLListValue(target, schemes...))
+}
+
 // Enum allows a value from a set of options.
 func (p *parserMixin) Enum(options ...string) (target *string) {
 	target = new(string)
@@ -200,7 +451,9 @@ func (p *parserMixin) EnumsVar(target *[]string, options ...string) {
 	p.SetValue(newEnumsFlag(target, options...))
 }
 
-// A Counter increments a number each time it is encountered.
+// A Counter increments a number each time it is encountered, so that short
+// flags can be clustered, eg. -vvv. Negating the flag (--no-verbose) resets
+// it to zero, and an explicit count may be given by an envar or default.
 func (p *parserMixin) Counter() (target *int) {
 	target = new(int)
 	p.CounterVar(target)
@@ -1,10 +1,13 @@
 package kingpin
 
 import (
+	"fmt"
 	"io/ioutil"
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/tj/assert"
 
@@ -52,6 +55,28 @@ func TestParseURL(t *testing.T) {
 	assert.Equal(t, *u, **v)
 }
 
+func TestParseURLWithSchemes(t *testing.T) {
+	p := parserMixin{}
+	v := p.URLWithSchemes("http", "https")
+	assert.NoError(t, p.value.Set("HTTPS://w3.org"))
+	assert.Equal(t, "w3.org", (*v).Host)
+	assert.EqualError(t, p.value.Set("ftp://w3.org"), "URL 'ftp://w3.org' must use one of the schemes http, https")
+	assert.Equal(t, "w3.org", (*v).Host)
+}
+
+func TestParseURLListWithSchemes(t *testing.T) {
+	app := newTestApp()
+	mirrors := app.Flag("mirror", "").URLListWithSchemes("https")
+	_, err := app.Parse([]string{"--mirror=https://a.example", "--mirror=https://b.example"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(*mirrors))
+	assert.Equal(t, "b.example", (*mirrors)[1].Host)
+	assert.Equal(t, "https://a.example,https://b.example", app.GetFlag("mirror").value.String())
+
+	_, err = app.Parse([]string{"--mirror=http://c.example"})
+	assert.EqualError(t, err, "URL 'http://c.example' must use one of the schemes https")
+}
+
 func TestParseExistingFile(t *testing.T) {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -96,3 +121,162 @@ func TestFloat32(t *testing.T) {
 	assert.NoError(t, err)
 	assert.InEpsilon(t, 123.45, *v, 0.001)
 }
+
+func TestParseCIDR(t *testing.T) {
+	p := parserMixin{}
+	v := p.CIDR()
+	assert.NoError(t, p.value.Set("10.1.2.3/8"))
+	assert.Equal(t, "10.0.0.0/8", (*v).String())
+	assert.EqualError(t, p.value.Set("10.1.2.3"), "'10.1.2.3' is not a CIDR network, eg. 10.0.0.0/8")
+}
+
+func TestParseHostPort(t *testing.T) {
+	p := parserMixin{}
+	v := p.HostPort()
+	for in, out := range map[string]string{
+		"example.com:80": "example.com:80",
+		":8080":          ":8080",
+		"[::1]:443":      "[::1]:443",
+	} {
+		assert.NoError(t, p.value.Set(in), in)
+		assert.Equal(t, out, *v)
+	}
+	for _, in := range []string{"example.com", "example.com:http", "host:65536", "::1:80"} {
+		assert.EqualError(t, p.value.Set(in), "'"+in+"' is not a host:port address")
+	}
+}
+
+func TestNetworkCompletions(t *testing.T) {
+	app := newTestApp()
+	assert.NotNil(t, app.Flag("listen", "").HostPort())
+	assert.NotNil(t, app.Flag("allow", "").CIDR())
+	assert.Contains(t, app.GetFlag("listen").resolveCompletions(), ":8080")
+	assert.Contains(t, app.GetFlag("allow").resolveCompletions(), "10.0.0.0/8")
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2020, 3, 15, 10, 30, 0, 0, time.UTC)
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	p := parserMixin{}
+	v := p.Time("2006-01-02")
+	for in, out := range map[string]time.Time{
+		"2020-01-02T03:04:05Z": time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		"2019-12-31":           time.Date(2019, 12, 31, 0, 0, 0, 0, time.UTC),
+		"now":                  now,
+		"yesterday":            time.Date(2020, 3, 14, 0, 0, 0, 0, time.UTC),
+		"-2h":                  now.Add(-2 * time.Hour),
+		"+1d":                  now.Add(24 * time.Hour),
+	} {
+		assert.NoError(t, p.value.Set(in), in)
+		assert.Equal(t, out, *v, in)
+	}
+	err := p.value.Set("last tuesday")
+	assert.EqualError(t, err, "invalid time 'last tuesday', expected one of the layouts "+time.RFC3339+", 2006-01-02, or a relative time such as -2h or yesterday")
+}
+
+func TestParseStringMatching(t *testing.T) {
+	app := newTestApp()
+	name := app.Flag("name", "").StringMatching(`^[a-z][a-z0-9-]*$`)
+	_, err := app.Parse([]string{"--name=web-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "web-1", *name)
+
+	_, err = app.Parse([]string{"--name=Web_1"})
+	assert.EqualError(t, err, "'Web_1' does not match the pattern ^[a-z][a-z0-9-]*$")
+
+	app = newTestApp()
+	app.Arg("name", "").StringMatching(`[`)
+	_, err = app.Parse(nil)
+	assert.EqualError(t, err, "arg 'name': invalid pattern \"[\": error parsing regexp: missing closing ]: `[`")
+}
+
+func TestParseTypedMaps(t *testing.T) {
+	app := newTestApp()
+	limits := app.Flag("limit", "").StringToIntMap()
+	timeouts := app.Flag("timeout", "").KeyValueSeparator("->").StringToDurationMap()
+	_, err := app.Parse([]string{"--limit", "cpu=2", "--limit", "mem:512", "--timeout", "read->1m"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"cpu": 2, "mem": 512}, *limits)
+	assert.Equal(t, map[string]time.Duration{"read": time.Minute}, *timeouts)
+	assert.Equal(t, "map[cpu:2 mem:512]", app.GetFlag("limit").value.String())
+
+	_, err = app.Parse([]string{"--timeout", "read=1m"})
+	assert.EqualError(t, err, "expected KEY=VALUE got 'read=1m'")
+	_, err = app.Parse([]string{"--limit", "cpu=lots"})
+	assert.Error(t, err)
+}
+
+func TestParseAccessCheckedPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "file")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("x"), 0600))
+	missing := filepath.Join(dir, "missing")
+
+	p := parserMixin{}
+	readable := p.ReadableFile()
+	assert.NoError(t, p.value.Set(file))
+	assert.Equal(t, file, *readable)
+	assert.EqualError(t, p.value.Set(dir), fmt.Sprintf("'%s' is a directory", dir))
+	assert.EqualError(t, p.value.Set(missing), fmt.Sprintf("path '%s' does not exist", missing))
+
+	p = parserMixin{}
+	creatable := p.CreatableFile()
+	assert.NoError(t, p.value.Set(file))
+	assert.NoError(t, p.value.Set(missing))
+	assert.Equal(t, missing, *creatable)
+	nested := filepath.Join(missing, "file")
+	assert.EqualError(t, p.value.Set(nested), fmt.Sprintf("path '%s' does not exist", missing))
+
+	p = parserMixin{}
+	writable := p.WritableDir()
+	assert.NoError(t, p.value.Set(dir))
+	assert.Equal(t, dir, *writable)
+	assert.EqualError(t, p.value.Set(file), fmt.Sprintf("'%s' is a file", file))
+
+	p = parserMixin{}
+	created := p.ExistingDirOrCreate()
+	assert.NoError(t, p.value.Set(nested))
+	assert.Equal(t, nested, *created)
+	_, err = os.Stat(nested)
+	assert.True(t, os.IsNotExist(err))
+	assert.EqualError(t, p.value.Set(file), fmt.Sprintf("'%s' is a file", file))
+}
+
+func TestExistingDirOrCreateAfterParse(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kingpin")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	nested := filepath.Join(dir, "a", "b")
+
+	app := newTestApp()
+	app.OutputWriter(ioutil.Discard)
+	out := app.Flag("out", "").ExistingDirOrCreate()
+
+	_, err = app.Parse([]string{"--completion-bash", "--out", nested})
+	assert.NoError(t, err)
+	_, err = os.Stat(nested)
+	assert.True(t, os.IsNotExist(err))
+
+	_, err = app.Parse([]string{"--out", nested})
+	assert.NoError(t, err)
+	assert.Equal(t, nested, *out)
+	s, err := os.Stat(nested)
+	assert.NoError(t, err)
+	assert.True(t, s.IsDir())
+}
+
+func TestParseFileDash(t *testing.T) {
+	app := newTestApp()
+	in := app.Arg("input", "").Default("-").File()
+	out := app.Flag("output", "").Default("-").OpenFile(os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, os.Stdin, *in)
+	assert.Equal(t, os.Stdout, *out)
+}
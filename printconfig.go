@@ -0,0 +1,90 @@
+package kingpin
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Config file formats supported by WriteConfigAs().
+var configFormats = []string{"json", "yaml", "toml"}
+
+// PrintConfigFlag adds a --print-config=FORMAT flag that writes the resolved
+// value of every flag in scope for the selected command to the output writer
+// as a config file, then exits. This bootstraps a config file for
+// ConfigFile() from the flags currently being passed, eg.
+//
+//     app deploy --region=eu-west-1 --replicas=3 --print-config=yaml > app.yaml
+//
+// FORMAT is one of json, yaml or toml. Values of Secret() flags are redacted
+// as configured with Redact().
+func (a *Application) PrintConfigFlag() *FlagClause {
+	flag := a.Flag("print-config", "Print the resolved flags as a config file ("+strings.Join(configFormats, ", ")+") and exit.").
+		PlaceHolder("FORMAT")
+	flag.PreAction(func(context *ParseContext) error {
+		if err := context.WriteConfigAs(a.outputWriter, context.Value("print-config").(string)); err != nil {
+			return err
+		}
+		a.terminate(0)
+		return nil
+	})
+	flag.Enum(configFormats...)
+	a.printConfig = flag
+	return flag
+}
+
+// WriteConfigAs writes the values of all flags that were set to w as a config
+// file in format, one of json, yaml or toml. See WriteConfig().
+func (p *ParseContext) WriteConfigAs(w io.Writer, format string) error {
+	values := p.configValues()
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	switch format {
+	case "json":
+		return p.WriteConfig(w)
+	case "yaml":
+		for _, name := range names {
+			switch value := values[name].(type) {
+			case []string:
+				fmt.Fprintf(w, "%s:\n", name)
+				for _, item := range value {
+					fmt.Fprintf(w, "  - %s\n", yamlQuote(item))
+				}
+			case string:
+				fmt.Fprintf(w, "%s: %s\n", name, yamlQuote(value))
+			}
+		}
+	case "toml":
+		for _, name := range names {
+			switch value := values[name].(type) {
+			case []string:
+				quoted := make([]string, len(value))
+				for i, item := range value {
+					quoted[i] = strconv.Quote(item)
+				}
+				fmt.Fprintf(w, "%s = [%s]\n", name, strings.Join(quoted, ", "))
+			case string:
+				fmt.Fprintf(w, "%s = %s\n", name, strconv.Quote(value))
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported config format '%s', expected one of %s", format, strings.Join(configFormats, ", "))
+	}
+	return nil
+}
+
+var yamlPlainValue = regexp.MustCompile(`^[A-Za-z0-9_./][A-Za-z0-9_./+=-]*$`)
+
+// Quote value for YAML if it can't be written as a plain scalar.
+func yamlQuote(value string) string {
+	if yamlPlainValue.MatchString(value) {
+		return value
+	}
+	return strconv.Quote(value)
+}
@@ -0,0 +1,41 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestPrintConfigFlag(t *testing.T) {
+	for _, test := range []struct {
+		format   string
+		expected string
+	}{
+		{"yaml", "region: eu-west-1\nreplicas: 3\ntag:\n  - a\n  - \"b c\"\ntoken: \"******\"\n"},
+		{"toml", "region = \"eu-west-1\"\nreplicas = \"3\"\ntag = [\"a\", \"b c\"]\ntoken = \"******\"\n"},
+		{"json", "{\n  \"region\": \"eu-west-1\",\n  \"replicas\": \"3\",\n  \"tag\": [\n    \"a\",\n    \"b c\"\n  ],\n  \"token\": \"******\"\n}\n"},
+	} {
+		var out bytes.Buffer
+		app := newTestApp().OutputWriter(&out)
+		app.PrintConfigFlag()
+		deploy := app.Command("deploy", "")
+		deploy.Flag("region", "").Default("us-east-1").String()
+		deploy.Flag("replicas", "").Int()
+		deploy.Flag("tag", "").Strings()
+		deploy.Flag("token", "").Secret().String()
+		deploy.Flag("dry-run", "").Bool()
+		app.Command("other", "").Flag("unrelated", "").Default("x").String()
+
+		_, err := app.Parse([]string{"deploy", "--region=eu-west-1", "--replicas=3", "--tag=a", "--tag=b c", "--token=s3cret", "--print-config=" + test.format})
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, out.String(), test.format)
+
+		// The output can be read back as a config file.
+		if test.format != "json" {
+			values, err := parseConfig("app."+test.format, out.String())
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"a", "b c"}, values["tag"])
+		}
+	}
+}
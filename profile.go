@@ -0,0 +1,151 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Profile defines a named set of flag values, selected on the command line
+// with --profile=<name>. Values from the selected profile take precedence
+// over flag defaults, but not over environment variables or the command line.
+//
+// Cumulative flags can be given several values by separating them with new
+// lines.
+func (a *Application) Profile(name string, values map[string]string) *Application {
+	profile := map[string][]string{}
+	for flag, value := range values {
+		profile[flag] = strings.Split(value, "\n")
+	}
+	a.addProfile(name, profile)
+	return a
+}
+
+// LoadProfiles reads profiles from the "profiles" section of a JSON document
+// in the form:
+//
+//     {"profiles": {"dev": {"region": "eu-west-1", "tags": ["a", "b"]}}}
+func (a *Application) LoadProfiles(r io.Reader) error {
+	config := struct {
+		Profiles map[string]map[string]interface{} `json:"profiles"`
+	}{}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	if err := dec.Decode(&config); err != nil {
+		return fmt.Errorf("invalid profiles: %s", err)
+	}
+	for name, values := range config.Profiles {
+		profile := map[string][]string{}
+		for flag, value := range values {
+			v, err := jsonValues(value)
+			if err != nil {
+				return fmt.Errorf("invalid value for '%s' in profile '%s': %s", flag, name, err)
+			}
+			profile[flag] = v
+		}
+		a.addProfile(name, profile)
+	}
+	return nil
+}
+
+func (a *Application) addProfile(name string, profile map[string][]string) {
+	if a.profiles == nil {
+		a.profiles = map[string]map[string][]string{}
+		a.ProfileFlag = a.Flag("profile", "Apply a named set of flag values.").PlaceHolder("NAME")
+		a.ProfileFlag.HintAction(a.profileNames).StringVar(&a.profile)
+	}
+	a.profiles[name] = profile
+}
+
+func (a *Application) profileNames() []string {
+	names := make([]string, 0, len(a.profiles))
+	for name := range a.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Find the values of the profile selected in context, if any.
+func (a *Application) activeProfile(context *ParseContext) (map[string][]string, error) {
+	if a.ProfileFlag == nil {
+		return nil, nil
+	}
+	name := ""
+	for _, element := range context.Elements {
+		if element.Clause == a.ProfileFlag {
+			name = *element.Value
+		}
+	}
+	if name == "" {
+		if name = a.ProfileFlag.GetEnvarValue(); name == "" && len(a.ProfileFlag.defaultValues) > 0 {
+			name = a.ProfileFlag.defaultValues[0]
+		}
+	}
+	if name == "" {
+		return nil, nil
+	}
+	profile, ok := a.profiles[name]
+	if !ok {
+		return nil, errorf(MsgUnknownProfile, name, strings.Join(a.profileNames(), ","))
+	}
+	context.profile = name
+	return profile, nil
+}
+
+// ProfileCommand adds a "profile" command with "list" and "show" subcommands
+// for inspecting the profiles defined with Profile() and LoadProfiles().
+func (a *Application) ProfileCommand() *Cmd {
+	cmd := a.Command("profile", "Inspect flag profiles.")
+	cmd.Command("list", "List available profiles.").Action(func(*ParseContext) error {
+		for _, name := range a.profileNames() {
+			fmt.Fprintln(a.usageWriter, name)
+		}
+		return nil
+	})
+	show := cmd.Command("show", "Show the flag values applied by a profile.")
+	name := show.Arg("name", "Profile to show.").Required().HintAction(a.profileNames).String()
+	show.Action(func(*ParseContext) error {
+		profile, ok := a.profiles[*name]
+		if !ok {
+			return errorf(MsgUnknownProfile, *name, strings.Join(a.profileNames(), ","))
+		}
+		flags := make([]string, 0, len(profile))
+		for flag := range profile {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		for _, flag := range flags {
+			for _, value := range profile[flag] {
+				fmt.Fprintf(a.usageWriter, "--%s=%s\n", flag, value)
+			}
+		}
+		return nil
+	})
+	return cmd
+}
+
+// Convert a decoded JSON value into flag values.
+func jsonValues(value interface{}) ([]string, error) {
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case bool, float64, json.Number:
+		return []string{fmt.Sprintf("%v", v)}, nil
+	case []interface{}:
+		out := []string{}
+		for _, e := range v {
+			values, err := jsonValues(e)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, values...)
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("unsupported value %v", value)
+}
@@ -0,0 +1,94 @@
+package kingpin
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestProfileAppliesValues(t *testing.T) {
+	app := newTestApp()
+	region := app.Flag("region", "").Default("us-east-1").String()
+	tags := app.Flag("tag", "").Strings()
+	app.Profile("dev", map[string]string{"region": "eu-west-1", "tag": "a\nb"})
+
+	ctx, err := app.ParseContext([]string{"--profile=dev"})
+	assert.NoError(t, err)
+	assert.NoError(t, app.setDefaults(ctx))
+	assert.Equal(t, "eu-west-1", *region)
+	assert.Equal(t, []string{"a", "b"}, *tags)
+	assert.Equal(t, SourceProfile, ctx.sourceOf(app.GetFlag("region")))
+	assert.Equal(t, "dev", ctx.profile)
+}
+
+func TestProfilePrecedence(t *testing.T) {
+	app := newTestApp()
+	region := app.Flag("region", "").Envar("TEST_PROFILE_REGION").String()
+	zone := app.Flag("zone", "").Default("a").String()
+	app.Profile("dev", map[string]string{"region": "eu-west-1", "zone": "b"})
+
+	os.Setenv("TEST_PROFILE_REGION", "ap-south-1")
+	defer os.Unsetenv("TEST_PROFILE_REGION")
+	_, err := app.Parse([]string{"--profile", "dev", "--zone=c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "ap-south-1", *region)
+	assert.Equal(t, "c", *zone)
+
+	_, err = app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", *zone)
+}
+
+func TestProfileSatisfiesRequiredFlag(t *testing.T) {
+	app := newTestApp()
+	region := app.Flag("region", "").Required().String()
+	app.Profile("dev", map[string]string{"region": "eu-west-1"})
+	_, err := app.Parse([]string{"--profile=dev"})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", *region)
+	_, err = app.Parse([]string{})
+	assert.Error(t, err)
+}
+
+func TestUnknownProfile(t *testing.T) {
+	app := newTestApp()
+	app.Flag("region", "").String()
+	app.Profile("dev", map[string]string{"region": "eu-west-1"})
+	_, err := app.Parse([]string{"--profile=prod"})
+	assert.Error(t, err)
+}
+
+func TestLoadProfiles(t *testing.T) {
+	app := newTestApp()
+	count := app.Flag("count", "").Int()
+	debug := app.Flag("debug", "").Bool()
+	tags := app.Flag("tag", "").Strings()
+	err := app.LoadProfiles(strings.NewReader(`{"profiles": {"ci": {"count": 3, "debug": true, "tag": ["x", "y"]}}}`))
+	assert.NoError(t, err)
+	_, err = app.Parse([]string{"--profile=ci"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *count)
+	assert.True(t, *debug)
+	assert.Equal(t, []string{"x", "y"}, *tags)
+}
+
+func TestProfileCommand(t *testing.T) {
+	var buf bytes.Buffer
+	app := newTestApp().UsageWriter(&buf)
+	app.Flag("region", "").String()
+	app.Profile("dev", map[string]string{"region": "eu-west-1"})
+	app.Profile("prod", map[string]string{"region": "us-east-1"})
+	app.ProfileCommand()
+
+	_, err := app.Parse([]string{"profile", "list"})
+	assert.NoError(t, err)
+	assert.Equal(t, "dev\nprod\n", buf.String())
+
+	buf.Reset()
+	_, err = app.Parse([]string{"profile", "show", "prod"})
+	assert.NoError(t, err)
+	assert.Equal(t, "--region=us-east-1\n", buf.String())
+}
@@ -0,0 +1,123 @@
+package kingpin
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// PromptMissing enables prompting for required flags and arguments that were
+// not provided, when the application is running on a terminal. Each is
+// prompted for using its help text, and Enum() values are offered as a
+// numbered menu, eg.
+//
+//     Output format (--format):
+//       1) json
+//       2) yaml
+//     Choice:
+//
+// When not on a terminal missing values are reported as errors as usual, so
+// the application remains scriptable. Required Secret() flags are always
+// prompted for on a terminal, without echo.
+func (a *Application) PromptMissing(enabled bool) *Application {
+	a.promptMissing = enabled
+	return a
+}
+
+// Whether the user can be prompted for values. Replaced in tests.
+var isInteractive = func(a *Application) bool {
+	in, ok := a.stdin.(*os.File)
+	return ok && isTerminal(in) && isTerminal(a.errorWriter)
+}
+
+// Turn terminal echo of input on or off. Replaced in tests.
+var setEcho = func(in io.Reader, on bool) error {
+	terminal, ok := in.(*os.File)
+	if !ok {
+		return fmt.Errorf("input is not a terminal")
+	}
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = terminal
+	return cmd.Run()
+}
+
+// Prompt for the value of a required flag or argument that was not provided,
+// setting it with set. Invalid values are reported and prompted for again.
+// Returns false if the user was not prompted or did not provide a value.
+func (a *Application) promptRequired(name, help string, value Value, secret bool, set func(string) error) bool {
+	if !(secret || a.promptMissing) || !isInteractive(a) {
+		return false
+	}
+	label := name
+	if help != "" {
+		label = fmt.Sprintf("%s (%s)", help, name)
+	}
+	options := enumOptions(value)
+	for {
+		var (
+			input string
+			ok    bool
+		)
+		switch {
+		case secret:
+			input, ok = a.readSecret(label + ": ")
+		case len(options) > 0:
+			fmt.Fprintf(a.errorWriter, "%s:\n", label)
+			for i, option := range options {
+				fmt.Fprintf(a.errorWriter, "  %d) %s\n", i+1, option)
+			}
+			fmt.Fprint(a.errorWriter, "Choice: ")
+			input, ok = a.readLine()
+		default:
+			fmt.Fprintf(a.errorWriter, "%s: ", label)
+			input, ok = a.readLine()
+		}
+		if !ok {
+			return false
+		}
+		if input == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(options) {
+			input = options[n-1]
+		}
+		err := set(input)
+		if err == nil {
+			return true
+		}
+		a.writeError(err.Error(), "", "")
+	}
+}
+
+// Read a line of input without echoing it.
+func (a *Application) readSecret(prompt string) (string, bool) {
+	if err := setEcho(a.stdin, false); err != nil {
+		return "", false
+	}
+	defer setEcho(a.stdin, true)
+	fmt.Fprint(a.errorWriter, prompt)
+	line, ok := a.readLine()
+	// The user's newline was not echoed.
+	fmt.Fprintln(a.errorWriter)
+	return line, ok
+}
+
+// Read a line of input, without its line ending.
+func (a *Application) readLine() (string, bool) {
+	if a.stdinLines == nil {
+		a.stdinLines = bufio.NewReader(a.stdin)
+	}
+	line, err := a.stdinLines.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimRight(line, "\r\n"), true
+}
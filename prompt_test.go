@@ -0,0 +1,70 @@
+package kingpin
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+// Make the application interactive, reading input from the given lines.
+func interactiveApp(t *testing.T, input string) (*Application, *bytes.Buffer) {
+	interactive, echo := isInteractive, setEcho
+	t.Cleanup(func() { isInteractive, setEcho = interactive, echo })
+	isInteractive = func(*Application) bool { return true }
+	setEcho = func(io.Reader, bool) error { return nil }
+
+	app := newTestApp()
+	app.stdin = strings.NewReader(input)
+	w := &bytes.Buffer{}
+	app.ErrorWriter(w)
+	return app, w
+}
+
+func TestPromptMissing(t *testing.T) {
+	app, w := interactiveApp(t, "eu-west-1\nyaml\n")
+	app.PromptMissing(true)
+	region := app.Flag("region", "AWS region.").Required().String()
+	format := app.Arg("format", "Output format.").Required().Enum("json", "yaml")
+
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-west-1", *region)
+	assert.Equal(t, "yaml", *format)
+	assert.Equal(t, "AWS region. (--region): Output format. (format):\n  1) json\n  2) yaml\nChoice: ", w.String())
+}
+
+func TestPromptMissingRepromptsInvalidValues(t *testing.T) {
+	app, w := interactiveApp(t, "xml\n\n2\n")
+	app.PromptMissing(true)
+	format := app.Flag("format", "").Required().Enum("json", "yaml")
+
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "yaml", *format)
+	assert.Contains(t, w.String(), "error: enum value must be one of json,yaml, got 'xml'")
+}
+
+func TestPromptMissingDisabled(t *testing.T) {
+	app, _ := interactiveApp(t, "eu-west-1\n")
+	app.Flag("region", "").Required().String()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "required flag --region not provided")
+}
+
+func TestPromptSecret(t *testing.T) {
+	app, w := interactiveApp(t, "hunter2\n")
+	password := app.Flag("password", "").Secret().Required().String()
+
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "hunter2", *password)
+	assert.Equal(t, "--password: \n", w.String())
+
+	app, _ = interactiveApp(t, "")
+	app.Flag("password", "").Secret().Required().String()
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "required flag --password not provided")
+}
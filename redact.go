@@ -0,0 +1,102 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Displayed in place of the values of Secret() flags by MaskSecrets.
+const secretMask = "******"
+
+// A Redactor decides how the value of a Secret() flag is written when flag
+// values are serialised, eg. by ParseContext.WriteConfig() or --debug-flags.
+// It returns the replacement value, or false to omit the flag entirely.
+type Redactor func(flag, value string) (replacement string, keep bool)
+
+// MaskSecrets replaces secret values with "******". This is the default.
+func MaskSecrets(flag, value string) (string, bool) {
+	return secretMask, true
+}
+
+// OmitSecrets omits secret flags entirely.
+func OmitSecrets(flag, value string) (string, bool) {
+	return "", false
+}
+
+// SecretReference returns a Redactor that replaces secret values with a
+// reference token, formed by substituting the flag name into format. eg.
+//
+//     app.Redact(kingpin.SecretReference("vault://secrets/%s"))
+func SecretReference(format string) Redactor {
+	return func(flag, value string) (string, bool) {
+		return fmt.Sprintf(format, flag), true
+	}
+}
+
+// Redact sets how the values of Secret() flags are serialised. The default is
+// MaskSecrets.
+func (a *Application) Redact(redactor Redactor) *Application {
+	a.redactor = redactor
+	return a
+}
+
+// Apply the application's Redactor to value if flag is secret.
+func (a *Application) redact(flag *FlagClause, value string) (string, bool) {
+	if !flag.secret {
+		return value, true
+	}
+	if a.redactor == nil {
+		return MaskSecrets(flag.name, value)
+	}
+	return a.redactor(flag.name, value)
+}
+
+// The string form of each value held by value.
+func valueStrings(value Value) []string {
+	if a, ok := value.(*accumulator); ok {
+		return a.strings()
+	}
+	return []string{value.String()}
+}
+
+// Collect the values of every set, visible flag in scope, redacting secrets.
+// Repeatable flags map to a slice of values.
+func (p *ParseContext) configValues() map[string]interface{} {
+	out := map[string]interface{}{}
+	for _, flag := range p.flags.flagOrder {
+		if flag.hidden || flag == p.app.printConfig || p.sourceOf(flag) == SourceNone {
+			continue
+		}
+		values := []string{}
+		keep := true
+		for _, value := range valueStrings(flag.value) {
+			var v string
+			if v, keep = p.app.redact(flag, value); !keep {
+				break
+			}
+			values = append(values, v)
+		}
+		if !keep {
+			continue
+		}
+		if isCumulative(flag.value) {
+			out[flag.name] = values
+		} else if len(values) > 0 {
+			out[flag.name] = values[0]
+		}
+	}
+	return out
+}
+
+// WriteConfig writes the values of all flags that were set, whether from
+// the command line, environment or elsewhere, to w as a JSON document.
+// Values of Secret() flags are redacted as configured with Redact().
+func (p *ParseContext) WriteConfig(w io.Writer) error {
+	data, err := json.MarshalIndent(p.configValues(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
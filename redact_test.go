@@ -0,0 +1,63 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func redactApp() *Application {
+	app := newTestApp()
+	app.Flag("user", "").String()
+	app.Flag("password", "").Secret().String()
+	app.Flag("tag", "").Strings()
+	app.Flag("unset", "").String()
+	return app
+}
+
+func TestWriteConfigRedactsSecrets(t *testing.T) {
+	for _, test := range []struct {
+		redactor Redactor
+		expected string
+	}{
+		{nil, `{
+  "password": "******",
+  "tag": [
+    "a",
+    "b"
+  ],
+  "user": "alec"
+}
+`},
+		{OmitSecrets, `{
+  "tag": [
+    "a",
+    "b"
+  ],
+  "user": "alec"
+}
+`},
+		{SecretReference("vault://%s"), `{
+  "password": "vault://password",
+  "tag": [
+    "a",
+    "b"
+  ],
+  "user": "alec"
+}
+`},
+	} {
+		app := redactApp()
+		if test.redactor != nil {
+			app.Redact(test.redactor)
+		}
+		var config bytes.Buffer
+		app.Action(func(ctx *ParseContext) error {
+			return ctx.WriteConfig(&config)
+		})
+		_, err := app.Parse([]string{"--user=alec", "--password=hunter2", "--tag=a", "--tag=b"})
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, config.String())
+	}
+}
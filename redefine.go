@@ -0,0 +1,144 @@
+package kingpin
+
+// RedefinitionPolicy controls what happens when a flag is defined more than
+// once in the same application or command, eg. by two independent packages.
+type RedefinitionPolicy int
+
+const (
+	// RedefinitionError fails at init with a "duplicate long flag" error. This
+	// is the default.
+	RedefinitionError RedefinitionPolicy = iota
+	// RedefinitionReplace uses the last definition, discarding earlier ones.
+	RedefinitionReplace
+	// RedefinitionMerge combines all definitions into the first. Values are
+	// set on every definition's target, and actions, hints and transforms of
+	// all definitions apply.
+	RedefinitionMerge
+)
+
+// AllowFlagRedefinition sets how repeated definitions of a flag are resolved.
+// Individual definitions can also replace earlier ones with Override().
+func (a *Application) AllowFlagRedefinition(policy RedefinitionPolicy) *Application {
+	a.redefinition = policy
+	return a
+}
+
+// Override allows this definition to replace any earlier definition of a flag
+// with the same name, regardless of the application's RedefinitionPolicy.
+//
+// On a command, it also allows the flag to redefine one inherited from a
+// parent command or the application, eg. with a different default or help:
+//
+//     app.Flag("timeout", "Request timeout.").Default("30s").Duration()
+//     app.Command("upload", "").Flag("timeout", "Upload timeout.").Default("10m").Override().Duration()
+//
+// The command's definition shadows the parent's after the command on the
+// command line, and the parent's flag takes its default.
+func (f *FlagClause) Override() *FlagClause {
+	f.override = true
+	return f
+}
+
+// Remove a flag redefined by a selected command from those in scope.
+func (p *ParseContext) shadowFlag(flag *FlagClause) {
+	order := []*FlagClause{}
+	for _, other := range p.flags.flagOrder {
+		if other != flag {
+			order = append(order, other)
+		}
+	}
+	p.flags.flagOrder = order
+	if flag.shorthand != 0 && p.flags.short[string(flag.shorthand)] == flag {
+		delete(p.flags.short, string(flag.shorthand))
+	}
+	p.shadowedFlags = append(p.shadowedFlags, flag)
+}
+
+// Resolve repeated definitions of flags according to policy.
+func (f *flagGroup) redefine(policy RedefinitionPolicy) {
+	kept := map[string]int{}
+	order := []*FlagClause{}
+	for _, flag := range f.flagOrder {
+		i, ok := kept[flag.name]
+		switch {
+		case !ok:
+			kept[flag.name] = len(order)
+			order = append(order, flag)
+
+		case flag.override || policy == RedefinitionReplace:
+			order[i] = flag
+
+		case policy == RedefinitionMerge:
+			order[i].merge(flag)
+
+		default:
+			// Keep both, so init reports the duplicate.
+			order = append(order, flag)
+		}
+	}
+	f.flagOrder = order
+	for _, flag := range order {
+		f.long[flag.name] = flag
+	}
+}
+
+// Merge another definition of the same flag into f.
+func (f *FlagClause) merge(other *FlagClause) {
+	if merged, ok := f.value.(*mergedValue); ok {
+		merged.values = append(merged.values, other.value)
+	} else {
+		f.value = &mergedValue{values: []Value{f.value, other.value}}
+	}
+	if f.help == "" {
+		f.help = other.help
+	}
+	if f.shorthand == 0 {
+		f.shorthand = other.shorthand
+	}
+	if f.placeholder == "" {
+		f.placeholder = other.placeholder
+	}
+	if len(f.defaultValues) == 0 {
+		f.defaultValues = other.defaultValues
+	}
+	if f.envar == "" && !f.noEnvar {
+		f.envar, f.fallbacks, f.noEnvar = other.envar, other.fallbacks, other.noEnvar
+	}
+	f.required = f.required || other.required
+	f.hidden = f.hidden && other.hidden
+	f.secret = f.secret || other.secret
+	f.actions = append(f.actions, other.actions...)
+	f.preActions = append(f.preActions, other.preActions...)
+	f.postActions = append(f.postActions, other.postActions...)
+	f.hintActions = append(f.hintActions, other.hintActions...)
+	f.builtinHintActions = append(f.builtinHintActions, other.builtinHintActions...)
+	f.transforms = append(f.transforms, other.transforms...)
+}
+
+// mergedValue sets each of several values, and otherwise behaves like the
+// first.
+type mergedValue struct {
+	values []Value
+}
+
+func (m *mergedValue) Set(value string) error {
+	for _, v := range m.values {
+		if err := v.Set(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *mergedValue) String() string { return m.values[0].String() }
+
+func (m *mergedValue) Get() interface{} {
+	if getter, ok := m.values[0].(Getter); ok {
+		return getter.Get()
+	}
+	return m.values[0].String()
+}
+
+func (m *mergedValue) IsBoolFlag() bool { return isBoolValue(m.values[0]) }
+
+func (m *mergedValue) IsCumulative() bool { return isCumulative(m.values[0]) }
@@ -0,0 +1,111 @@
+package kingpin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+func TestFlagRedefinitionError(t *testing.T) {
+	app := newTestApp()
+	app.Flag("region", "").String()
+	app.Flag("region", "").String()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "duplicate long flag --region")
+}
+
+func TestFlagOverride(t *testing.T) {
+	app := newTestApp()
+	first := app.Flag("region", "First.").String()
+	second := app.Flag("region", "Second.").Override().Default("eu").String()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "", *first)
+	assert.Equal(t, "eu", *second)
+	assert.Equal(t, "Second.", app.GetFlag("region").help)
+	assert.Equal(t, 1, countFlags(app, "region"))
+}
+
+func TestFlagOverrideInherited(t *testing.T) {
+	app := newTestApp()
+	timeout := app.Flag("timeout", "Request timeout.").Short('t').Default("30s").Duration()
+	upload := app.Command("upload", "")
+	uploadTimeout := upload.Flag("timeout", "Upload timeout.").Default("10m").Override().Duration()
+	app.Command("list", "")
+
+	_, err := app.Parse([]string{"upload", "--timeout=1h"})
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, *timeout)
+	assert.Equal(t, time.Hour, *uploadTimeout)
+
+	_, err = app.Parse([]string{"--timeout=1m", "upload"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, *timeout)
+	assert.Equal(t, 10*time.Minute, *uploadTimeout)
+
+	// The parent's short flag isn't available for the redefined flag.
+	_, err = app.Parse([]string{"upload", "-t", "1h"})
+	assert.Error(t, err)
+
+	_, err = app.Parse([]string{"list", "-t", "1h"})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, *timeout)
+
+	context, err := app.ParseContext([]string{"upload"})
+	assert.NoError(t, err)
+	help := []string{}
+	for _, flag := range context.flagsModel().Flags {
+		if flag.Name == "timeout" {
+			help = append(help, flag.Help)
+		}
+	}
+	assert.Equal(t, []string{"Upload timeout."}, help)
+}
+
+func TestFlagInheritedRedefinitionError(t *testing.T) {
+	app := newTestApp()
+	app.Flag("timeout", "").Duration()
+	app.Command("upload", "").Flag("timeout", "").Duration()
+	_, err := app.Parse([]string{"upload"})
+	assert.EqualError(t, err, "duplicate long flag --timeout")
+}
+
+func TestFlagRedefinitionReplace(t *testing.T) {
+	app := newTestApp().AllowFlagRedefinition(RedefinitionReplace)
+	first := app.Flag("region", "").String()
+	second := app.Flag("region", "").String()
+	_, err := app.Parse([]string{"--region=us"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", *first)
+	assert.Equal(t, "us", *second)
+}
+
+func TestFlagRedefinitionMerge(t *testing.T) {
+	app := newTestApp().AllowFlagRedefinition(RedefinitionMerge)
+	first := app.Flag("region", "Region.").Short('r').String()
+	second := app.Flag("region", "").Default("eu").String()
+	verbose := app.Flag("verbose", "").Bool()
+	alsoVerbose := app.Flag("verbose", "").Bool()
+	_, err := app.Parse([]string{"-r", "us", "--verbose"})
+	assert.NoError(t, err)
+	assert.Equal(t, "us", *first)
+	assert.Equal(t, "us", *second)
+	assert.True(t, *verbose)
+	assert.True(t, *alsoVerbose)
+	assert.Equal(t, 1, countFlags(app, "region"))
+
+	_, err = app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu", *first)
+}
+
+func countFlags(app *Application, name string) int {
+	n := 0
+	for _, flag := range app.flagOrder {
+		if flag.name == name {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,106 @@
+package kingpin
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Registrar adds flags, arguments and commands to an application. It allows
+// a CLI to be composed from independent packages. See Application.Register().
+type Registrar interface {
+	Register(app *Application)
+}
+
+// A CmdRegistrar adds flags, arguments and subcommands to a command. See
+// Cmd.Register().
+type CmdRegistrar interface {
+	RegisterCmd(cmd *Cmd)
+}
+
+// RegistrationOrder may be implemented by Registrars and CmdRegistrars to
+// control the order in which they are registered. Lower values are
+// registered first; modules without an order have order zero, and ties keep
+// the order they were passed in.
+type RegistrationOrder interface {
+	RegistrationOrder() int
+}
+
+// Register calls Register(a) on each module, in order. If a module defines a
+// command or flag already defined by another module, the conflict is reported
+// when the application is initialised, naming both modules. Modules are named
+// by their String() method if they have one, or their type otherwise.
+func (a *Application) Register(modules ...Registrar) *Application {
+	for _, module := range sortModules(modules) {
+		module := module.(Registrar)
+		a.cmdMixin.register(module, a.redefinition, func() { module.Register(a) })
+	}
+	return a
+}
+
+// Register calls RegisterCmd(c) on each module, in order. Conflicts are
+// reported as for Application.Register().
+func (c *Cmd) Register(modules ...CmdRegistrar) *Cmd {
+	for _, module := range sortModules(modules) {
+		module := module.(CmdRegistrar)
+		c.cmdMixin.register(module, c.app.redefinition, func() { module.RegisterCmd(c) })
+	}
+	return c
+}
+
+// Sort modules (a slice of Registrar or CmdRegistrar) by RegistrationOrder.
+func sortModules(modules interface{}) []interface{} {
+	out := []interface{}{}
+	switch modules := modules.(type) {
+	case []Registrar:
+		for _, module := range modules {
+			out = append(out, module)
+		}
+	case []CmdRegistrar:
+		for _, module := range modules {
+			out = append(out, module)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		return registrationOrder(out[i]) < registrationOrder(out[j])
+	})
+	return out
+}
+
+func registrationOrder(module interface{}) int {
+	if order, ok := module.(RegistrationOrder); ok {
+		return order.RegistrationOrder()
+	}
+	return 0
+}
+
+func moduleName(module interface{}) string {
+	if stringer, ok := module.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("%T", module)
+}
+
+// Call register, recording module as the owner of any commands and flags it
+// defines and reporting those already owned by another module.
+func (c *cmdMixin) register(module interface{}, policy RedefinitionPolicy, register func()) {
+	if c.owners == nil {
+		c.owners = map[string]string{}
+	}
+	name := moduleName(module)
+	flags, commands := len(c.flagOrder), len(c.commandOrder)
+	register()
+
+	claim := func(key, description string, allowed bool) {
+		if owner, ok := c.owners[key]; ok && owner != name && !allowed {
+			c.registerErrs = append(c.registerErrs, fmt.Errorf("%s: %s already registered by %s", name, description, owner))
+			return
+		}
+		c.owners[key] = name
+	}
+	for _, flag := range c.flagOrder[flags:] {
+		claim("--"+flag.name, "flag --"+flag.name, flag.override || policy != RedefinitionError)
+	}
+	for _, cmd := range c.commandOrder[commands:] {
+		claim(cmd.name, "command '"+cmd.name+"'", false)
+	}
+}
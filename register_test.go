@@ -0,0 +1,77 @@
+package kingpin
+
+import (
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type serverModule struct {
+	port *int
+}
+
+func (s *serverModule) Register(app *Application) {
+	cmd := app.Command("serve", "Run the server.")
+	s.port = cmd.Flag("port", "").Default("8080").Int()
+}
+
+type loggingModule struct {
+	order int
+	calls *[]string
+}
+
+func (l *loggingModule) String() string { return "logging" }
+
+func (l *loggingModule) RegistrationOrder() int { return l.order }
+
+func (l *loggingModule) Register(app *Application) {
+	*l.calls = append(*l.calls, "logging")
+	app.Flag("log-level", "").Default("info").String()
+}
+
+type otherLoggingModule struct{}
+
+func (otherLoggingModule) Register(app *Application) {
+	app.Flag("log-level", "").String()
+	app.Command("serve", "")
+}
+
+type migrateModule struct{ calls *[]string }
+
+func (m *migrateModule) Register(app *Application) {
+	*m.calls = append(*m.calls, "migrate")
+	m.RegisterCmd(app.Command("db", "Database."))
+}
+
+func (m *migrateModule) RegisterCmd(cmd *Cmd) {
+	cmd.Command("migrate", "Run migrations.")
+}
+
+func TestRegister(t *testing.T) {
+	calls := []string{}
+	server := &serverModule{}
+	app := newTestApp().Register(server, &migrateModule{&calls}, &loggingModule{order: -1, calls: &calls})
+	assert.Equal(t, []string{"logging", "migrate"}, calls)
+	command, err := app.Parse([]string{"serve", "--port=80"})
+	assert.NoError(t, err)
+	assert.Equal(t, "serve", command)
+	assert.Equal(t, 80, *server.port)
+	command, err = app.Parse([]string{"db", "migrate"})
+	assert.NoError(t, err)
+	assert.Equal(t, "db migrate", command)
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	calls := []string{}
+	app := newTestApp().Register(&serverModule{}, &loggingModule{calls: &calls}, otherLoggingModule{})
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "kingpin.otherLoggingModule: flag --log-level already registered by logging; kingpin.otherLoggingModule: command 'serve' already registered by *kingpin.serverModule")
+}
+
+func TestRegisterAllowedRedefinition(t *testing.T) {
+	calls := []string{}
+	app := newTestApp().AllowFlagRedefinition(RedefinitionReplace)
+	app.Register(&loggingModule{calls: &calls}, otherLoggingModule{})
+	_, err := app.Parse([]string{"serve"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,201 @@
+package kingpin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Repl runs an interactive shell that reads command lines from stdin and
+// parses and dispatches each against the application's commands, eg.
+//
+//     app.Command("repl", "Start an interactive shell.").Action(func(*kingpin.ParseContext) error {
+//         return app.Repl("admin> ")
+//     })
+//
+// Lines are split into arguments as a shell would: single quotes preserve
+// text literally, double quotes allow backslash escapes, and a backslash
+// outside quotes escapes the next character. Errors are reported and the
+// shell continues, and --help shows help without exiting.
+//
+// The shell also understands:
+//
+//     history   list previous lines
+//     !!        repeat the previous line
+//     !N        repeat line N of the history
+//     ...?      list completions for the last word, eg. "user a?"
+//     exit      leave the shell (also quit, or end of input)
+//
+// unless the application defines commands with those names.
+//
+// Every flag and argument is reset to its default before each line, as with
+// repeated calls to Parse().
+func (a *Application) Repl(prompt string) error {
+	if err := a.init(); err != nil {
+		return err
+	}
+	history := []string{}
+	for {
+		fmt.Fprint(a.outputWriter, prompt)
+		line, ok := a.readLine()
+		if !ok {
+			fmt.Fprintln(a.outputWriter)
+			return nil
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			expanded, err := expandHistory(line, history)
+			if err != nil {
+				a.writeErr("", err)
+				continue
+			}
+			line = expanded
+			fmt.Fprintln(a.outputWriter, line)
+		}
+		args, err := splitCommandLine(strings.TrimSuffix(line, "?"))
+		if err != nil {
+			a.writeErr("", err)
+			continue
+		}
+		if len(args) == 1 && a.GetCommand(args[0]) == nil {
+			switch args[0] {
+			case "exit", "quit":
+				return nil
+			case "history":
+				for i, previous := range history {
+					fmt.Fprintf(a.outputWriter, "%4d  %s\n", i+1, previous)
+				}
+				continue
+			}
+		}
+		if strings.HasSuffix(line, "?") {
+			for _, completion := range a.Complete(strings.TrimSuffix(line, "?")) {
+				fmt.Fprintln(a.outputWriter, completion)
+			}
+			continue
+		}
+		history = append(history, line)
+		if _, _, _, err := a.parseNoExit(args); err != nil {
+			a.writeErr("", err)
+		}
+	}
+}
+
+// Complete returns the completions of the last word of a partial command
+// line, as used by Repl(). A line ending in a space completes a new word.
+func (a *Application) Complete(line string) []string {
+	words, err := splitCommandLine(line)
+	if err != nil {
+		return nil
+	}
+	current := ""
+	if len(words) > 0 && line != "" && !unicode.IsSpace(rune(line[len(line)-1])) {
+		current = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+	context, _ := a.ParseContext(append(append([]string{"--completion-bash"}, words...), current))
+	if context == nil {
+		return nil
+	}
+	out := []string{}
+	for _, option := range a.completionOptions(context) {
+		if strings.HasPrefix(option, current) {
+			out = append(out, option)
+		}
+	}
+	return out
+}
+
+// Parse args, returning rather than exiting if the application terminates,
+// eg. after showing help. If it does, terminated is true and status is the
+// exit status.
+func (a *Application) parseNoExit(args []string) (command string, status int, terminated bool, err error) {
+	terminate := a.terminate
+	a.terminate = func(code int) {
+		status, terminated = code, true
+		// Unwind out of the parse, as the application expects termination
+		// to end the process.
+		panic(terminatedError{})
+	}
+	defer func() {
+		a.terminate = terminate
+		if r := recover(); r != nil {
+			if _, ok := r.(terminatedError); !ok {
+				panic(r)
+			}
+		}
+	}()
+	command, err = a.Parse(args)
+	return command, status, terminated, err
+}
+
+type terminatedError struct{}
+
+// Replace a "!!" or "!N" history reference with the line it refers to.
+func expandHistory(line string, history []string) (string, error) {
+	n := len(history)
+	if line != "!!" {
+		var err error
+		if n, err = strconv.Atoi(line[1:]); err != nil {
+			return "", fmt.Errorf("invalid history reference %q", line)
+		}
+	}
+	if n < 1 || n > len(history) {
+		return "", fmt.Errorf("no history entry %q", line)
+	}
+	return history[n-1], nil
+}
+
+// Split a command line into arguments, honouring quotes and backslash
+// escapes as a shell would.
+func splitCommandLine(line string) ([]string, error) {
+	args := []string{}
+	current := []rune{}
+	inWord := false
+	quote := rune(0)
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			current = append(current, r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				current = append(current, r)
+			}
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				current = append(current, r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				args = append(args, string(current))
+				current, inWord = current[:0], false
+			}
+		default:
+			current = append(current, r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in %q", line)
+	}
+	if inWord {
+		args = append(args, string(current))
+	}
+	return args, nil
+}
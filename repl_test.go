@@ -0,0 +1,81 @@
+package kingpin
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	for _, test := range []struct {
+		line     string
+		expected []string
+	}{
+		{"", []string{}},
+		{"  get  user ", []string{"get", "user"}},
+		{`set name 'Jane Doe'`, []string{"set", "name", "Jane Doe"}},
+		{`say "a \"quoted\" word" it\'s`, []string{"say", `a "quoted" word`, "it's"}},
+		{`empty ""`, []string{"empty", ""}},
+	} {
+		args, err := splitCommandLine(test.line)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, args, test.line)
+	}
+	_, err := splitCommandLine(`say "unterminated`)
+	assert.Error(t, err)
+}
+
+func TestRepl(t *testing.T) {
+	app := newTestApp()
+	out := &bytes.Buffer{}
+	app.Writer(out).OutputWriter(out)
+	greeted := []string{}
+	greet := app.Command("greet", "Greet someone.")
+	name := greet.Arg("name", "").Required().String()
+	greet.Action(func(*ParseContext) error {
+		greeted = append(greeted, *name)
+		return nil
+	})
+	app.Command("group", "")
+	app.stdin = strings.NewReader("greet 'Jane Doe'\ngreet\n!1\ngr?\nhistory\nexit\ngreet never\n")
+
+	assert.NoError(t, app.Repl("> "))
+	assert.Equal(t, []string{"Jane Doe", "Jane Doe"}, greeted)
+	assert.Equal(t, `> > test: error: required argument 'name' not provided
+> greet 'Jane Doe'
+> greet
+group
+>    1  greet 'Jane Doe'
+   2  greet
+   3  greet 'Jane Doe'
+> `, out.String())
+}
+
+func TestReplHelpDoesNotExit(t *testing.T) {
+	app := newTestApp()
+	out := &bytes.Buffer{}
+	app.Writer(out).OutputWriter(out)
+	app.Command("run", "Run it.")
+	app.stdin = strings.NewReader("--help\nrun\n")
+	assert.NoError(t, app.Repl(""))
+	assert.Contains(t, out.String(), "Run it.")
+}
+
+func TestReplResetsValuesBetweenLines(t *testing.T) {
+	app := newTestApp()
+	app.Writer(&bytes.Buffer{})
+	del := app.Command("delete", "")
+	force := del.Flag("force", "").Bool()
+	tags := del.Flag("tag", "").Strings()
+	runs := []string{}
+	del.Action(func(*ParseContext) error {
+		runs = append(runs, fmt.Sprintf("force=%v tags=%v", *force, *tags))
+		return nil
+	})
+	app.stdin = strings.NewReader("delete --force --tag=a\ndelete --tag=b\n")
+	assert.NoError(t, app.Repl(""))
+	assert.Equal(t, []string{"force=true tags=[a]", "force=false tags=[b]"}, runs)
+}
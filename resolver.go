@@ -0,0 +1,80 @@
+package kingpin
+
+// A Resolver supplies the values of flags that were not given on the command
+// line, for example from a remote key-value store.
+type Resolver interface {
+	// Resolve returns the value of the named flag, and whether it has one.
+	// Several values of a repeatable flag are separated by new lines.
+	Resolve(flagName string, context *ParseContext) (string, bool)
+}
+
+// ResolverFunc is a function that implements Resolver.
+type ResolverFunc func(flagName string, context *ParseContext) (string, bool)
+
+// Resolve calls r.
+func (r ResolverFunc) Resolve(flagName string, context *ParseContext) (string, bool) {
+	return r(flagName, context)
+}
+
+// Resolvers adds resolvers that supply values for flags that were not given
+// on the command line, eg.
+//
+//     app.Resolvers(kingpin.ResolverFunc(func(name string, context *kingpin.ParseContext) (string, bool) {
+//         return consul.Get("app/" + name)
+//     }))
+//
+// A flag takes its value from the first of, in order:
+//
+//     1. the command line or --flags-json
+//     2. its Envar()
+//     3. the active Profiles()
+//     4. ConfigFiles()
+//     5. resolvers, in the order they were added
+//     6. its DefaultFrom() or Default()
+func (a *Application) Resolvers(resolvers ...Resolver) *Application {
+	a.resolvers = append(a.resolvers, resolvers...)
+	return a
+}
+
+// A step in the chain of sources consulted for flags missing from the
+// command line.
+type resolverStep struct {
+	source  ValueSource
+	resolve func(flag *FlagClause) ([]string, bool)
+}
+
+// The sources consulted, in order of precedence, for flags missing from the
+// command line.
+func (a *Application) resolverChain(context *ParseContext, profile, config map[string][]string) []resolverStep {
+	fromMap := func(values map[string][]string) func(flag *FlagClause) ([]string, bool) {
+		return func(flag *FlagClause) ([]string, bool) {
+			v, ok := values[flag.name]
+			return v, ok
+		}
+	}
+	chain := []resolverStep{
+		{SourceEnvar, func(flag *FlagClause) ([]string, bool) {
+			value := flag.GetEnvarValue()
+			return flag.splitValue(value), value != ""
+		}},
+		{SourceProfile, fromMap(profile)},
+		{SourceConfig, fromMap(config)},
+	}
+	for _, resolver := range a.resolvers {
+		resolver := resolver
+		chain = append(chain, resolverStep{SourceResolver, func(flag *FlagClause) ([]string, bool) {
+			value, ok := resolver.Resolve(flag.name, context)
+			return flag.splitValue(value), ok
+		}})
+	}
+	return chain
+}
+
+// Split value into several values, separated by new lines, if the flag is
+// repeatable.
+func (f *FlagClause) splitValue(value string) []string {
+	if v, ok := f.value.(repeatableFlag); ok && v.IsCumulative() {
+		return envVarValuesSplitter.Split(envVarValuesTrimmer.ReplaceAllString(value, ""), -1)
+	}
+	return []string{value}
+}
@@ -0,0 +1,74 @@
+package kingpin
+
+import (
+	"os"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestResolvers(t *testing.T) {
+	os.Setenv("TEST_RESOLVER_REGION", "eu")
+	defer os.Unsetenv("TEST_RESOLVER_REGION")
+	kv := map[string]string{"region": "us", "replicas": "3", "tag": "a\nb"}
+	calls := []string{}
+	app := newTestApp().Resolvers(
+		ResolverFunc(func(name string, context *ParseContext) (string, bool) {
+			calls = append(calls, name)
+			value, ok := kv[name]
+			return value, ok
+		}),
+		ResolverFunc(func(name string, context *ParseContext) (string, bool) {
+			return "fallback", name == "owner"
+		}),
+	)
+	region := app.Flag("region", "").Envar("TEST_RESOLVER_REGION").String()
+	replicas := app.Flag("replicas", "").Required().Int()
+	tags := app.Flag("tag", "").Strings()
+	owner := app.Flag("owner", "").Default("nobody").String()
+	name := app.Flag("name", "").Default("app").String()
+
+	_, err := app.Parse([]string{"--name=web"})
+	assert.NoError(t, err)
+	assert.Equal(t, "eu", *region)
+	assert.Equal(t, 3, *replicas)
+	assert.Equal(t, []string{"a", "b"}, *tags)
+	assert.Equal(t, "fallback", *owner)
+	assert.Equal(t, "web", *name)
+	assert.NotContains(t, calls, "region")
+	assert.NotContains(t, calls, "name")
+}
+
+func TestSourceOf(t *testing.T) {
+	os.Setenv("TEST_SOURCE_OF_TOKEN", "secret")
+	defer os.Unsetenv("TEST_SOURCE_OF_TOKEN")
+	app := newTestApp().Resolvers(ResolverFunc(func(name string, context *ParseContext) (string, bool) {
+		return "remote", name == "owner"
+	}))
+	app.Flag("name", "").String()
+	app.Flag("token", "").Envar("TEST_SOURCE_OF_TOKEN").String()
+	app.Flag("owner", "").String()
+	app.Flag("level", "").Default("info").String()
+	app.Flag("unset", "").String()
+	app.Arg("file", "").Default("-").String()
+
+	var sources map[string]ValueSource
+	app.Action(func(context *ParseContext) error {
+		sources = map[string]ValueSource{}
+		for _, name := range []string{"name", "token", "owner", "level", "unset", "file", "missing"} {
+			sources[name] = context.SourceOf(name)
+		}
+		return nil
+	})
+	_, err := app.Parse([]string{"--name=web"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ValueSource{
+		"name":    SourceArgs,
+		"token":   SourceEnvar,
+		"owner":   SourceResolver,
+		"level":   SourceDefault,
+		"unset":   SourceNone,
+		"file":    SourceDefault,
+		"missing": SourceNone,
+	}, sources)
+}
@@ -0,0 +1,28 @@
+package kingpin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Replace occurrences of the value of a Secret() flag in an error about it.
+func maskSecret(err error, value string) error {
+	if value == "" {
+		return err
+	}
+	e, ok := err.(*Error)
+	if !ok {
+		return errors.New(strings.Replace(err.Error(), value, secretMask, -1))
+	}
+	masked := *e
+	masked.Args = make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		if s := fmt.Sprint(arg); strings.Contains(s, value) {
+			arg = strings.Replace(s, value, secretMask, -1)
+		}
+		masked.Args[i] = arg
+	}
+	masked.Token = strings.Replace(e.Token, value, secretMask, -1)
+	return &masked
+}
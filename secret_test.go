@@ -0,0 +1,24 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestSecretMaskedInHelpAndErrors(t *testing.T) {
+	app := newTestApp()
+	app.Flag("token", "").Secret().Default("hunter2").String()
+	pin := app.Flag("pin", "").Secret().Enum("1234", "5678")
+
+	w := &bytes.Buffer{}
+	app.UsageWriter(w)
+	app.Usage(nil)
+	assert.Contains(t, w.String(), "--token=******")
+	assert.NotContains(t, w.String(), "hunter2")
+
+	_, err := app.Parse([]string{"--pin=0000"})
+	assert.EqualError(t, err, "enum value must be one of 1234,5678, got '******'")
+	assert.Equal(t, "", *pin)
+}
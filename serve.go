@@ -0,0 +1,203 @@
+package kingpin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// CommandRequest is the JSON body of a request to the Handler(). Flags map
+// long flag names to a string, number, boolean or list of values. Args are
+// passed verbatim, after "--".
+type CommandRequest struct {
+	Flags map[string]interface{} `json:"flags,omitempty"`
+	Args  []string               `json:"args,omitempty"`
+}
+
+// CommandResponse is the JSON body returned by the Handler().
+type CommandResponse struct {
+	Command string `json:"command,omitempty"`
+	Status  int    `json:"status"`
+	Output  string `json:"output,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Handler returns an http.Handler exposing the application's commands as
+// endpoints, so the same definitions can power both the CLI and a minimal
+// automation API. Each command is invoked with
+//
+//     POST /cmd/<command>/<subcommand> {"flags": {"force": true}, "args": ["a"]}
+//
+// The request is converted into command-line arguments and run through the
+// normal parsing, validation and action pipeline. Anything written to the
+// output writer (eg. with ParseContext.Print()) or error writer is returned
+// in the response.
+//
+// Only visible commands are served, and only the visible flags of the
+// selected command may be set; hidden flags and those controlling kingpin
+// itself, such as --help, --version, --completion-bash or --flags-json, are
+// rejected. Each request starts from the default values, see Parse().
+//
+// Requests are handled one at a time, as parsed values are shared.
+func (a *Application) Handler() http.Handler {
+	return &commandHandler{app: a}
+}
+
+// ServeCommand adds a "serve" command that serves Handler() on the given
+// address.
+func (a *Application) ServeCommand() *Cmd {
+	cmd := a.Command("serve", "Serve commands over HTTP.")
+	addr := cmd.Flag("addr", "Address to listen on.").Default("127.0.0.1:8080").String()
+	cmd.Action(func(*ParseContext) error {
+		return http.ListenAndServe(*addr, a.Handler())
+	})
+	a.serveCommand = cmd
+	return cmd
+}
+
+type commandHandler struct {
+	app *Application
+}
+
+func (h *commandHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, "/cmd/") {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	request := &CommandRequest{}
+	if r.ContentLength != 0 {
+		dec := json.NewDecoder(r.Body)
+		dec.UseNumber()
+		if err := dec.Decode(request); err != nil {
+			writeCommandResponse(w, http.StatusBadRequest, &CommandResponse{Status: 2, Error: "invalid request: " + err.Error()})
+			return
+		}
+	}
+	if err := h.app.init(); err != nil {
+		writeCommandResponse(w, http.StatusInternalServerError, &CommandResponse{Status: 1, Error: err.Error()})
+		return
+	}
+	path := strings.Fields(strings.Replace(strings.TrimPrefix(r.URL.Path, "/cmd/"), "/", " ", -1))
+	cmd := h.command(path)
+	if cmd == nil {
+		http.NotFound(w, r)
+		return
+	}
+	args, err := request.args(func(name string) *FlagClause { return h.flag(cmd, name) })
+	if err != nil {
+		writeCommandResponse(w, http.StatusBadRequest, &CommandResponse{Status: 2, Error: err.Error()})
+		return
+	}
+	response := h.run(append(path, args...))
+	code := http.StatusOK
+	if response.Status != 0 {
+		code = http.StatusBadRequest
+	}
+	writeCommandResponse(w, code, response)
+}
+
+// Resolve the request path to a command, refusing anything that is not
+// listed in help: hidden commands, the serve command itself and segments that
+// would be parsed as flags.
+func (h *commandHandler) command(path []string) *Cmd {
+	var cmd *Cmd
+	group := h.app.cmdGroup
+	for _, name := range path {
+		if strings.HasPrefix(name, "-") || strings.HasPrefix(name, "__") {
+			return nil
+		}
+		cmd = group.GetCommand(name)
+		if cmd == nil || cmd.hidden || cmd == h.app.serveCommand {
+			return nil
+		}
+		group = cmd.cmdGroup
+	}
+	return cmd
+}
+
+// The flag called name as seen by cmd, or nil if it may not be set remotely.
+func (h *commandHandler) flag(cmd *Cmd, name string) *FlagClause {
+	var flag *FlagClause
+	owner := cmd
+	for c := cmd; c != nil && flag == nil; c = c.parent {
+		flag, owner = c.flagGroup.long[name], c
+	}
+	if flag == nil {
+		flag, owner = h.app.flagGroup.long[name], nil
+	}
+	if flag == nil || flag.hidden || flag.builtin || (flag.local && owner != cmd) {
+		return nil
+	}
+	return flag
+}
+
+// Run the application with args, capturing its output and exit status.
+func (h *commandHandler) run(args []string) *CommandResponse {
+	a := h.app
+	a.serveLock.Lock()
+	defer a.serveLock.Unlock()
+	output := bytes.NewBuffer(nil)
+	response := &CommandResponse{}
+	outputWriter, errorWriter, usageWriter := a.outputWriter, a.errorWriter, a.usageWriter
+	a.outputWriter, a.errorWriter, a.usageWriter = output, output, output
+	defer func() {
+		a.outputWriter, a.errorWriter, a.usageWriter = outputWriter, errorWriter, usageWriter
+		response.Output = output.String()
+	}()
+
+	command, status, _, err := a.parseNoExit(args)
+	response.Command, response.Status = command, status
+	if err != nil {
+		response.Status = 1
+		response.Error = err.Error()
+	}
+	return response
+}
+
+// Convert the request into command-line arguments, resolving each flag name
+// with lookup.
+func (c *CommandRequest) args(lookup func(name string) *FlagClause) ([]string, error) {
+	names := make([]string, 0, len(c.Flags))
+	for name := range c.Flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := []string{}
+	for _, name := range names {
+		if lookup(name) == nil {
+			return nil, errorf(MsgUnknownLongFlag, "--"+name)
+		}
+		switch value := c.Flags[name].(type) {
+		case bool:
+			if value {
+				out = append(out, "--"+name)
+			} else {
+				out = append(out, "--no-"+name)
+			}
+		default:
+			values, err := jsonValues(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for flag '%s': %s", name, err)
+			}
+			for _, v := range values {
+				out = append(out, "--"+name+"="+v)
+			}
+		}
+	}
+	out = append(out, "--")
+	return append(out, c.Args...), nil
+}
+
+func writeCommandResponse(w http.ResponseWriter, code int, response *CommandResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,95 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func serveTestRequest(t *testing.T, app *Application, method, path, body string) (int, *CommandResponse) {
+	w := httptest.NewRecorder()
+	app.Handler().ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	response := &CommandResponse{}
+	if w.Code != http.StatusNotFound && w.Code != http.StatusMethodNotAllowed {
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), response))
+	}
+	return w.Code, response
+}
+
+func TestHandler(t *testing.T) {
+	app := newTestApp()
+	remote := app.Command("remote", "")
+	add := remote.Command("add", "")
+	force := add.Flag("force", "").Bool()
+	tags := add.Flag("tag", "").Strings()
+	name := add.Arg("name", "").Required().String()
+	add.Action(func(ctx *ParseContext) error {
+		return ctx.Print(map[string]interface{}{"name": *name, "tags": *tags, "force": *force})
+	})
+
+	code, response := serveTestRequest(t, app, "POST", "/cmd/remote/add", `{"flags": {"force": true, "tag": ["a", "b"]}, "args": ["--origin"]}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "remote add", response.Command)
+	assert.Equal(t, "--origin", *name)
+	assert.Equal(t, []string{"a", "b"}, *tags)
+	assert.True(t, *force)
+	assert.Contains(t, response.Output, "--origin")
+
+	code, response = serveTestRequest(t, app, "POST", "/cmd/remote/add", `{}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, "required argument 'name' not provided", response.Error)
+
+	code, _ = serveTestRequest(t, app, "GET", "/cmd/remote/add", ``)
+	assert.Equal(t, http.StatusMethodNotAllowed, code)
+}
+
+func TestHandlerRejectsHiddenAndBuiltins(t *testing.T) {
+	app := newTestApp()
+	app.Flag("debug", "").Hidden().Bool()
+	app.Command("secret", "").Hidden().Action(func(*ParseContext) error { return nil })
+	deploy := app.Command("deploy", "")
+	deploy.Flag("force", "").Bool()
+	ran := false
+	deploy.Action(func(*ParseContext) error {
+		ran = true
+		return nil
+	})
+
+	for _, path := range []string{"/cmd/secret", "/cmd/--help-man", "/cmd/-h", "/cmd/__complete", "/cmd/deploy/--force", "/cmd/missing"} {
+		code, _ := serveTestRequest(t, app, "POST", path, `{}`)
+		assert.Equal(t, http.StatusNotFound, code, path)
+	}
+	for _, flag := range []string{"help-man", "flags-json", "debug", "completion-bash"} {
+		code, response := serveTestRequest(t, app, "POST", "/cmd/deploy", `{"flags": {"`+flag+`": true}}`)
+		assert.Equal(t, http.StatusBadRequest, code, flag)
+		assert.Equal(t, "unknown long flag '--"+flag+"'", response.Error)
+	}
+	assert.False(t, ran)
+
+	code, _ := serveTestRequest(t, app, "POST", "/cmd/deploy", `{"flags": {"force": true}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, ran)
+}
+
+func TestHandlerIsolatesRequests(t *testing.T) {
+	app := newTestApp().VerbosityFlags()
+	app.OutputFlag()
+	deploy := app.Command("deploy", "")
+	token := deploy.Flag("token", "").String()
+	tags := deploy.Flag("tag", "").Strings()
+	deploy.Action(func(ctx *ParseContext) error {
+		return ctx.Print(map[string]interface{}{"token": *token, "tags": *tags})
+	})
+
+	code, response := serveTestRequest(t, app, "POST", "/cmd/deploy", `{"flags": {"token": "secret", "tag": ["a"], "verbose": true}}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, response.Output, "secret")
+
+	code, response = serveTestRequest(t, app, "POST", "/cmd/deploy", `{"flags": {"tag": ["b"], "output": "yaml"}}`)
+	assert.Equal(t, http.StatusOK, code, response.Error)
+	assert.Equal(t, "tags:\n  - b\ntoken: \"\"\n", response.Output)
+}
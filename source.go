@@ -0,0 +1,45 @@
+package kingpin
+
+// ValueSource describes where the value of a flag or argument came from.
+type ValueSource string
+
+// Value sources, in order of decreasing precedence.
+const (
+	SourceNone     ValueSource = ""
+	SourceArgs     ValueSource = "args"
+	SourceJSON     ValueSource = "json"
+	SourceEnvar    ValueSource = "envar"
+	SourceProfile  ValueSource = "profile"
+	SourceConfig   ValueSource = "config"
+	SourceResolver ValueSource = "resolver"
+	SourcePrompt   ValueSource = "prompt"
+	SourceDefault  ValueSource = "default"
+)
+
+func (p *ParseContext) setSource(clause interface{}, source ValueSource) {
+	if p.sources == nil {
+		p.sources = map[interface{}]ValueSource{}
+	}
+	p.sources[clause] = source
+}
+
+func (p *ParseContext) sourceOf(clause interface{}) ValueSource {
+	return p.sources[clause]
+}
+
+// SourceOf returns where the value of the flag or argument with the given
+// name came from, or SourceNone if it was not given a value, eg.
+//
+//     if context.SourceOf("database-url") == kingpin.SourceDefault {
+//         log.Println("warning: using the default database")
+//     }
+//
+// Values are resolved while parsing, so SourceOf is meaningful from actions
+// onwards. See also IsSet() and WriteEffectiveConfig().
+func (p *ParseContext) SourceOf(name string) ValueSource {
+	clause, _ := p.lookup(name)
+	if clause == nil {
+		return SourceNone
+	}
+	return p.sourceOf(clause)
+}
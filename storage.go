@@ -0,0 +1,134 @@
+package kingpin
+
+import (
+	"reflect"
+)
+
+// storageValue is implemented by Values that write to a variable, so that
+// the variable can be restored before each parse.
+type storageValue interface {
+	// storage returns a pointer to the variable written by Set().
+	storage() interface{}
+}
+
+// A variable written by a Value and its content before the first parse.
+type savedValue struct {
+	storage reflect.Value
+	initial reflect.Value
+}
+
+// The pointers to the variables written by value, if known. Values such as
+// durationValue are the variable themselves.
+func valueStorage(value Value) []reflect.Value {
+	switch value := value.(type) {
+	case *mergedValue:
+		out := []reflect.Value{}
+		for _, v := range value.values {
+			out = append(out, valueStorage(v)...)
+		}
+		return out
+	case storageValue:
+		return []reflect.Value{reflect.ValueOf(value.storage())}
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() != reflect.Struct {
+		return []reflect.Value{v}
+	}
+	return nil
+}
+
+// A pointer to a copy of the variable ptr points to, not sharing the backing
+// array of a slice or the entries of a map.
+func copyStorage(ptr reflect.Value) reflect.Value {
+	out := reflect.New(ptr.Type().Elem())
+	src := ptr.Elem()
+	switch {
+	case src.Kind() == reflect.Slice && !src.IsNil():
+		out.Elem().Set(reflect.AppendSlice(reflect.MakeSlice(src.Type(), 0, src.Len()), src))
+	case src.Kind() == reflect.Map && !src.IsNil():
+		m := reflect.MakeMapWithSize(src.Type(), src.Len())
+		for _, key := range src.MapKeys() {
+			m.SetMapIndex(key, src.MapIndex(key))
+		}
+		out.Elem().Set(m)
+	default:
+		out.Elem().Set(src)
+	}
+	return out
+}
+
+// Record the content of the variable of every flag and argument, so that
+// resetValues() can restore it.
+func (a *Application) saveValues() {
+	a.savedValues = nil
+	save := func(value Value) {
+		for _, ptr := range valueStorage(value) {
+			a.savedValues = append(a.savedValues, savedValue{ptr, copyStorage(ptr)})
+		}
+	}
+	a.eachCmdMixin(func(c *cmdMixin) {
+		for _, flag := range c.flagGroup.flagOrder {
+			save(flag.value)
+		}
+		for _, arg := range c.argGroup.args {
+			save(arg.value)
+		}
+	})
+}
+
+// Restore every flag and argument to its state before the first parse, so
+// that values from one parse do not leak into the next. Slices and maps are
+// always replaced, as values recorded by captureValues() may still refer to
+// the old ones. Values of unknown types are left as they are.
+func (a *Application) resetValues() {
+	for _, saved := range a.savedValues {
+		current := saved.storage.Elem()
+		switch current.Kind() {
+		case reflect.Slice, reflect.Map:
+			current.Set(copyStorage(saved.initial).Elem())
+		default:
+			if !reflect.DeepEqual(current.Interface(), saved.initial.Elem().Interface()) {
+				current.Set(saved.initial.Elem())
+			}
+		}
+	}
+}
+
+// Record the value of every flag and argument in scope, as returned by
+// Value(), so that it can be read from the context once another parse has
+// changed the variables they are bound to.
+func (p *ParseContext) captureValues() {
+	p.values = map[interface{}]interface{}{}
+	for _, flag := range p.flags.long {
+		p.values[flag] = capturedValue(flag.value)
+	}
+	for _, flag := range p.shadowedFlags {
+		p.values[flag] = capturedValue(flag.value)
+	}
+	for _, arg := range p.arguments.args {
+		p.values[arg] = capturedValue(arg.value)
+	}
+}
+
+func capturedValue(value Value) interface{} {
+	if acc, ok := value.(*accumulator); ok {
+		return copyStorage(acc.slice).Interface()
+	}
+	return getValue(value)
+}
+
+// The result of value's Get(), or its String() form.
+func getValue(value Value) interface{} {
+	if getter, ok := value.(Getter); ok {
+		return getter.Get()
+	}
+	return value.String()
+}
+
+// The value of clause, as captured when values were resolved.
+func (p *ParseContext) clauseValue(clause interface{}, value Value) interface{} {
+	if v, ok := p.values[clause]; ok {
+		return v
+	}
+	return getValue(value)
+}
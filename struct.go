@@ -0,0 +1,354 @@
+package kingpin
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// A parsed `kingpin:"..."` struct tag.
+type structTag struct {
+	name        string
+	short       rune
+	help        string
+	defaults    []string
+	envars      []string
+	placeholder string
+	enum        []string
+	typ         string
+	required    bool
+	hidden      bool
+	arg         bool
+	counter     bool
+}
+
+// Struct declares a flag (or argument) for each field of the struct pointed
+// to by v that has a `kingpin` tag, and binds the parsed values back into the
+// fields, eg.
+//
+//     var opts struct {
+//         Verbose bool     `kingpin:"short=v,help=Verbose output.,env=VERBOSE"`
+//         Level   string   `kingpin:"default=info,enum=debug|info|warn"`
+//         Files   []string `kingpin:"arg,required,help=Files to process."`
+//     }
+//     app.Struct(&opts)
+//
+// The tag is a comma separated list of keys:
+//
+//     name=NAME         the flag name (default: the field name in kebab-case)
+//     short=C           short flag
+//     help=TEXT         help text
+//     default=VALUE     default value; repeat the key for several values
+//     env=NAME|NAME     environment variable, followed by any fallbacks
+//     placeholder=TEXT  place-holder shown in help
+//     enum=A|B|C        permitted values of a string or []string field
+//     type=NAME         a type registered with RegisterValueType()
+//     required          the flag or argument is required
+//     hidden            hide the flag or argument from help
+//     counter           count occurrences of the flag (int fields only)
+//     arg               declare a positional argument rather than a flag
+//
+// A comma followed by a space does not start a new key, so help text may
+// contain commas. The tag `kingpin:"-"` skips a field, and embedded structs
+// are declared in place.
+//
+// Fields may be of any type held by a built-in value type (see ValueTypes()),
+// a slice of one of those types, or implement Value. Errors in tags or
+// unsupported field types are reported when the application is initialised.
+func (a *Application) Struct(v interface{}) *Application {
+	a.cmdMixin.declareStruct(v)
+	return a
+}
+
+// Struct declares flags and arguments for the command from the fields of the
+// struct pointed to by v. See Application.Struct().
+func (c *Cmd) Struct(v interface{}) *Cmd {
+	c.cmdMixin.declareStruct(v)
+	return c
+}
+
+func (c *cmdMixin) declareStruct(v interface{}) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Ptr || value.Elem().Kind() != reflect.Struct {
+		c.structErrs = append(c.structErrs, fmt.Errorf("Struct() expects a pointer to a struct, got %T", v))
+		return
+	}
+	if err := c.declareFields(value.Elem()); err != nil {
+		c.structErrs = append(c.structErrs, err)
+	}
+}
+
+func (c *cmdMixin) declareFields(value reflect.Value) error {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		raw, ok := field.Tag.Lookup("kingpin")
+		if raw == "-" {
+			continue
+		}
+		if !ok {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := c.declareFields(value.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf("field %s is tagged but not exported", field.Name)
+		}
+		tag, err := parseStructTag(raw)
+		if err != nil {
+			return fmt.Errorf("invalid tag on field %s: %s", field.Name, err)
+		}
+		if tag.name == "" {
+			tag.name = kebabCase(field.Name)
+		}
+		target, err := structFieldValue(value.Field(i).Addr(), tag)
+		if err != nil {
+			return fmt.Errorf("field %s: %s", field.Name, err)
+		}
+		if tag.arg {
+			arg := c.Arg(tag.name, tag.help).Default(tag.defaults...).PlaceHolder(tag.placeholder)
+			if len(tag.envars) > 0 {
+				arg.Envar(tag.envars[0], tag.envars[1:]...)
+			}
+			if tag.required {
+				arg.Required()
+			}
+			if tag.hidden {
+				arg.Hidden()
+			}
+			if tag.enum != nil {
+				arg.addHintActionBuiltin(enumHints(tag.enum))
+			}
+			arg.SetValue(target)
+			continue
+		}
+		flag := c.Flag(tag.name, tag.help).Default(tag.defaults...).PlaceHolder(tag.placeholder)
+		if tag.short != 0 {
+			flag.Short(tag.short)
+		}
+		if len(tag.envars) > 0 {
+			flag.Envar(tag.envars[0], tag.envars[1:]...)
+		}
+		if tag.required {
+			flag.Required()
+		}
+		if tag.hidden {
+			flag.Hidden()
+		}
+		if tag.enum != nil {
+			flag.addHintActionBuiltin(enumHints(tag.enum))
+		}
+		flag.SetValue(target)
+	}
+	return nil
+}
+
+func enumHints(options []string) HintAction {
+	return func() []string { return options }
+}
+
+// The Value that binds a flag or argument to the field pointed to by target.
+func structFieldValue(target reflect.Value, tag *structTag) (Value, error) {
+	if value, ok := target.Interface().(Value); ok {
+		return value, nil
+	}
+	typ := target.Type().Elem()
+	switch {
+	case tag.counter:
+		if typ != reflect.TypeOf(int(0)) {
+			return nil, fmt.Errorf("counter must be an int, not %s", typ)
+		}
+		return newCounterValue(target.Interface().(*int)), nil
+
+	case tag.enum != nil:
+		switch t := target.Interface().(type) {
+		case *string:
+			return newEnumFlag(t, tag.enum...), nil
+		case *[]string:
+			return newEnumsFlag(t, tag.enum...), nil
+		}
+		return nil, fmt.Errorf("enum must be a string or []string, not %s", typ)
+	}
+	factory, ok := builtinValueTypeFor(typ)
+	if !ok && typ.Kind() == reflect.Slice {
+		factory, ok = builtinValueTypeFor(typ.Elem())
+	}
+	if tag.typ != "" {
+		if factory, ok = valueTypeFactory(tag.typ); !ok {
+			return nil, fmt.Errorf("unknown value type %q, expected one of %v", tag.typ, ValueTypes())
+		}
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+	switch held := heldType(factory()); {
+	case held == typ:
+		return &fieldValue{factory, target}, nil
+	case typ.Kind() == reflect.Slice && held == typ.Elem():
+		return newAccumulator(target.Interface(), func(t interface{}) Value {
+			return &fieldValue{factory, reflect.ValueOf(t)}
+		}), nil
+	}
+	return nil, fmt.Errorf("type %q can't be bound to %s", tag.typ, typ)
+}
+
+// A Value of a registered type, bound to a struct field. Each Set() parses
+// into a new Value holding the content of the field, then copies the result
+// back.
+type fieldValue struct {
+	factory ValueFactory
+	field   reflect.Value // Pointer to the field.
+}
+
+// A new Value of the field's type, holding the content of the field if its
+// variable is known.
+func (f *fieldValue) value() (Value, bool) {
+	value := f.factory()
+	storage := valueStorage(value)
+	if len(storage) != 1 || !f.field.Type().Elem().ConvertibleTo(storage[0].Type().Elem()) {
+		return value, false
+	}
+	storage[0].Elem().Set(f.field.Elem().Convert(storage[0].Type().Elem()))
+	return value, true
+}
+
+func (f *fieldValue) Set(s string) error {
+	value, _ := f.value()
+	if err := value.Set(s); err != nil {
+		return err
+	}
+	if s, ok := value.(storageValue); ok {
+		f.field.Elem().Set(reflect.ValueOf(s.storage()).Elem())
+	} else if v := reflect.ValueOf(value.(Getter).Get()); v.IsValid() {
+		f.field.Elem().Set(v)
+	} else {
+		f.field.Elem().Set(reflect.Zero(f.field.Type().Elem()))
+	}
+	return nil
+}
+
+func (f *fieldValue) Get() interface{} { return f.field.Elem().Interface() }
+
+func (f *fieldValue) storage() interface{} { return f.field.Interface() }
+
+func (f *fieldValue) String() string {
+	if value, ok := f.value(); ok {
+		return value.String()
+	}
+	if f.field.Elem().IsZero() {
+		return ""
+	}
+	return fmt.Sprint(f.field.Elem().Interface())
+}
+
+func (f *fieldValue) IsBoolFlag() bool {
+	b, ok := f.factory().(boolFlag)
+	return ok && b.IsBoolFlag()
+}
+
+func (f *fieldValue) IsCumulative() bool {
+	r, ok := f.factory().(repeatableFlag)
+	return ok && r.IsCumulative()
+}
+
+func parseStructTag(raw string) (*structTag, error) {
+	tag := &structTag{}
+	// Split on commas, rejoining parts that start with a space as they
+	// belong to the previous value.
+	parts := []string{}
+	for _, part := range strings.Split(raw, ",") {
+		if len(parts) > 0 && strings.HasPrefix(part, " ") {
+			parts[len(parts)-1] += "," + part
+			continue
+		}
+		parts = append(parts, part)
+	}
+	for _, part := range parts {
+		if part == "" {
+			continue
+		}
+		key, value := part, ""
+		hasValue := false
+		if i := strings.Index(part, "="); i >= 0 {
+			key, value, hasValue = part[:i], part[i+1:], true
+		}
+		takesValue, ok := tagKeyHasValue[key]
+		switch {
+		case !ok:
+			return nil, fmt.Errorf("unknown key %q", key)
+		case hasValue && !takesValue:
+			return nil, fmt.Errorf("%q does not take a value", key)
+		case !hasValue && takesValue:
+			return nil, fmt.Errorf("%q requires a value", key)
+		}
+		switch key {
+		case "name":
+			tag.name = value
+		case "short":
+			runes := []rune(value)
+			if len(runes) != 1 {
+				return nil, fmt.Errorf("short flag must be a single character, got %q", value)
+			}
+			tag.short = runes[0]
+		case "help":
+			tag.help = value
+		case "default":
+			tag.defaults = append(tag.defaults, value)
+		case "env":
+			tag.envars = strings.Split(value, "|")
+		case "placeholder":
+			tag.placeholder = value
+		case "enum":
+			tag.enum = strings.Split(value, "|")
+		case "type":
+			tag.typ = value
+		case "required":
+			tag.required = true
+		case "hidden":
+			tag.hidden = true
+		case "arg":
+			tag.arg = true
+		case "counter":
+			tag.counter = true
+		}
+	}
+	return tag, nil
+}
+
+// Struct tag keys, and whether each takes a value.
+var tagKeyHasValue = map[string]bool{
+	"name":        true,
+	"short":       true,
+	"help":        true,
+	"default":     true,
+	"env":         true,
+	"placeholder": true,
+	"enum":        true,
+	"type":        true,
+	"required":    false,
+	"hidden":      false,
+	"arg":         false,
+	"counter":     false,
+}
+
+// Convert a Go identifier such as DryRun or HTTPPort to dry-run or http-port.
+func kebabCase(name string) string {
+	runes := []rune(name)
+	out := []rune{}
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// Start a new word at a lower to upper transition, or at the last
+			// upper case letter of an acronym followed by a lower case one.
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				out = append(out, '-')
+			}
+			r = unicode.ToLower(r)
+		}
+		out = append(out, r)
+	}
+	return string(out)
+}
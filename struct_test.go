@@ -0,0 +1,109 @@
+package kingpin
+
+import (
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tj/assert"
+)
+
+type structTestCommon struct {
+	Verbose int `kingpin:"short=v,counter,help=Verbosity, may be repeated."`
+}
+
+func TestStruct(t *testing.T) {
+	var opts struct {
+		structTestCommon
+		DryRun   bool          `kingpin:"help=Only show what would be done."`
+		Level    string        `kingpin:"default=info,enum=debug|info|warn"`
+		Timeout  time.Duration `kingpin:"name=wait,default=1m,env=TEST_STRUCT_WAIT"`
+		Tags     []string      `kingpin:"short=t"`
+		HTTPPort int           `kingpin:"required"`
+		Ignored  string
+		Skipped  string   `kingpin:"-"`
+		Files    []string `kingpin:"arg,required,help=Files to process."`
+	}
+	app := newTestApp().Struct(&opts)
+	os.Setenv("TEST_STRUCT_WAIT", "5s")
+	defer os.Unsetenv("TEST_STRUCT_WAIT")
+
+	_, err := app.Parse([]string{"-vv", "--dry-run", "--http-port=80", "-t", "a", "-t", "b", "x", "y"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, opts.Verbose)
+	assert.True(t, opts.DryRun)
+	assert.Equal(t, "info", opts.Level)
+	assert.Equal(t, 5*time.Second, opts.Timeout)
+	assert.Equal(t, []string{"a", "b"}, opts.Tags)
+	assert.Equal(t, 80, opts.HTTPPort)
+	assert.Equal(t, []string{"x", "y"}, opts.Files)
+
+	assert.Equal(t, "Verbosity, may be repeated.", app.GetFlag("verbose").help)
+	assert.Nil(t, app.GetFlag("ignored"))
+	assert.Nil(t, app.GetFlag("skipped"))
+
+	_, err = app.Parse([]string{"--http-port=80", "--level=loud", "x"})
+	assert.EqualError(t, err, "enum value must be one of debug,info,warn, got 'loud'")
+}
+
+func TestStructValueTypes(t *testing.T) {
+	RegisterValueType("ipnet", func() Value { return &ipNetValue{} })
+	var opts struct {
+		Key      []byte       `kingpin:""`
+		Addr     string       `kingpin:"type=hostport"`
+		Networks []*net.IPNet `kingpin:"type=ipnet"`
+		Wait     time.Duration
+	}
+	app := newTestApp().Struct(&opts)
+	_, err := app.Parse([]string{"--key=cafe", "--addr=localhost:80", "--networks=10.0.0.0/8", "--networks=::1/128"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xca, 0xfe}, opts.Key)
+	assert.Equal(t, "localhost:80", opts.Addr)
+	assert.Equal(t, 2, len(opts.Networks))
+	assert.Equal(t, "::1/128", opts.Networks[1].String())
+	assert.Equal(t, "localhost:80", app.GetFlag("addr").value.String())
+
+	_, err = app.Parse([]string{"--addr=localhost"})
+	assert.EqualError(t, err, "'localhost' is not a host:port address")
+}
+
+func TestStructErrors(t *testing.T) {
+	var unsupported struct {
+		Ch chan int `kingpin:""`
+	}
+	_, err := newTestApp().Struct(&unsupported).Parse(nil)
+	assert.EqualError(t, err, "field Ch: unsupported type chan int")
+
+	var badKey struct {
+		Name string `kingpin:"nmae=x"`
+	}
+	_, err = newTestApp().Struct(&badKey).Parse(nil)
+	assert.EqualError(t, err, `invalid tag on field Name: unknown key "nmae"`)
+
+	var unknownType struct {
+		Name string `kingpin:"type=nope"`
+	}
+	_, err = newTestApp().Struct(&unknownType).Parse(nil)
+	assert.Contains(t, err.Error(), `field Name: unknown value type "nope"`)
+
+	var mismatch struct {
+		Port int `kingpin:"type=duration"`
+	}
+	_, err = newTestApp().Struct(&mismatch).Parse(nil)
+	assert.EqualError(t, err, `field Port: type "duration" can't be bound to int`)
+
+	_, err = newTestApp().Struct(badKey).Parse(nil)
+	assert.Error(t, err)
+}
+
+func TestKebabCase(t *testing.T) {
+	for in, out := range map[string]string{
+		"Name":      "name",
+		"DryRun":    "dry-run",
+		"HTTPPort":  "http-port",
+		"MaxIOSize": "max-io-size",
+	} {
+		assert.Equal(t, out, kebabCase(in), in)
+	}
+}
@@ -0,0 +1,65 @@
+package kingpin
+
+import (
+	"fmt"
+)
+
+// The number of single character edits (insertions, deletions or
+// substitutions) needed to turn a into b.
+func levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			above := row[j]
+			row[j] = min3(row[j]+1, row[j-1]+1, diagonal+cost)
+			diagonal = above
+		}
+	}
+	return row[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// The candidate closest to name, if it is close enough to be a plausible
+// typo. Ties are broken by the order of candidates.
+func closest(name string, candidates []string) (string, bool) {
+	best, bestDistance := "", 0
+	for _, candidate := range candidates {
+		d := levenshtein(name, candidate)
+		if best == "" || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	// Allow roughly one edit per three characters, and at least one.
+	limit := len([]rune(name)) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	return best, best != "" && bestDistance <= limit
+}
+
+// Attach a "did you mean" hint for the closest candidate to err, if any.
+func (e *Error) suggest(name, format string, candidates []string) *Error {
+	if match, ok := closest(name, candidates); ok {
+		e.Hint = fmt.Sprintf("did you mean "+format+"?", match)
+	}
+	return e
+}
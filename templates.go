@@ -3,15 +3,11 @@ package kingpin
 // Default usage template.
 var DefaultUsageTemplate = `{{define "FormatCommand"}}\
 {{if .FlagSummary}} {{.FlagSummary}}{{end}}\
-{{range .Args}} {{if not .Required}}[{{end}}<{{.Name}}>{{if .Value|IsCumulative}}...{{end}}{{if not .Required}}]{{end}}{{end}}\
+{{range .Args}}{{if not .Hidden}} {{.FormatUsage}}{{end}}{{end}}\
 {{end}}\
 
 {{define "FormatCommands"}}\
-{{range .FlattenedCommands}}\
-{{if not .Hidden}}\
-    {{printf "%-20s %s" .FullCommand .Help}}
-{{end}}\
-{{end}}\
+{{.FlattenedCommands|CommandsToTwoColumns|FormatTwoColumns}}\
 {{end}}\
 
 {{define "FormatUsage"}}\
@@ -19,38 +15,45 @@ var DefaultUsageTemplate = `{{define "FormatCommand"}}\
 {{end}}
 {{if .Context.SelectedCommand}}\
 {{.Context.SelectedCommand.Help | Wrap 2}}
-  {{"Usage:" | bold}}
+  {{"Usage:" | heading}}
 
     {{.App.Name}} {{.Context.SelectedCommand}}{{template "FormatUsage" .Context.SelectedCommand}}
 {{else}}\
-  {{"Usage:" | bold}}
+  {{"Usage:" | heading}}
 
     {{.App.Name}}{{template "FormatUsage" .App}}
 {{end}}\
-{{if .Context.Flags}}\
-  {{"Flags:" | bold}}
+{{range .Context.Flags|FlagGroups}}\
+  {{.Title | heading}}
 
-{{.Context.Flags|FlagsToTwoColumns|FormatTwoColumns}}
+{{.Flags|FlagsToTwoColumns|FormatTwoColumns}}
 {{end}}\
-{{if .Context.Args}}\
-  {{"Args:" | bold}}
+{{with .Context.Args|ArgsToTwoColumns}}\
+  {{"Args:" | heading}}
 
-{{.Context.Args|ArgsToTwoColumns|FormatTwoColumns}}
+{{.|FormatTwoColumns}}
 {{end}}\
 {{if .Context.SelectedCommand}}\
-{{if len .Context.SelectedCommand.Commands}}\
-  {{"Subcommands:" | bold}}
+{{range .Context.SelectedCommand.Categories "Subcommands:"}}\
+  {{.Title | heading}}
 
-{{template "FormatCommands" .Context.SelectedCommand}}
+{{template "FormatCommands" .}}
 {{end}}\
-{{else if .App.Commands}}\
-  {{"Commands:" | bold}}
+{{else}}\
+{{range .App.Categories "Commands:"}}\
+  {{.Title | heading}}
 
-{{template "FormatCommands" .App}}
+{{template "FormatCommands" .}}
+{{end}}\
+{{with .App.HelpTopics}}\
+  {{"Additional help topics:" | heading}}
+
+{{.|HelpTopicsToTwoColumns|FormatTwoColumns}}
+{{end}}\
 {{end}}\
 {{define "Examples"}}\
 {{if .}}\
-  {{"Examples:" | bold}}
+  {{"Examples:" | heading}}
   {{range .}}
     {{.Help}}
     $ {{.Usage}}
@@ -67,7 +70,7 @@ var DefaultUsageTemplate = `{{define "FormatCommand"}}\
 // Usage template where command's optional flags are listed separately
 var SeparateOptionalFlagsUsageTemplate = `{{define "FormatCommand"}}\
 {{if .FlagSummary}} {{.FlagSummary}}{{end}}\
-{{range .Args}} {{if not .Required}}[{{end}}<{{.Name}}>{{if .Value|IsCumulative}}...{{end}}{{if not .Required}}]{{end}}{{end}}\
+{{range .Args}}{{if not .Hidden}} {{.FormatUsage}}{{end}}{{end}}\
 {{end}}\
 
 {{define "FormatCommands"}}\
@@ -100,25 +103,31 @@ Required flags:
 Optional flags:
 {{.Context.Flags|OptionalFlags|FlagsToTwoColumns|FormatTwoColumns}}
 {{end}}\
-{{if .Context.Args}}\
+{{with .Context.Args|ArgsToTwoColumns}}\
 Args:
-{{.Context.Args|ArgsToTwoColumns|FormatTwoColumns}}
+{{.|FormatTwoColumns}}
 {{end}}\
 {{if .Context.SelectedCommand}}\
-Subcommands:
-{{if .Context.SelectedCommand.Commands}}\
-{{template "FormatCommands" .Context.SelectedCommand}}
+{{range .Context.SelectedCommand.Categories "Subcommands:"}}\
+{{.Title}}
+{{template "FormatCommands" .}}
+{{end}}\
+{{else}}\
+{{range .App.Categories "Commands:"}}\
+{{.Title}}
+{{template "FormatCommands" .}}
+{{end}}\
+{{with .App.HelpTopics}}\
+Additional help topics:
+{{.|HelpTopicsToTwoColumns|FormatTwoColumns}}
 {{end}}\
-{{else if .App.Commands}}\
-Commands:
-{{template "FormatCommands" .App}}
 {{end}}\
 `
 
 // Usage template with compactly formatted commands.
 var CompactUsageTemplate = `{{define "FormatCommand"}}\
 {{if .FlagSummary}} {{.FlagSummary}}{{end}}\
-{{range .Args}} {{if not .Required}}[{{end}}<{{.Name}}>{{if .Value|IsCumulative}}...{{end}}{{if not .Required}}]{{end}}{{end}}\
+{{range .Args}}{{if not .Hidden}} {{.FormatUsage}}{{end}}{{end}}\
 {{end}}\
 
 {{define "FormatCommandList"}}\
@@ -143,13 +152,13 @@ usage: {{.App.Name}} {{.Context.SelectedCommand}}{{template "FormatUsage" .Conte
 {{else}}\
 usage: {{.App.Name}}{{template "FormatUsage" .App}}
 {{end}}\
-{{if .Context.Flags}}\
-Flags:
-{{.Context.Flags|FlagsToTwoColumns|FormatTwoColumns}}
+{{range .Context.Flags|FlagGroups}}\
+{{.Title}}
+{{.Flags|FlagsToTwoColumns|FormatTwoColumns}}
 {{end}}\
-{{if .Context.Args}}\
+{{with .Context.Args|ArgsToTwoColumns}}\
 Args:
-{{.Context.Args|ArgsToTwoColumns|FormatTwoColumns}}
+{{.|FormatTwoColumns}}
 {{end}}\
 {{if .Context.SelectedCommand}}\
 {{if .Context.SelectedCommand.Commands}}\
@@ -157,12 +166,19 @@ Commands:
   {{.Context.SelectedCommand}}
 {{template "FormatCommandList" .Context.SelectedCommand.Commands}}
 {{end}}\
-{{else if .App.Commands}}\
-Commands:
-{{template "FormatCommandList" .App.Commands}}
+{{else}}\
+{{range .App.Categories "Commands:"}}\
+{{.Title}}
+{{template "FormatCommandList" .Commands}}
+{{end}}\
+{{with .App.HelpTopics}}\
+Additional help topics:
+{{.|HelpTopicsToTwoColumns|FormatTwoColumns}}
+{{end}}\
 {{end}}\
 `
 
+// Man page template, used by --help-man only if replaced. See WriteManPage().
 var ManPageTemplate = `{{define "FormatFlags"}}\
 {{range .Flags}}\
 {{if not .Hidden}}\
@@ -175,7 +191,7 @@ var ManPageTemplate = `{{define "FormatFlags"}}\
 
 {{define "FormatCommand"}}\
 {{if .FlagSummary}} {{.FlagSummary}}{{end}}\
-{{range .Args}} {{if not .Required}}[{{end}}<{{.Name}}{{if .Default}}*{{end}}>{{if .Value|IsCumulative}}...{{end}}{{if not .Required}}]{{end}}{{end}}\
+{{range .Args}}{{if not .Hidden}} {{if not .Required}}[{{end}}{{if .PlaceHolder}}{{.PlaceHolder}}{{else}}<{{.Name}}{{if .Default}}*{{end}}>{{end}}{{if .Value|IsCumulative}}...{{end}}{{if not .Required}}]{{end}}{{end}}{{end}}\
 {{end}}\
 
 {{define "FormatCommands"}}\
@@ -194,7 +210,7 @@ var ManPageTemplate = `{{define "FormatFlags"}}\
 {{template "FormatCommand" .}}{{if .Commands}} <command> [<args> ...]{{end}}\\fR
 {{end}}\
 
-.TH {{.App.Name}} 1 {{.App.Version}} "{{.App.Author}}"
+.TH "{{.App.Name}}" "{{.App.Section}}" "{{.App.Date}}" "{{.App.Name}} {{.App.Version}}"
 .SH "NAME"
 {{.App.Name}}
 .SH "SYNOPSIS"
@@ -208,12 +224,31 @@ var ManPageTemplate = `{{define "FormatFlags"}}\
 .SH "COMMANDS"
 {{template "FormatCommands" .App}}\
 {{end}}\
+{{if .App.Authors}}\
+.SH "AUTHORS"
+{{range .App.Authors}}\
+{{.}}
+.br
+{{end}}\
+{{end}}\
+{{if .App.SourceURL}}\
+.SH "SOURCE"
+{{.App.SourceURL}}
+{{end}}\
+{{if .App.Copyright}}\
+.SH "COPYRIGHT"
+{{.App.Copyright}}
+{{end}}\
+{{if .App.SeeAlso}}\
+.SH "SEE ALSO"
+{{range $i, $page := .App.SeeAlso}}{{if $i}}, {{end}}{{$page}}{{end}}
+{{end}}\
 `
 
 // Default usage template.
 var LongHelpTemplate = `{{define "FormatCommand"}}\
 {{if .FlagSummary}} {{.FlagSummary}}{{end}}\
-{{range .Args}} {{if not .Required}}[{{end}}<{{.Name}}>{{if .Value|IsCumulative}}...{{end}}{{if not .Required}}]{{end}}{{end}}\
+{{range .Args}}{{if not .Hidden}} {{.FormatUsage}}{{end}}{{end}}\
 {{end}}\
 
 {{define "FormatCommands"}}\
@@ -235,27 +270,78 @@ var LongHelpTemplate = `{{define "FormatCommand"}}\
 {{end}}\
 
 usage: {{.App.Name}}{{template "FormatUsage" .App}}
-{{if .Context.Flags}}\
-Flags:
-{{.Context.Flags|FlagsToTwoColumns|FormatTwoColumns}}
+{{range .Context.Flags|FlagGroups}}\
+{{.Title}}
+{{.Flags|FlagsToTwoColumns|FormatTwoColumns}}
 {{end}}\
-{{if .Context.Args}}\
+{{with .Context.Args|ArgsToTwoColumns}}\
 Args:
-{{.Context.Args|ArgsToTwoColumns|FormatTwoColumns}}
+{{.|FormatTwoColumns}}
 {{end}}\
 {{if .App.Commands}}\
 Commands:
 {{template "FormatCommands" .App}}
 {{end}}\
+{{with .App.HelpTopics}}\
+Additional help topics:
+{{.|HelpTopicsToTwoColumns|FormatTwoColumns}}
+{{end}}\
+`
+
+// Default template for the --version flag and the "version" command, executed
+// with a VersionInfo.
+var DefaultVersionTemplate = `{{.Version}}
+{{- if .Commit}}
+commit: {{.Commit}}
+{{- end}}
+{{- if .Date}}
+built: {{.Date}}
+{{- end}}
+{{- if .GoVersion}}
+go: {{.GoVersion}}
+{{- end}}
 `
 
 var BashCompletionTemplate = `
 _{{.App.Name}}_bash_autocomplete() {
-    local cur prev opts base
+    local line cur word strip out directive value equals
+    local -a words
     COMPREPLY=()
-    cur="${COMP_WORDS[COMP_CWORD]}"
-    opts=$( ${COMP_WORDS[0]} --completion-bash ${COMP_WORDS[@]:1:$COMP_CWORD} )
-    COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
+    # Split the line ourselves, as bash also splits words at "=" and ":".
+    line="${COMP_LINE:0:COMP_POINT}"
+    read -ra words <<< "${line}"
+    [[ "${line}" =~ [[:space:]]$ ]] && words+=("")
+    cur="${words[${#words[@]}-1]}"
+    word="${COMP_WORDS[COMP_CWORD]}"
+    # The part of cur that bash does not consider part of the word.
+    strip="${cur%"${word}"}"
+    if [[ "${word}" == "=" ]]; then
+        equals="="
+        word=""
+    fi
+    out=$( "${words[0]}" __complete $(( ${#words[@]} - 2 )) "${words[@]:1}" 2>/dev/null )
+    directive="${out##*:}"
+    out="${out%:*}"
+    if (( directive & 2 )); then
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=( $(compgen -d -- "${word}") )
+        while IFS= read -r value; do
+            [[ -n "${value}" ]] && COMPREPLY+=( $(compgen -f -X "!${value}" -- "${word}") )
+        done <<< "${out}"
+    elif (( directive & 4 )); then
+        compopt -o filenames 2>/dev/null
+        COMPREPLY=( $(compgen -d -- "${word}") )
+    fi
+    if (( directive & 6 )); then
+        # Keep the "=" of "--flag=" that bash treats as the word.
+        [[ -n "${equals}" ]] && COMPREPLY=( "${COMPREPLY[@]/#/=}" )
+    else
+        while IFS= read -r value; do
+            value="${value%%$'\t'*}"
+            [[ -n "${value}" ]] && COMPREPLY+=( "${value#"${strip}"}" )
+        done <<< "${out}"
+    fi
+    (( directive & 1 )) && compopt -o nospace 2>/dev/null
     return 0
 }
 complete -F _{{.App.Name}}_bash_autocomplete {{.App.Name}}
@@ -264,16 +350,69 @@ complete -F _{{.App.Name}}_bash_autocomplete {{.App.Name}}
 
 var ZshCompletionTemplate = `
 #compdef {{.App.Name}}
-autoload -U compinit && compinit
-autoload -U bashcompinit && bashcompinit
 
-_{{.App.Name}}_bash_autocomplete() {
-    local cur prev opts base
-    COMPREPLY=()
-    cur="${COMP_WORDS[COMP_CWORD]}"
-    opts=$( ${COMP_WORDS[0]} --completion-bash ${COMP_WORDS[@]:1:$COMP_CWORD} )
-    COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
-    return 0
+_{{.App.Name}}() {
+    local -a lines candidates
+    local out directive line value
+    out="$(${words[1]} __complete $((CURRENT - 2)) "${(@)words[2,CURRENT]}" 2>/dev/null)"
+    lines=("${(@f)out}")
+    directive="${lines[-1]#:}"
+    lines=("${(@)lines[1,-2]}")
+    if (( directive & 2 )); then
+        for line in "${lines[@]}"; do
+            _files -g "${line}"
+        done
+        return
+    elif (( directive & 4 )); then
+        _files -/
+        return
+    fi
+    for line in "${lines[@]}"; do
+        [[ -z "$line" ]] && continue
+        value="${line%%$'\t'*}"
+        if [[ "$line" == *$'\t'* ]]; then
+            candidates+=("${value//:/\\:}:${line#*$'\t'}")
+        else
+            candidates+=("${value//:/\\:}")
+        fi
+    done
+    if (( directive & 1 )); then
+        _describe '{{.App.Name}}' candidates -S ''
+    else
+        _describe '{{.App.Name}}' candidates
+    fi
+}
+
+if [[ "$(basename -- ${(%):-%x})" != "_{{.App.Name}}" ]]; then
+    compdef _{{.App.Name}} {{.App.Name}}
+fi
+`
+
+// PowerShell completion script. Completions are produced at runtime by the
+// hidden "__complete-json" entry point.
+var PowerShellCompletionTemplate = `
+Register-ArgumentCompleter -Native -CommandName '{{.App.Name}}' -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.EndOffset -le $cursorPosition } |
+        ForEach-Object { $_.ToString() })
+    $program = $words[0]
+    $words = @($words | Select-Object -Skip 1)
+    if ($wordToComplete -ne '') {
+        $words = @($words | Select-Object -SkipLast 1)
+    }
+    # Older versions of PowerShell drop empty arguments to native commands.
+    $arguments = @('__complete-json', $words.Count) + $words
+    if ($wordToComplete -ne '') {
+        $arguments += $wordToComplete
+    }
+    # Returning nothing for file and directory hints falls back to path
+    # completion.
+    & $program @arguments | ConvertFrom-Json |
+        Where-Object { $_.type -ne 'files' -and $_.type -ne 'directories' } | ForEach-Object {
+        $type = if ($_.type -eq 'flag') { 'ParameterName' } else { 'ParameterValue' }
+        $description = if ($_.description) { $_.description } else { $_.value }
+        [System.Management.Automation.CompletionResult]::new($_.value, $_.value, $type, $description)
+    }
 }
-complete -F _{{.App.Name}}_bash_autocomplete {{.App.Name}}
 `
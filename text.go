@@ -0,0 +1,196 @@
+package kingpin
+
+import (
+	"io"
+	"strings"
+	"unicode"
+)
+
+// Ranges of East Asian wide and fullwidth characters, which occupy two
+// terminal columns.
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x2FFFD},
+	{0x30000, 0x3FFFD},
+}
+
+func runeWidth(r rune) int {
+	if r < 0x20 || unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+	for _, wide := range wideRanges {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
+
+// displayWidth returns the number of terminal columns s occupies. ANSI escape
+// sequences and combining characters take no space, and wide characters take
+// two columns.
+func displayWidth(s string) int {
+	width := 0
+	escape := false
+	for i, r := range s {
+		switch {
+		case escape:
+			// CSI sequences end with a byte in the range 0x40-0x7E.
+			if r >= 0x40 && r <= 0x7E && !(r == '[' && i > 0 && s[i-1] == '\x1b') {
+				escape = false
+			}
+		case r == '\x1b':
+			escape = true
+		default:
+			width += runeWidth(r)
+		}
+	}
+	return width
+}
+
+// padRight pads s with spaces to width display columns.
+func padRight(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// wrapText writes text to w, wrapping paragraphs to width display columns
+// (excluding indent).
+//
+// Like go/doc.ToText, paragraphs are separated by blank lines and prefixed
+// with indent, and indented lines following a paragraph are preformatted and
+// prefixed with preIndent.
+func wrapText(w io.Writer, text, indent, preIndent string, width int) {
+	lines := strings.Split(text, "\n")
+	// If every line is indented there is no paragraph to attach a code
+	// block to, so treat the whole text as a single paragraph.
+	haveParagraph := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" && !isIndented(line) {
+			haveParagraph = true
+			break
+		}
+	}
+
+	first := true
+	separate := func() {
+		if !first {
+			io.WriteString(w, indent+"\n")
+		}
+		first = false
+	}
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case strings.TrimSpace(line) == "":
+			i++
+
+		case isIndented(line) && haveParagraph:
+			// Collect the block, including blank lines followed by more
+			// indented lines.
+			block := []string{}
+			for i < len(lines) {
+				if strings.TrimSpace(lines[i]) == "" {
+					j := i
+					for j < len(lines) && strings.TrimSpace(lines[j]) == "" {
+						j++
+					}
+					if j == len(lines) || !isIndented(lines[j]) {
+						break
+					}
+					for ; i < j; i++ {
+						block = append(block, "")
+					}
+					continue
+				}
+				if !isIndented(lines[i]) {
+					break
+				}
+				block = append(block, lines[i])
+				i++
+			}
+			separate()
+			prefix := commonIndent(block)
+			for _, code := range block {
+				if code == "" {
+					io.WriteString(w, "\n")
+					continue
+				}
+				io.WriteString(w, preIndent+strings.TrimPrefix(code, prefix)+"\n")
+			}
+
+		default:
+			words := []string{}
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && (!isIndented(lines[i]) || !haveParagraph) {
+				words = append(words, strings.Fields(lines[i])...)
+				i++
+			}
+			separate()
+			for _, out := range wrapWords(words, width) {
+				io.WriteString(w, indent+out+"\n")
+			}
+		}
+	}
+}
+
+// Greedily fill lines of at most width display columns with words.
+func wrapWords(words []string, width int) []string {
+	lines := []string{}
+	line := ""
+	lineWidth := 0
+	for _, word := range words {
+		wordWidth := displayWidth(word)
+		if line != "" && lineWidth+1+wordWidth > width {
+			lines = append(lines, line)
+			line, lineWidth = "", 0
+		}
+		if line != "" {
+			line += " "
+			lineWidth++
+		}
+		line += word
+		lineWidth += wordWidth
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func isIndented(line string) bool {
+	return line != "" && (line[0] == ' ' || line[0] == '\t')
+}
+
+// The longest run of leading whitespace common to all non-empty lines. The
+// first line must not be empty.
+func commonIndent(lines []string) string {
+	prefix := ""
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if i == 0 {
+			prefix = indent
+			continue
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix
+}
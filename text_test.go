@@ -0,0 +1,37 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	assert.Equal(t, 5, displayWidth("hello"))
+	assert.Equal(t, 6, displayWidth("日本語"))
+	assert.Equal(t, 4, displayWidth("café"))
+	assert.Equal(t, 3, displayWidth("\x1b[1;31mred\x1b[0m"))
+}
+
+func TestWrapTextMeasuresDisplayWidth(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wrapText(buf, "日本語 日本語 日本語", "", "  ", 14)
+	assert.Equal(t, "日本語 日本語\n日本語\n", buf.String())
+
+	buf.Reset()
+	wrapText(buf, "\x1b[1mbold\x1b[0m text wraps here", "", "  ", 15)
+	assert.Equal(t, "\x1b[1mbold\x1b[0m text wraps\nhere\n", buf.String())
+}
+
+func TestWrapTextPreformatted(t *testing.T) {
+	buf := &bytes.Buffer{}
+	wrapText(buf, "Example:\n    foo --bar\n\n    baz\nDone.", "> ", "> >", 80)
+	assert.Equal(t, "> Example:\n> \n> >foo --bar\n\n> >baz\n> \n> Done.\n", buf.String())
+}
+
+func TestFormatTwoColumnsAlignsWideText(t *testing.T) {
+	buf := &bytes.Buffer{}
+	formatTwoColumns(buf, 0, 2, 80, [][2]string{{"名前", "Name."}, {"name", "Name."}})
+	assert.Equal(t, "名前  Name.\nname  Name.\n", buf.String())
+}
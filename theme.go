@@ -0,0 +1,73 @@
+package kingpin
+
+// Color is an ANSI SGR parameter sequence, eg. "32" for green. Colors can be
+// combined with And().
+type Color string
+
+// Common colors and attributes.
+const (
+	ColorPlain     Color = ""
+	ColorBold      Color = "1"
+	ColorDim       Color = "2"
+	ColorUnderline Color = "4"
+	ColorRed       Color = "31"
+	ColorGreen     Color = "32"
+	ColorYellow    Color = "33"
+	ColorBlue      Color = "34"
+	ColorMagenta   Color = "35"
+	ColorCyan      Color = "36"
+)
+
+// And returns a Color applying both c and other, eg.
+// ColorBold.And(ColorGreen).
+func (c Color) And(other Color) Color {
+	switch {
+	case c == "":
+		return other
+	case other == "":
+		return c
+	}
+	return c + ";" + other
+}
+
+// Wrap s in the escape sequences for c.
+func (c Color) apply(s string) string {
+	if c == "" || s == "" {
+		return s
+	}
+	return "\x1b[" + string(c) + "m" + s + ansiReset
+}
+
+// Theme styles the parts of help output. Zero values are left plain.
+type Theme struct {
+	Heading Color // Section headings, eg. "Flags:".
+	Command Color // Command names in command lists.
+	Flag    Color // Flags, with their short form and place-holder.
+	Arg     Color // Argument names.
+}
+
+// DefaultTheme renders headings in bold.
+var DefaultTheme = Theme{Heading: ColorBold}
+
+// HelpTheme sets the colors used for help output, eg.
+//
+//     app.HelpTheme(kingpin.Theme{
+//         Heading: kingpin.ColorBold.And(kingpin.ColorUnderline),
+//         Command: kingpin.ColorGreen,
+//         Flag:    kingpin.ColorCyan,
+//     })
+//
+// As with errors, help is only colored when written to a terminal and the
+// NO_COLOR environment variable is not set, unless overridden with Color().
+// The default is DefaultTheme.
+func (a *Application) HelpTheme(theme Theme) *Application {
+	a.theme = &theme
+	return a
+}
+
+func (a *Application) helpTheme() Theme {
+	if a.theme == nil {
+		return DefaultTheme
+	}
+	return *a.theme
+}
@@ -0,0 +1,52 @@
+package kingpin
+
+import (
+	"os"
+	"strings"
+)
+
+// Transformer normalises a raw command-line value before it is passed to the
+// Value of a flag or argument.
+type Transformer func(value string) (string, error)
+
+type transformMixin struct {
+	transforms      []Transformer
+	inputTransforms []Transformer // Only applied to typed input, see NumberFormat().
+}
+
+func (t *transformMixin) addTransform(transform Transformer) {
+	t.transforms = append(t.transforms, transform)
+}
+
+func (t *transformMixin) addInputTransform(transform Transformer) {
+	t.inputTransforms = append(t.inputTransforms, transform)
+}
+
+// Apply all transforms, in the order they were added. Input transforms are
+// applied first, and only if value was typed by the user on the command line,
+// in an envar or at a prompt, rather than read from a default or a file.
+func (t *transformMixin) transform(value string, input bool) (string, error) {
+	transforms := t.transforms
+	if input {
+		transforms = append(append([]Transformer{}, t.inputTransforms...), transforms...)
+	}
+	for _, transform := range transforms {
+		var err error
+		if value, err = transform(value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+func trimTransform(value string) (string, error) {
+	return strings.TrimSpace(value), nil
+}
+
+func expandEnvTransform(value string) (string, error) {
+	return os.ExpandEnv(value), nil
+}
+
+func toLowerTransform(value string) (string, error) {
+	return strings.ToLower(value), nil
+}
@@ -0,0 +1,50 @@
+package kingpin
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestFlagTransforms(t *testing.T) {
+	os.Setenv("TEST_TRANSFORM_DIR", "/tmp")
+	defer os.Unsetenv("TEST_TRANSFORM_DIR")
+
+	app := newTestApp()
+	format := app.Flag("format", "").Trim().ToLower().Enum("json", "yaml")
+	dir := app.Flag("dir", "").ExpandEnv().String()
+	_, err := app.Parse([]string{"--format", "  JSON ", "--dir=$TEST_TRANSFORM_DIR/cache"})
+	assert.NoError(t, err)
+	assert.Equal(t, "json", *format)
+	assert.Equal(t, "/tmp/cache", *dir)
+}
+
+func TestTransformAppliesToDefaultsAndEnvars(t *testing.T) {
+	os.Setenv("TEST_TRANSFORM_NAME", "  Bob ")
+	defer os.Unsetenv("TEST_TRANSFORM_NAME")
+
+	app := newTestApp()
+	name := app.Flag("name", "").Envar("TEST_TRANSFORM_NAME").Trim().String()
+	mode := app.Arg("mode", "").Default("FAST").ToLower().String()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob", *name)
+	assert.Equal(t, "fast", *mode)
+}
+
+func TestCustomTransformError(t *testing.T) {
+	app := newTestApp()
+	app.Flag("id", "").Transform(func(value string) (string, error) {
+		if !strings.HasPrefix(value, "id-") {
+			return "", errors.New("ids must start with id-")
+		}
+		return strings.TrimPrefix(value, "id-"), nil
+	}).Int()
+	_, err := app.Parse([]string{"--id=42"})
+	assert.Error(t, err)
+	_, err = app.Parse([]string{"--id=id-42"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,44 @@
+package kingpin
+
+// CollectUnknownFlags appends flags not defined by the command, or any of
+// its parents, to target exactly as given rather than failing, eg. for a
+// wrapper that forwards options to another program:
+//
+//     var forward []string
+//     run := app.Command("run", "Run a container.").CollectUnknownFlags(&forward)
+//
+//     app run --rm -it --name=web nginx
+//
+// As the parser can not tell whether an unknown flag takes a value, values
+// must be attached as in "--name=web"; a separate value is parsed as an
+// argument. The setting applies to subcommands unless they collect into a
+// target of their own.
+func (c *Cmd) CollectUnknownFlags(target *[]string) *Cmd {
+	c.unknownFlags = target
+	return c
+}
+
+// CollectUnknownFlags appends flags not defined by the application to target
+// rather than failing. See Cmd.CollectUnknownFlags().
+func (a *Application) CollectUnknownFlags(target *[]string) *Application {
+	a.unknownFlags = target
+	return a
+}
+
+// If unknown flags are being collected, consume the flag token and record it
+// for setValues().
+func (p *ParseContext) collectUnknownFlag(token *Token) bool {
+	if p.unknownTarget == nil {
+		return false
+	}
+	p.Next()
+	// The value of "--flag=value" is tokenized separately.
+	if next := p.Peek(); next.Type == TokenArg && next.Index == token.Index {
+		p.Next()
+	}
+	if p.unknownFlags == nil {
+		p.unknownFlags = map[*[]string][]string{}
+	}
+	p.unknownFlags[p.unknownTarget] = append(p.unknownFlags[p.unknownTarget], p.raw(token)...)
+	return true
+}
@@ -0,0 +1,112 @@
+package kingpin
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// Release describes a published version of an application, as returned by an
+// Updater.
+type Release struct {
+	Version string
+	URL     string
+	// Checksum of the release artifact, in whatever form the Updater's Verify
+	// understands.
+	Checksum string
+	Notes    string
+}
+
+// Updater supplies the release backend for the "update" command scaffold
+// added by Application.UpdateCommand().
+type Updater interface {
+	// Latest returns the newest available release.
+	Latest() (*Release, error)
+	// Download returns the contents of the release artifact.
+	Download(release *Release) (io.ReadCloser, error)
+	// Verify checks the downloaded artifact at path before it replaces the
+	// running binary.
+	Verify(release *Release, path string) error
+}
+
+// Used to locate the binary to replace. Overridden in tests.
+var executablePath = os.Executable
+
+// UpdateCommand adds an "update" command that checks for a newer release
+// using updater, downloads and verifies it, then replaces the running binary.
+//
+// The current version is the one provided to Version().
+func (a *Application) UpdateCommand(updater Updater) *Cmd {
+	var (
+		check bool
+		force bool
+	)
+	cmd := a.Command("update", "Update to the latest release.")
+	cmd.Flag("check", "Only check whether an update is available.").BoolVar(&check)
+	cmd.Flag("force", "Update even if already at the latest version.").BoolVar(&force)
+	cmd.Action(func(*ParseContext) error {
+		return a.update(updater, check, force)
+	})
+	return cmd
+}
+
+func (a *Application) update(updater Updater, check, force bool) error {
+	release, err := updater.Latest()
+	if err != nil {
+		return fmt.Errorf("checking for updates: %s", err)
+	}
+	if release.Version == a.version && !force {
+		fmt.Fprintf(a.usageWriter, "%s is up to date (%s)\n", a.Name, a.version)
+		return nil
+	}
+	if check {
+		fmt.Fprintf(a.usageWriter, "%s %s is available (current version %s)\n", a.Name, release.Version, a.version)
+		return nil
+	}
+
+	exe, err := executablePath()
+	if err != nil {
+		return fmt.Errorf("locating executable: %s", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("locating executable: %s", err)
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	// Download next to the executable so the final rename is atomic.
+	tmp, err := ioutil.TempFile(filepath.Dir(exe), "."+filepath.Base(exe)+".update-")
+	if err != nil {
+		return fmt.Errorf("downloading %s: %s", release.Version, err)
+	}
+	defer os.Remove(tmp.Name())
+	r, err := updater.Download(release)
+	if err != nil {
+		tmp.Close()
+		return fmt.Errorf("downloading %s: %s", release.Version, err)
+	}
+	_, err = io.Copy(tmp, r)
+	r.Close()
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("downloading %s: %s", release.Version, err)
+	}
+
+	if err := updater.Verify(release, tmp.Name()); err != nil {
+		return fmt.Errorf("verifying %s: %s", release.Version, err)
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), exe); err != nil {
+		return fmt.Errorf("replacing %s: %s", exe, err)
+	}
+	fmt.Fprintf(a.usageWriter, "updated %s from %s to %s\n", a.Name, a.version, release.Version)
+	return nil
+}
@@ -0,0 +1,81 @@
+package kingpin
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type testUpdater struct {
+	release   *Release
+	contents  string
+	verifyErr error
+}
+
+func (u *testUpdater) Latest() (*Release, error) { return u.release, nil }
+
+func (u *testUpdater) Download(*Release) (io.ReadCloser, error) {
+	return ioutil.NopCloser(strings.NewReader(u.contents)), nil
+}
+
+func (u *testUpdater) Verify(*Release, string) error { return u.verifyErr }
+
+func withExecutable(t *testing.T, contents string) (string, func()) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	exe := filepath.Join(dir, "app")
+	assert.NoError(t, ioutil.WriteFile(exe, []byte(contents), 0755))
+	executablePath = func() (string, error) { return exe, nil }
+	return exe, func() {
+		executablePath = os.Executable
+		os.RemoveAll(dir)
+	}
+}
+
+func TestUpdateCommandReplacesBinary(t *testing.T) {
+	exe, cleanup := withExecutable(t, "old")
+	defer cleanup()
+
+	var buf bytes.Buffer
+	app := newTestApp().Version("1.0.0").UsageWriter(&buf)
+	app.UpdateCommand(&testUpdater{release: &Release{Version: "1.1.0"}, contents: "new"})
+	_, err := app.Parse([]string{"update"})
+	assert.NoError(t, err)
+	data, err := ioutil.ReadFile(exe)
+	assert.NoError(t, err)
+	assert.Equal(t, "new", string(data))
+	assert.Contains(t, buf.String(), "from 1.0.0 to 1.1.0")
+}
+
+func TestUpdateCommandCheckOnly(t *testing.T) {
+	exe, cleanup := withExecutable(t, "old")
+	defer cleanup()
+
+	var buf bytes.Buffer
+	app := newTestApp().Version("1.0.0").UsageWriter(&buf)
+	app.UpdateCommand(&testUpdater{release: &Release{Version: "1.1.0"}, contents: "new"})
+	_, err := app.Parse([]string{"update", "--check"})
+	assert.NoError(t, err)
+	data, _ := ioutil.ReadFile(exe)
+	assert.Equal(t, "old", string(data))
+	assert.Contains(t, buf.String(), "1.1.0 is available")
+}
+
+func TestUpdateCommandVerifyFailureKeepsBinary(t *testing.T) {
+	exe, cleanup := withExecutable(t, "old")
+	defer cleanup()
+
+	app := newTestApp().Version("1.0.0").UsageWriter(ioutil.Discard)
+	app.UpdateCommand(&testUpdater{release: &Release{Version: "1.1.0"}, contents: "new", verifyErr: errors.New("bad checksum")})
+	_, err := app.Parse([]string{"update"})
+	assert.Error(t, err)
+	data, _ := ioutil.ReadFile(exe)
+	assert.Equal(t, "old", string(data))
+}
@@ -3,7 +3,6 @@ package kingpin
 import (
 	"bytes"
 	"fmt"
-	"go/doc"
 	"io"
 	"strings"
 
@@ -15,10 +14,15 @@ var (
 )
 
 func formatTwoColumns(w io.Writer, indent, padding, width int, rows [][2]string) {
-	// Find size of first column.
+	// Find size of first column, which takes at most half the width so there
+	// is room left for help on narrow terminals.
+	limit := width / 2
+	if limit > 30 {
+		limit = 30
+	}
 	s := 0
 	for _, row := range rows {
-		if c := len(row[0]); c > s && c < 30 {
+		if c := displayWidth(row[0]); c > s && c < limit {
 			s = c
 		}
 	}
@@ -28,10 +32,10 @@ func formatTwoColumns(w io.Writer, indent, padding, width int, rows [][2]string)
 
 	for _, row := range rows {
 		buf := bytes.NewBuffer(nil)
-		doc.ToText(buf, row[1], "", preIndent, width-s-padding-indent)
+		wrapText(buf, row[1], "", preIndent, width-s-padding-indent)
 		lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
-		fmt.Fprintf(w, "%s%-*s%*s", indentStr, s, row[0], padding, "")
-		if len(row[0]) >= 30 {
+		fmt.Fprintf(w, "%s%s%*s", indentStr, padRight(row[0], s), padding, "")
+		if displayWidth(row[0]) >= limit {
 			fmt.Fprintf(w, "\n%s%s", indentStr, offsetStr)
 		}
 		fmt.Fprintf(w, "%s\n", lines[0])
@@ -44,9 +48,9 @@ func formatTwoColumns(w io.Writer, indent, padding, width int, rows [][2]string)
 // Usage writes application usage to w. It parses args to determine
 // appropriate help context, such as which command to show help for.
 func (a *Application) Usage(args []string) {
-	context, err := a.parseContext(true, args)
+	context, err := a.parseContext(true, a.dispatchArgs(args))
 	a.FatalIfError(err, "")
-	if err := a.UsageForContextWithTemplate(context, 2, a.usageTemplate); err != nil {
+	if err := a.UsageForContextWithTemplate(context, 2, a.usageTemplateFor(context)); err != nil {
 		panic(err)
 	}
 }
@@ -74,16 +78,7 @@ func formatCmdUsage(app *ApplicationModel, cmd *CmdModel) string {
 }
 
 func formatFlag(haveShort bool, flag *FlagModel) string {
-	flagString := "  "
-	if flag.Short != 0 {
-		flagString += fmt.Sprintf("-%c, --%s", flag.Short, flag.Name)
-	} else {
-		if haveShort {
-			flagString += fmt.Sprintf("    --%s", flag.Name)
-		} else {
-			flagString += fmt.Sprintf("--%s", flag.Name)
-		}
-	}
+	flagString := formatFlagName(haveShort, flag)
 	if !flag.IsBoolFlag() {
 		flagString += fmt.Sprintf("=%s", flag.FormatPlaceHolder())
 	}
@@ -93,6 +88,33 @@ func formatFlag(haveShort bool, flag *FlagModel) string {
 	return flagString
 }
 
+func formatFlagName(haveShort bool, flag *FlagModel) string {
+	if flag.Short != 0 {
+		return fmt.Sprintf("  -%c, --%s", flag.Short, flag.Name)
+	} else if haveShort {
+		return fmt.Sprintf("      --%s", flag.Name)
+	}
+	return fmt.Sprintf("  --%s", flag.Name)
+}
+
+// DefaultOptionsLimit is the number of enum options listed in help before
+// the rest are summarised. See FlagClause.OptionsLimit().
+var DefaultOptionsLimit = 10
+
+// Append a list of at most limit options to help.
+func helpWithOptions(help string, options []string, limit int) string {
+	if len(options) == 0 {
+		return help
+	}
+	if limit == 0 {
+		limit = DefaultOptionsLimit
+	}
+	if limit < 0 || len(options) <= limit {
+		return appendHelp(help, "One of: "+strings.Join(options, ", ")+".")
+	}
+	return appendHelp(help, fmt.Sprintf("One of: %s, …and %d more (use completion to list them all).", strings.Join(options[:limit], ", "), len(options)-limit))
+}
+
 type templateParseContext struct {
 	SelectedCommand *CmdModel
 	*FlagGroupModel
@@ -108,12 +130,66 @@ type templateContext struct {
 // UsageForContext displays usage information from a ParseContext (obtained from
 // Application.ParseContext() or Action(f) callbacks).
 func (a *Application) UsageForContext(context *ParseContext) error {
-	return a.UsageForContextWithTemplate(context, 2, a.usageTemplate)
+	return a.UsageForContextWithTemplate(context, 2, a.usageTemplateFor(context))
 }
 
 // UsageForContextWithTemplate is the base usage function. You generally don't need to use this.
 func (a *Application) UsageForContextWithTemplate(context *ParseContext, indent int, tmpl string) error {
-	width := guessWidth(a.usageWriter)
+	w := a.usageWriterFor(context)
+	if !a.pagedHelp {
+		return a.renderUsage(w, context, indent, tmpl)
+	}
+	page := &pager{w: w, errW: a.errorWriter}
+	if err := a.renderUsage(page, context, indent, tmpl); err != nil {
+		return err
+	}
+	return page.flush()
+}
+
+// The usage template for the command selected by context: the template set
+// on the command or its nearest ancestor with Cmd.UsageTemplate(), or the
+// application's.
+func (a *Application) usageTemplateFor(context *ParseContext) string {
+	for cmd := context.SelectedCommand; cmd != nil; cmd = cmd.parent {
+		if cmd.usageTemplate != "" {
+			return cmd.usageTemplate
+		}
+	}
+	return a.usageTemplate
+}
+
+// The usage writer for the command selected by context. See
+// usageTemplateFor().
+func (a *Application) usageWriterFor(context *ParseContext) io.Writer {
+	for cmd := context.SelectedCommand; cmd != nil; cmd = cmd.parent {
+		if cmd.usageWriter != nil {
+			return cmd.usageWriter
+		}
+	}
+	return a.usageWriter
+}
+
+// Render tmpl to w.
+func (a *Application) renderUsage(w io.Writer, context *ParseContext, indent int, tmpl string) error {
+	// Size and color help for the terminal, rather than any pager buffer.
+	terminal := w
+	if page, ok := w.(*pager); ok {
+		terminal = page.w
+	}
+	width := a.terminalWidth
+	if width <= 0 {
+		width = guessWidth(terminal)
+	}
+	theme := a.helpTheme()
+	color := a.colorEnabled(terminal)
+	style := func(c Color, s string) string {
+		if !color {
+			return s
+		}
+		// Leave leading padding unstyled so underlines don't extend into it.
+		trimmed := strings.TrimLeft(s, " ")
+		return s[:len(s)-len(trimmed)] + c.apply(trimmed)
+	}
 	funcs := template.FuncMap{
 		"Indent": func(level int) string {
 			return strings.Repeat(" ", level*indent)
@@ -121,7 +197,7 @@ func (a *Application) UsageForContextWithTemplate(context *ParseContext, indent
 		"Wrap": func(indent int, s string) string {
 			buf := bytes.NewBuffer(nil)
 			indentText := strings.Repeat(" ", indent)
-			doc.ToText(buf, s, indentText, "  "+indentText, width-indent)
+			wrapText(buf, s, indentText, "  "+indentText, width-indent)
 			return buf.String()
 		},
 		"FormatFlag": formatFlag,
@@ -135,12 +211,36 @@ func (a *Application) UsageForContextWithTemplate(context *ParseContext, indent
 				}
 			}
 			for _, flag := range f {
-				if !flag.Hidden {
-					rows = append(rows, [2]string{formatFlag(haveShort, flag), flag.Help})
+				if flag.Hidden {
+					continue
+				}
+				s := formatFlag(haveShort, flag)
+				if context.helpValues {
+					s = context.formatFlagValue(haveShort, flag)
+				}
+				rows = append(rows, [2]string{style(theme.Flag, s), flag.HelpWithOptions()})
+			}
+			return rows
+		},
+		"CommandsToTwoColumns": func(cmds []*CmdModel) [][2]string {
+			rows := [][2]string{}
+			for _, cmd := range cmds {
+				if !cmd.Hidden {
+					rows = append(rows, [2]string{style(theme.Command, "  "+cmd.FullCommand), cmd.Help})
 				}
 			}
 			return rows
 		},
+		"HelpTopicsToTwoColumns": func(topics []HelpTopic) [][2]string {
+			rows := [][2]string{}
+			for _, topic := range topics {
+				rows = append(rows, [2]string{style(theme.Command, "  "+topic.Name), topic.Title})
+			}
+			return rows
+		},
+		"FlagGroups": func(f []*FlagModel) []*FlagGroupSection {
+			return (&FlagGroupModel{Flags: f}).Sections()
+		},
 		"RequiredFlags": func(f []*FlagModel) []*FlagModel {
 			requiredFlags := []*FlagModel{}
 			for _, flag := range f {
@@ -162,11 +262,14 @@ func (a *Application) UsageForContextWithTemplate(context *ParseContext, indent
 		"ArgsToTwoColumns": func(a []*ArgModel) [][2]string {
 			rows := [][2]string{}
 			for _, arg := range a {
-				s := "<" + arg.Name + ">"
+				if arg.Hidden {
+					continue
+				}
+				s := arg.FormatPlaceHolder()
 				if !arg.Required {
-					s = "[" + s + "]"
+					s = "[" + s + arg.formatDefault() + "]"
 				}
-				rows = append(rows, [2]string{"  " + s, arg.Help})
+				rows = append(rows, [2]string{style(theme.Arg, "  "+s), arg.HelpWithOptions()})
 			}
 			return rows
 		},
@@ -189,8 +292,17 @@ func (a *Application) UsageForContextWithTemplate(context *ParseContext, indent
 		"Char": func(c rune) string {
 			return string(c)
 		},
+		"PadRight": func(width int, s string) string {
+			return padRight(s, width)
+		},
 		"bold": func(s string) string {
-			return fmt.Sprintf("\033[1m%s\033[0m", s)
+			return style(ColorBold, s)
+		},
+		"heading": func(s string) string {
+			return style(theme.Heading, s)
+		},
+		"command": func(s string) string {
+			return style(theme.Command, s)
 		},
 	}
 	t, err := template.New("usage").Funcs(funcs).Parse(tmpl)
@@ -206,9 +318,19 @@ func (a *Application) UsageForContextWithTemplate(context *ParseContext, indent
 		Width: width,
 		Context: &templateParseContext{
 			SelectedCommand: selectedCommand,
-			FlagGroupModel:  context.flags.Model(),
+			FlagGroupModel:  context.flagsModel(),
 			ArgGroupModel:   context.arguments.Model(),
 		},
 	}
-	return t.Execute(a.usageWriter, ctx)
+	level := context.helpLevel
+	if level < HelpLevelBasic {
+		level = HelpLevelBasic
+	}
+	ctx.App.hideAbove(level)
+	ctx.Context.FlagGroupModel.hideAbove(level)
+	if selectedCommand != nil {
+		selectedCommand.FlagGroupModel.hideAbove(level)
+		selectedCommand.CmdGroupModel.hideAbove(level)
+	}
+	return t.Execute(w, ctx)
 }
@@ -2,8 +2,10 @@ package kingpin
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/tj/assert"
 )
@@ -63,3 +65,151 @@ func TestHiddenCommand(t *testing.T) {
 		assert.Contains(t, usage, "visible")
 	}
 }
+
+func TestManPageMetadata(t *testing.T) {
+	var buf bytes.Buffer
+	a := New("test", "Test").Version("1.2.3").Writer(&buf).Terminate(nil).
+		ManSection("8").
+		Date(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)).
+		Authors("Alice <alice@example.com>", "Bob").
+		Copyright("Copyright 2024 Example Inc.").
+		SourceURL("https://example.com/test").
+		SeeAlso("git(1)", "ssh(1)")
+	ctx, err := a.ParseContext(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, a.UsageForContextWithTemplate(ctx, 2, ManPageTemplate))
+	usage := buf.String()
+	assert.Contains(t, usage, `.TH "test" "8" "2024-03-01" "test 1.2.3"`)
+	for _, line := range []string{".SH \"AUTHORS\"", "Alice <alice@example.com>", "Bob", "https://example.com/test", "Copyright 2024 Example Inc.", "git(1), ssh(1)"} {
+		assert.Contains(t, usage, line+"\n")
+	}
+}
+
+func TestHelpWithOptions(t *testing.T) {
+	regions := []string{}
+	for i := 0; i < 200; i++ {
+		regions = append(regions, fmt.Sprintf("r%d", i))
+	}
+	app := newTestApp()
+	app.Flag("format", "Format.").Enum("json", "text")
+	app.Flag("region", "Region.").Enum(regions...)
+	app.Flag("zone", "").OptionsLimit(2).Enum("a", "b", "c")
+	assert.Equal(t, "Format. One of: json, text.", app.GetFlag("format").Model().HelpWithOptions())
+	assert.Equal(t, "Region. One of: r0, r1, r2, r3, r4, r5, r6, r7, r8, r9, …and 190 more (use completion to list them all).", app.GetFlag("region").Model().HelpWithOptions())
+	assert.Equal(t, "One of: a, b, …and 1 more (use completion to list them all).", app.GetFlag("zone").Model().HelpWithOptions())
+}
+
+func TestFlagGroupsInHelp(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).Terminate(nil).UsageTemplate(CompactUsageTemplate)
+	app.Flag("debug", "Enable debugging.").Bool()
+	conn := app.FlagGroup("Connection options")
+	conn.Flag("host", "Server to connect to.").String()
+	conn.Flag("secret-port", "").Hidden().Int()
+	app.FlagGroup("Output options").Flag("format", "Output format.").String()
+	app.FlagGroup("Empty")
+	app.Parse([]string{"--help"})
+	usage := buf.String()
+	flags := strings.Index(usage, "Flags:")
+	debug := strings.Index(usage, "--debug")
+	connection := strings.Index(usage, "Connection options:")
+	host := strings.Index(usage, "--host")
+	output := strings.Index(usage, "Output options:")
+	format := strings.Index(usage, "--format")
+	assert.True(t, flags >= 0 && flags < debug && debug < connection && connection < host && host < output && output < format, usage)
+	assert.NotContains(t, usage, "Empty")
+	assert.NotContains(t, usage, "secret-port")
+}
+
+func TestCommandCategoriesInHelp(t *testing.T) {
+	for _, template := range []string{DefaultUsageTemplate, SeparateOptionalFlagsUsageTemplate, CompactUsageTemplate} {
+		var buf bytes.Buffer
+		app := New("test", "").Writer(&buf).Terminate(nil).UsageTemplate(template)
+		app.CategoryOrder("Management Commands")
+		app.Command("run", "Run a container.")
+		app.Command("prune", "Remove unused data.").Category("System Commands")
+		app.Command("container", "Manage containers.").Category("Management Commands")
+		app.Command("secret", "").Category("Hidden Commands").Hidden()
+		app.Parse([]string{"--help"})
+		usage := buf.String()
+		commands := strings.Index(usage, "Commands:")
+		run := strings.Index(usage, "  run")
+		management := strings.Index(usage, "Management Commands:")
+		container := strings.Index(usage, "  container")
+		system := strings.Index(usage, "System Commands:")
+		prune := strings.Index(usage, "  prune")
+		assert.True(t, commands >= 0 && commands < run && run < management && management < container && container < system && system < prune, usage)
+		assert.NotContains(t, usage, "Hidden Commands")
+	}
+}
+
+func TestArgDefaultsInUsage(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).Terminate(nil)
+	app.Arg("src", "Source.").Required().String()
+	app.Arg("dir", "Directory.").Default(".").String()
+	app.Arg("files", "Files.").PlaceHolder("FILE").Default("a", "b").Strings()
+	app.Parse([]string{"--help"})
+	usage := buf.String()
+	assert.Contains(t, usage, " <src> [<dir>=.] [FILE...=a,b]")
+	assert.Contains(t, usage, "    [<dir>=.]   Directory.\n")
+	assert.Contains(t, usage, "    [FILE=a,b]  Files.\n")
+	assert.Equal(t, "<src> [<dir>=. [FILE=a,b]]", app.Model().ArgSummary())
+}
+
+func TestCommandUsageTemplate(t *testing.T) {
+	var appOut, cmdOut bytes.Buffer
+	app := New("test", "").Writer(&appOut).Terminate(nil)
+	db := app.Command("db", "").UsageTemplate("db help for {{.Context.SelectedCommand.FullCommand}}\n").UsageWriter(&cmdOut)
+	db.Command("migrate", "")
+	app.Command("run", "")
+
+	app.Parse([]string{"db", "migrate", "--help"})
+	assert.Equal(t, "db help for db migrate\n", cmdOut.String())
+	assert.Equal(t, "", appOut.String())
+
+	app.Parse([]string{"run", "--help"})
+	assert.Contains(t, appOut.String(), "test run")
+}
+
+func TestTerminalWidth(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).Terminate(nil).Terminal(40)
+	app.Command("deploy", "Deploy the application to the configured cluster.")
+	app.Command("ls", "List deployments.")
+	app.Parse([]string{"--help"})
+	usage := buf.String()
+	assert.Contains(t, usage, "    deploy  Deploy the application to\n            the configured cluster.\n")
+	assert.Contains(t, usage, "    ls      List deployments.\n")
+}
+
+func TestHelpLevels(t *testing.T) {
+	var buf bytes.Buffer
+	app := New("test", "").Writer(&buf).OutputWriter(&buf).Terminate(nil)
+	app.Flag("verbose", "Verbose output.").Bool()
+	app.Flag("gc-percent", "GC target percentage.").HelpLevel(HelpLevelLong).Int()
+	app.Flag("trace-alloc", "Trace allocations.").HelpLevel(HelpLevelAll).Bool()
+	app.Command("run", "Run it.")
+	app.Command("debug", "Debug internals.").HelpLevel(HelpLevelAll)
+
+	app.Parse([]string{"--help"})
+	usage := buf.String()
+	assert.Contains(t, usage, "--verbose")
+	assert.NotContains(t, usage, "--gc-percent")
+	assert.NotContains(t, usage, "--trace-alloc")
+	assert.NotContains(t, usage, "debug")
+
+	buf.Reset()
+	app.Parse([]string{"--help-long"})
+	usage = buf.String()
+	assert.Contains(t, usage, "--gc-percent")
+	assert.NotContains(t, usage, "--trace-alloc")
+	assert.NotContains(t, usage, "debug")
+
+	buf.Reset()
+	app.Parse([]string{"--help-all"})
+	usage = buf.String()
+	assert.Contains(t, usage, "--gc-percent")
+	assert.Contains(t, usage, "--trace-alloc")
+	assert.Contains(t, usage, "Debug internals.")
+}
@@ -0,0 +1,51 @@
+package kingpin
+
+// A ValueValidator checks a raw flag or argument value before it is parsed.
+type ValueValidator func(value string) error
+
+type validateMixin struct {
+	validators []ValueValidator
+}
+
+func (v *validateMixin) addValidator(validator ValueValidator) {
+	v.validators = append(v.validators, validator)
+}
+
+// Apply all validators, in the order they were added, returning the first
+// error.
+func (v *validateMixin) validate(value string) error {
+	for _, validator := range v.validators {
+		if err := validator(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate registers a function that checks each value of the flag, after
+// any Transform()s and before it is parsed, eg.
+//
+//     app.Flag("name", "Service name.").Validate(func(value string) error {
+//         if len(value) > 63 {
+//             return errors.New("must be at most 63 characters")
+//         }
+//         return nil
+//     }).String()
+//
+// Errors are reported naming the flag:
+//
+//     invalid value 'x...' for flag --name: must be at most 63 characters
+//
+// Validators apply to values from the command line, the environment, config
+// files and defaults alike.
+func (f *FlagClause) Validate(validator ValueValidator) *FlagClause {
+	f.addValidator(validator)
+	return f
+}
+
+// Validate registers a function that checks each value of the argument. See
+// FlagClause.Validate().
+func (a *ArgClause) Validate(validator ValueValidator) *ArgClause {
+	a.addValidator(validator)
+	return a
+}
@@ -0,0 +1,56 @@
+package kingpin
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func portRange(value string) error {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("must be a port between 1 and 65535")
+	}
+	return nil
+}
+
+func nonEmpty(value string) error {
+	if value == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	app := newTestApp()
+	port := app.Flag("port", "").Default("8080").Validate(portRange).Int()
+	name := app.Arg("name", "").Validate(nonEmpty).String()
+
+	_, err := app.Parse([]string{"--port=443", "web"})
+	assert.NoError(t, err)
+	assert.Equal(t, 443, *port)
+	assert.Equal(t, "web", *name)
+
+	_, err = app.Parse([]string{"--port=70000", "web"})
+	assert.EqualError(t, err, "invalid value '70000' for flag --port: must be a port between 1 and 65535")
+
+	_, err = app.Parse([]string{""})
+	assert.EqualError(t, err, "invalid value '' for argument 'name': must not be empty")
+}
+
+func TestValidateAppliesToDefaultsAndMasksSecrets(t *testing.T) {
+	app := newTestApp()
+	app.Flag("port", "").Default("0").Validate(portRange).Int()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "invalid value '0' for flag --port: must be a port between 1 and 65535")
+
+	app = newTestApp()
+	app.Flag("token", "").Secret().Validate(func(value string) error {
+		return fmt.Errorf("'%s' is too short", value)
+	}).String()
+	_, err = app.Parse([]string{"--token=abc"})
+	assert.EqualError(t, err, "invalid value '******' for flag --token: '******' is too short")
+}
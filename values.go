@@ -4,11 +4,15 @@ package kingpin
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -84,12 +88,17 @@ func newAccumulator(slice interface{}, element func(value interface{}) Value) *a
 }
 
 func (a *accumulator) String() string {
+	return strings.Join(a.strings(), ",")
+}
+
+// The string form of each accumulated value.
+func (a *accumulator) strings() []string {
 	out := []string{}
 	s := a.slice.Elem()
 	for i := 0; i < s.Len(); i++ {
 		out = append(out, a.element(s.Index(i).Addr().Interface()).String())
 	}
-	return strings.Join(out, ",")
+	return out
 }
 
 func (a *accumulator) Set(value string) error {
@@ -106,10 +115,64 @@ func (a *accumulator) Get() interface{} {
 	return a.slice.Interface()
 }
 
+func (a *accumulator) storage() interface{} { return a.slice.Interface() }
+
 func (a *accumulator) IsCumulative() bool {
 	return true
 }
 
+// optionalValue holds a pointer to a value which is nil until Set.
+type optionalValue struct {
+	element func(value interface{}) Value
+	typ     reflect.Type
+	ptr     reflect.Value
+}
+
+// Use reflection to allocate and set an optional value.
+//
+// var target *string
+// newOptionalValue(&target, func (value interface{}) Value {
+//   return newStringValue(value.(*string))
+// })
+func newOptionalValue(ptr interface{}, element func(value interface{}) Value) *optionalValue {
+	typ := reflect.TypeOf(ptr)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Ptr {
+		panic("expected a pointer to a pointer")
+	}
+	return &optionalValue{
+		element: element,
+		typ:     typ.Elem().Elem(),
+		ptr:     reflect.ValueOf(ptr),
+	}
+}
+
+func (o *optionalValue) Set(value string) error {
+	e := reflect.New(o.typ)
+	if err := o.element(e.Interface()).Set(value); err != nil {
+		return err
+	}
+	o.ptr.Elem().Set(e)
+	return nil
+}
+
+func (o *optionalValue) Get() interface{} {
+	return o.ptr.Elem().Interface()
+}
+
+func (o *optionalValue) storage() interface{} { return o.ptr.Interface() }
+
+func (o *optionalValue) String() string {
+	if o.ptr.Elem().IsNil() {
+		return ""
+	}
+	return o.element(o.ptr.Elem().Interface()).String()
+}
+
+func (o *optionalValue) IsBoolFlag() bool {
+	b, ok := o.element(reflect.New(o.typ).Interface()).(boolFlag)
+	return ok && b.IsBoolFlag()
+}
+
 func (b *boolValue) IsBoolFlag() bool { return true }
 
 // -- time.Duration Value
@@ -129,33 +192,184 @@ func (d *durationValue) Get() interface{} { return time.Duration(*d) }
 
 func (d *durationValue) String() string { return (*time.Duration)(d).String() }
 
-// -- map[string]string Value
-type stringMapValue map[string]string
+// -- time.Duration Value accepting the units d, w and y
+type extendedDurationValue time.Duration
+
+func newExtendedDurationValue(p *time.Duration) *extendedDurationValue {
+	return (*extendedDurationValue)(p)
+}
+
+func (d *extendedDurationValue) Set(s string) error {
+	hours, err := parseExtendedDuration(s)
+	if err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(hours)
+	if err != nil {
+		return errorf(MsgInvalidDuration, s)
+	}
+	*d = extendedDurationValue(v)
+	return nil
+}
+
+func (d *extendedDurationValue) Get() interface{} { return time.Duration(*d) }
+
+// String uses the largest of the units y, w and d that the duration is a whole
+// multiple of, if any.
+func (d *extendedDurationValue) String() string {
+	v := time.Duration(*d)
+	for _, unit := range []string{"y", "w", "d"} {
+		scale := durationUnits[unit]
+		if v != 0 && v%scale == 0 {
+			return fmt.Sprintf("%d%s", v/scale, unit)
+		}
+	}
+	return v.String()
+}
+
+// -- time.Time Value
+
+// The current time, replaceable in tests.
+var timeNow = time.Now
+
+type timeValue struct {
+	t       *time.Time
+	layouts []string
+}
+
+func newTimeValue(p *time.Time, layouts ...string) *timeValue {
+	return &timeValue{p, append([]string{time.RFC3339}, layouts...)}
+}
+
+func (t *timeValue) Set(value string) error {
+	now := timeNow()
+	year, month, day := now.Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	switch s := strings.TrimSpace(value); s {
+	case "now":
+		*t.t = now
+	case "today":
+		*t.t = today
+	case "yesterday":
+		*t.t = today.AddDate(0, 0, -1)
+	case "tomorrow":
+		*t.t = today.AddDate(0, 0, 1)
+	default:
+		if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+			if hours, err := parseExtendedDuration(s); err == nil {
+				if d, err := time.ParseDuration(hours); err == nil {
+					*t.t = now.Add(d)
+					return nil
+				}
+			}
+		}
+		for _, layout := range t.layouts {
+			if v, err := time.ParseInLocation(layout, s, now.Location()); err == nil {
+				*t.t = v
+				return nil
+			}
+		}
+		return errorf(MsgInvalidTime, value, strings.Join(t.layouts, ", "))
+	}
+	return nil
+}
+
+func (t *timeValue) Get() interface{} { return *t.t }
+
+func (t *timeValue) storage() interface{} { return t.t }
+
+func (t *timeValue) String() string {
+	if t.t.IsZero() {
+		return ""
+	}
+	return t.t.Format(time.RFC3339)
+}
+
+// -- string Value restricted to a regular expression
+type stringMatchingValue struct {
+	v       *string
+	pattern *regexp.Regexp
+}
+
+func newStringMatchingValue(p *string, pattern *regexp.Regexp) *stringMatchingValue {
+	return &stringMatchingValue{p, pattern}
+}
+
+func (s *stringMatchingValue) Set(value string) error {
+	if !s.pattern.MatchString(value) {
+		return errorf(MsgNotMatching, value, s.pattern)
+	}
+	*s.v = value
+	return nil
+}
+
+func (s *stringMatchingValue) Get() interface{} { return *s.v }
+
+func (s *stringMatchingValue) storage() interface{} { return s.v }
+
+func (s *stringMatchingValue) String() string { return *s.v }
+
+// -- map[string]T Value
+type mapValue struct {
+	element   func(value interface{}) Value
+	typ       reflect.Type
+	m         reflect.Value
+	separator string // Splits keys from values; ":" or "=" if empty.
+}
 
-func newStringMapValue(p *map[string]string) *stringMapValue {
-	return (*stringMapValue)(p)
+// Use reflection to set keys of a map[string]T, with values parsed by the
+// Value returned by element, eg.
+//
+// target := map[string]int{}
+// newMapValue(&target, func(value interface{}) Value {
+//   return newIntValue(value.(*int))
+// })
+func newMapValue(m interface{}, element func(value interface{}) Value) *mapValue {
+	typ := reflect.TypeOf(m)
+	if typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Map || typ.Elem().Key().Kind() != reflect.String {
+		panic("expected a pointer to a map with string keys")
+	}
+	return &mapValue{
+		element: element,
+		typ:     typ.Elem().Elem(),
+		m:       reflect.ValueOf(m),
+	}
 }
 
 var stringMapRegex = regexp.MustCompile("[:=]")
 
-func (s *stringMapValue) Set(value string) error {
-	parts := stringMapRegex.Split(value, 2)
+func (m *mapValue) Set(value string) error {
+	var parts []string
+	if m.separator != "" {
+		parts = strings.SplitN(value, m.separator, 2)
+	} else {
+		parts = stringMapRegex.Split(value, 2)
+	}
 	if len(parts) != 2 {
-		return fmt.Errorf("expected KEY=VALUE got '%s'", value)
+		return errorf(MsgInvalidKeyValue, value)
+	}
+	e := reflect.New(m.typ)
+	if err := m.element(e.Interface()).Set(parts[1]); err != nil {
+		return err
 	}
-	(*s)[parts[0]] = parts[1]
+	if m.m.Elem().IsNil() {
+		m.m.Elem().Set(reflect.MakeMap(m.m.Elem().Type()))
+	}
+	m.m.Elem().SetMapIndex(reflect.ValueOf(parts[0]).Convert(m.m.Elem().Type().Key()), e.Elem())
 	return nil
 }
 
-func (s *stringMapValue) Get() interface{} {
-	return (map[string]string)(*s)
+func (m *mapValue) Get() interface{} {
+	return m.m.Elem().Interface()
 }
 
-func (s *stringMapValue) String() string {
-	return fmt.Sprintf("%s", map[string]string(*s))
+func (m *mapValue) storage() interface{} { return m.m.Interface() }
+
+func (m *mapValue) String() string {
+	return fmt.Sprintf("%v", m.m.Elem().Interface())
 }
 
-func (s *stringMapValue) IsCumulative() bool {
+func (m *mapValue) IsCumulative() bool {
 	return true
 }
 
@@ -168,7 +382,7 @@ func newIPValue(p *net.IP) *ipValue {
 
 func (i *ipValue) Set(value string) error {
 	if ip := net.ParseIP(value); ip == nil {
-		return fmt.Errorf("'%s' is not an IP address", value)
+		return errorf(MsgInvalidIP, value)
 	} else {
 		*i = *(*ipValue)(&ip)
 		return nil
@@ -183,6 +397,62 @@ func (i *ipValue) String() string {
 	return (*net.IP)(i).String()
 }
 
+// -- *net.IPNet Value
+type cidrValue struct {
+	network **net.IPNet
+}
+
+func newCIDRValue(p **net.IPNet) *cidrValue {
+	return &cidrValue{p}
+}
+
+func (c *cidrValue) Set(value string) error {
+	_, network, err := net.ParseCIDR(value)
+	if err != nil {
+		return errorf(MsgInvalidCIDR, value)
+	}
+	*c.network = network
+	return nil
+}
+
+func (c *cidrValue) Get() interface{} {
+	return *c.network
+}
+
+func (c *cidrValue) storage() interface{} { return c.network }
+
+func (c *cidrValue) String() string {
+	if *c.network == nil {
+		return ""
+	}
+	return (*c.network).String()
+}
+
+// -- host:port Value
+type hostPortValue string
+
+func newHostPortValue(p *string) *hostPortValue {
+	return (*hostPortValue)(p)
+}
+
+// Set accepts a host (which may be empty) and a numeric port, normalising
+// IPv6 hosts to the bracketed form, eg. "[::1]:80".
+func (h *hostPortValue) Set(value string) error {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil {
+		return errorf(MsgInvalidHostPort, value)
+	}
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return errorf(MsgInvalidHostPort, value)
+	}
+	*h = hostPortValue(net.JoinHostPort(host, port))
+	return nil
+}
+
+func (h *hostPortValue) Get() interface{} { return string(*h) }
+
+func (h *hostPortValue) String() string { return string(*h) }
+
 // -- *net.TCPAddr Value
 type tcpAddrValue struct {
 	addr **net.TCPAddr
@@ -194,7 +464,7 @@ func newTCPAddrValue(p **net.TCPAddr) *tcpAddrValue {
 
 func (i *tcpAddrValue) Set(value string) error {
 	if addr, err := net.ResolveTCPAddr("tcp", value); err != nil {
-		return fmt.Errorf("'%s' is not a valid TCP address: %s", value, err)
+		return errorf(MsgInvalidTCPAddr, value, err)
 	} else {
 		*i.addr = addr
 		return nil
@@ -205,6 +475,8 @@ func (t *tcpAddrValue) Get() interface{} {
 	return (*net.TCPAddr)(*t.addr)
 }
 
+func (t *tcpAddrValue) storage() interface{} { return t.addr }
+
 func (i *tcpAddrValue) String() string {
 	return (*i.addr).String()
 }
@@ -225,7 +497,7 @@ func newFileStatValue(p *string, predicate func(os.FileInfo) error) *fileStatVal
 
 func (e *fileStatValue) Set(value string) error {
 	if s, err := os.Stat(value); os.IsNotExist(err) {
-		return fmt.Errorf("path '%s' does not exist", value)
+		return errorf(MsgPathNotExist, value)
 	} else if err != nil {
 		return err
 	} else if err := e.predicate(s); err != nil {
@@ -239,6 +511,8 @@ func (f *fileStatValue) Get() interface{} {
 	return (string)(*f.path)
 }
 
+func (f *fileStatValue) storage() interface{} { return f.path }
+
 func (e *fileStatValue) String() string {
 	return *e.path
 }
@@ -256,6 +530,14 @@ func newFileValue(p **os.File, flag int, perm os.FileMode) *fileValue {
 }
 
 func (f *fileValue) Set(value string) error {
+	if value == "-" {
+		if f.flag&(os.O_WRONLY|os.O_RDWR) == 0 {
+			*f.f = os.Stdin
+		} else {
+			*f.f = os.Stdout
+		}
+		return nil
+	}
 	if fd, err := os.OpenFile(value, f.flag, f.perm); err != nil {
 		return err
 	} else {
@@ -268,6 +550,8 @@ func (f *fileValue) Get() interface{} {
 	return (*os.File)(*f.f)
 }
 
+func (f *fileValue) storage() interface{} { return f.f }
+
 func (f *fileValue) String() string {
 	if *f.f == nil {
 		return "<nil>"
@@ -275,28 +559,48 @@ func (f *fileValue) String() string {
 	return (*f.f).Name()
 }
 
+// Parse a URL, checking that it uses one of schemes if any are given.
+func parseURL(value string, schemes []string) (*url.URL, error) {
+	u, err := url.Parse(value)
+	if err != nil {
+		return nil, errorf(MsgInvalidURL, err)
+	}
+	if len(schemes) == 0 {
+		return u, nil
+	}
+	for _, scheme := range schemes {
+		if strings.EqualFold(u.Scheme, scheme) {
+			return u, nil
+		}
+	}
+	return nil, errorf(MsgInvalidURLScheme, value, strings.Join(schemes, ", "))
+}
+
 // -- url.URL Value
 type urlValue struct {
-	u **url.URL
+	u       **url.URL
+	schemes []string
 }
 
-func newURLValue(p **url.URL) *urlValue {
-	return &urlValue{p}
+func newURLValue(p **url.URL, schemes ...string) *urlValue {
+	return &urlValue{p, schemes}
 }
 
 func (u *urlValue) Set(value string) error {
-	if url, err := url.Parse(value); err != nil {
-		return fmt.Errorf("invalid URL: %s", err)
-	} else {
-		*u.u = url
-		return nil
+	url, err := parseURL(value, u.schemes)
+	if err != nil {
+		return err
 	}
+	*u.u = url
+	return nil
 }
 
 func (u *urlValue) Get() interface{} {
 	return (*url.URL)(*u.u)
 }
 
+func (u *urlValue) storage() interface{} { return u.u }
+
 func (u *urlValue) String() string {
 	if *u.u == nil {
 		return "<nil>"
@@ -305,28 +609,33 @@ func (u *urlValue) String() string {
 }
 
 // -- []*url.URL Value
-type urlListValue []*url.URL
+type urlListValue struct {
+	u       *[]*url.URL
+	schemes []string
+}
 
-func newURLListValue(p *[]*url.URL) *urlListValue {
-	return (*urlListValue)(p)
+func newURLListValue(p *[]*url.URL, schemes ...string) *urlListValue {
+	return &urlListValue{p, schemes}
 }
 
 func (u *urlListValue) Set(value string) error {
-	if url, err := url.Parse(value); err != nil {
-		return fmt.Errorf("invalid URL: %s", err)
-	} else {
-		*u = append(*u, url)
-		return nil
+	url, err := parseURL(value, u.schemes)
+	if err != nil {
+		return err
 	}
+	*u.u = append(*u.u, url)
+	return nil
 }
 
 func (u *urlListValue) Get() interface{} {
-	return ([]*url.URL)(*u)
+	return *u.u
 }
 
+func (u *urlListValue) storage() interface{} { return u.u }
+
 func (u *urlListValue) String() string {
 	out := []string{}
-	for _, url := range *u {
+	for _, url := range *u.u {
 		out = append(out, url.String())
 	}
 	return strings.Join(out, ",")
@@ -336,10 +645,22 @@ func (u *urlListValue) IsCumulative() bool {
 	return true
 }
 
+// The option matching value, if any. If fold is true, options are matched
+// case-insensitively and the option's own spelling is returned.
+func matchOption(options []string, value string, fold bool) (string, bool) {
+	for _, option := range options {
+		if option == value || (fold && strings.EqualFold(option, value)) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
 // A flag whose value must be in a set of options.
 type enumValue struct {
 	value   *string
 	options []string
+	fold    bool // See CaseInsensitive().
 }
 
 func newEnumFlag(target *string, options ...string) *enumValue {
@@ -354,23 +675,24 @@ func (a *enumValue) String() string {
 }
 
 func (a *enumValue) Set(value string) error {
-	for _, v := range a.options {
-		if v == value {
-			*a.value = value
-			return nil
-		}
+	if option, ok := matchOption(a.options, value, a.fold); ok {
+		*a.value = option
+		return nil
 	}
-	return fmt.Errorf("enum value must be one of %s, got '%s'", strings.Join(a.options, ","), value)
+	return errorf(MsgInvalidEnum, strings.Join(a.options, ","), value)
 }
 
 func (e *enumValue) Get() interface{} {
 	return (string)(*e.value)
 }
 
+func (e *enumValue) storage() interface{} { return e.value }
+
 // -- []string Enum Value
 type enumsValue struct {
 	value   *[]string
 	options []string
+	fold    bool // See CaseInsensitive().
 }
 
 func newEnumsFlag(target *[]string, options ...string) *enumsValue {
@@ -381,19 +703,19 @@ func newEnumsFlag(target *[]string, options ...string) *enumsValue {
 }
 
 func (s *enumsValue) Set(value string) error {
-	for _, v := range s.options {
-		if v == value {
-			*s.value = append(*s.value, value)
-			return nil
-		}
+	if option, ok := matchOption(s.options, value, s.fold); ok {
+		*s.value = append(*s.value, option)
+		return nil
 	}
-	return fmt.Errorf("enum value must be one of %s, got '%s'", strings.Join(s.options, ","), value)
+	return errorf(MsgInvalidEnum, strings.Join(s.options, ","), value)
 }
 
 func (e *enumsValue) Get() interface{} {
 	return ([]string)(*e.value)
 }
 
+func (e *enumsValue) storage() interface{} { return e.value }
+
 func (s *enumsValue) String() string {
 	return strings.Join(*s.value, ",")
 }
@@ -419,10 +741,74 @@ func (d *bytesValue) Get() interface{} { return units.Base2Bytes(*d) }
 
 func (d *bytesValue) String() string { return (*units.Base2Bytes)(d).String() }
 
+// -- int64 Value holding a number of bytes, with SI and IEC units
+type byteSizeValue int64
+
+func newByteSizeValue(p *int64) *byteSizeValue {
+	return (*byteSizeValue)(p)
+}
+
+// Multipliers by lower case unit. Single letters are IEC (powers of 1024), as
+// in GNU coreutils.
+var byteSizeUnits = map[string]float64{"": 1, "b": 1}
+
+// Units in the order they are preferred when formatting.
+var byteSizeNames = []string{"EiB", "PiB", "TiB", "GiB", "MiB", "KiB", "EB", "PB", "TB", "GB", "MB", "kB"}
+
+func init() {
+	for i, prefix := range []string{"k", "m", "g", "t", "p", "e"} {
+		iec, si := math.Pow(1024, float64(i+1)), math.Pow(1000, float64(i+1))
+		byteSizeUnits[prefix] = iec
+		byteSizeUnits[prefix+"ib"] = iec
+		byteSizeUnits[prefix+"b"] = si
+	}
+}
+
+func parseByteSize(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	i := len(value)
+	for i > 0 && !(value[i-1] == '.' || (value[i-1] >= '0' && value[i-1] <= '9')) {
+		i--
+	}
+	scale, ok := byteSizeUnits[strings.ToLower(strings.TrimSpace(value[i:]))]
+	if !ok {
+		return 0, errorf(MsgInvalidByteSize, s)
+	}
+	n, err := strconv.ParseFloat(value[:i], 64)
+	if err != nil || n < 0 || n*scale >= math.MaxInt64 {
+		return 0, errorf(MsgInvalidByteSize, s)
+	}
+	return int64(math.Round(n * scale)), nil
+}
+
+// Format n using the largest unit it is a whole multiple of.
+func formatByteSize(n int64) string {
+	for _, unit := range byteSizeNames {
+		scale := int64(byteSizeUnits[strings.ToLower(unit)])
+		if n != 0 && n%scale == 0 {
+			return fmt.Sprintf("%d%s", n/scale, unit)
+		}
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+func (d *byteSizeValue) Set(s string) error {
+	v, err := parseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*d = byteSizeValue(v)
+	return nil
+}
+
+func (d *byteSizeValue) Get() interface{} { return int64(*d) }
+
+func (d *byteSizeValue) String() string { return formatByteSize(int64(*d)) }
+
 func newExistingFileValue(target *string) *fileStatValue {
 	return newFileStatValue(target, func(s os.FileInfo) error {
 		if s.IsDir() {
-			return fmt.Errorf("'%s' is a directory", s.Name())
+			return errorf(MsgPathIsDir, s.Name())
 		}
 		return nil
 	})
@@ -431,7 +817,7 @@ func newExistingFileValue(target *string) *fileStatValue {
 func newExistingDirValue(target *string) *fileStatValue {
 	return newFileStatValue(target, func(s os.FileInfo) error {
 		if !s.IsDir() {
-			return fmt.Errorf("'%s' is a file", s.Name())
+			return errorf(MsgPathIsFile, s.Name())
 		}
 		return nil
 	})
@@ -441,14 +827,149 @@ func newExistingFileOrDirValue(target *string) *fileStatValue {
 	return newFileStatValue(target, func(s os.FileInfo) error { return nil })
 }
 
+// -- access-checked path Value
+
+// A path validated by check when it is set.
+type pathValue struct {
+	path  *string
+	check func(path string) error
+	// Prepares the path once parsing has succeeded, see finalize().
+	prepare func(path string) error
+}
+
+func (p *pathValue) Set(value string) error {
+	if err := p.check(value); err != nil {
+		return err
+	}
+	*p.path = value
+	return nil
+}
+
+func (p *pathValue) Get() interface{} { return *p.path }
+
+func (p *pathValue) storage() interface{} { return p.path }
+
+func (p *pathValue) String() string { return *p.path }
+
+// Prepare path, the value this Value was set to by a successful parse.
+func (p *pathValue) finalize(path string) error {
+	if p.prepare == nil || path == "" {
+		return nil
+	}
+	return p.prepare(path)
+}
+
+func newReadableFileValue(target *string) *pathValue {
+	return &pathValue{path: target, check: func(path string) error {
+		if err := checkIsFile(path); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return errorf(MsgPathNotReadable, path)
+		}
+		return f.Close()
+	}}
+}
+
+func newCreatableFileValue(target *string) *pathValue {
+	return &pathValue{path: target, check: func(path string) error {
+		s, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return checkWritableDir(filepath.Dir(path))
+		} else if err != nil {
+			return err
+		} else if s.IsDir() {
+			return errorf(MsgPathIsDir, path)
+		}
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return errorf(MsgPathNotWritable, path)
+		}
+		return f.Close()
+	}}
+}
+
+func newWritableDirValue(target *string) *pathValue {
+	return &pathValue{path: target, check: checkWritableDir}
+}
+
+// The directory is only created by finalize(), so that completion, help and
+// failed parses have no effect on the file system.
+func newExistingDirOrCreateValue(target *string) *pathValue {
+	check := func(path string) error {
+		s, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return nil
+		} else if err != nil {
+			return err
+		} else if !s.IsDir() {
+			return errorf(MsgPathIsFile, path)
+		}
+		return nil
+	}
+	create := func(path string) error {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			return errorf(MsgCannotCreateDir, path, err)
+		}
+		return nil
+	}
+	return &pathValue{path: target, check: check, prepare: create}
+}
+
+func checkIsFile(path string) error {
+	s, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return errorf(MsgPathNotExist, path)
+	} else if err != nil {
+		return err
+	} else if s.IsDir() {
+		return errorf(MsgPathIsDir, path)
+	}
+	return nil
+}
+
+// Check that path is an existing directory in which files can be created, by
+// creating (and removing) a temporary file in it.
+func checkWritableDir(path string) error {
+	s, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return errorf(MsgPathNotExist, path)
+	} else if err != nil {
+		return err
+	} else if !s.IsDir() {
+		return errorf(MsgPathIsFile, path)
+	}
+	f, err := ioutil.TempFile(path, ".kingpin-")
+	if err != nil {
+		return errorf(MsgPathNotWritable, path)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
 type counterValue int
 
 func newCounterValue(n *int) *counterValue {
 	return (*counterValue)(n)
 }
 
+// Set increments the counter when the flag is given, resets it when negated
+// (eg. --no-verbose) and otherwise accepts an explicit count, such as from an
+// environment variable or default.
 func (c *counterValue) Set(s string) error {
-	*c++
+	switch s {
+	case "", "true":
+		*c++
+	case "false":
+		*c = 0
+	default:
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return errorf(MsgInvalidCount, s)
+		}
+		*c = counterValue(n)
+	}
 	return nil
 }
 
@@ -28,7 +28,9 @@ func (f *boolValue) Set(s string) error {
 
 func (f *boolValue) Get() interface{} { return (bool)(*f.v) }
 
-func (f *boolValue) String() string { return fmt.Sprintf("%v", *f) }
+func (f *boolValue) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *boolValue) storage() interface{} { return f.v }
 
 // Bool parses the next command-line value as bool.
 func (p *parserMixin) Bool() (target *bool) {
@@ -54,6 +56,19 @@ func (p *parserMixin) BoolListVar(target *[]bool) {
 	}))
 }
 
+// OptionalBool parses the next command-line value as bool, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalBool() (target **bool) {
+	target = new(*bool)
+	p.OptionalBoolVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalBoolVar(target **bool) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newBoolValue(v.(*bool))
+	}))
+}
+
 // -- string Value
 type stringValue struct{ v *string }
 
@@ -73,6 +88,8 @@ func (f *stringValue) Get() interface{} { return (string)(*f.v) }
 
 func (f *stringValue) String() string { return string(*f.v) }
 
+func (f *stringValue) storage() interface{} { return f.v }
+
 // String parses the next command-line value as string.
 func (p *parserMixin) String() (target *string) {
 	target = new(string)
@@ -97,6 +114,19 @@ func (p *parserMixin) StringsVar(target *[]string) {
 	}))
 }
 
+// OptionalString parses the next command-line value as string, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalString() (target **string) {
+	target = new(*string)
+	p.OptionalStringVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalStringVar(target **string) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newStringValue(v.(*string))
+	}))
+}
+
 // -- uint Value
 type uintValue struct{ v *uint }
 
@@ -114,7 +144,9 @@ func (f *uintValue) Set(s string) error {
 
 func (f *uintValue) Get() interface{} { return (uint)(*f.v) }
 
-func (f *uintValue) String() string { return fmt.Sprintf("%v", *f) }
+func (f *uintValue) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *uintValue) storage() interface{} { return f.v }
 
 // Uint parses the next command-line value as uint.
 func (p *parserMixin) Uint() (target *uint) {
@@ -140,6 +172,19 @@ func (p *parserMixin) UintsVar(target *[]uint) {
 	}))
 }
 
+// OptionalUint parses the next command-line value as uint, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalUint() (target **uint) {
+	target = new(*uint)
+	p.OptionalUintVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalUintVar(target **uint) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newUintValue(v.(*uint))
+	}))
+}
+
 // -- uint8 Value
 type uint8Value struct{ v *uint8 }
 
@@ -157,7 +202,9 @@ func (f *uint8Value) Set(s string) error {
 
 func (f *uint8Value) Get() interface{} { return (uint8)(*f.v) }
 
-func (f *uint8Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *uint8Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *uint8Value) storage() interface{} { return f.v }
 
 // Uint8 parses the next command-line value as uint8.
 func (p *parserMixin) Uint8() (target *uint8) {
@@ -183,6 +230,19 @@ func (p *parserMixin) Uint8ListVar(target *[]uint8) {
 	}))
 }
 
+// OptionalUint8 parses the next command-line value as uint8, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalUint8() (target **uint8) {
+	target = new(*uint8)
+	p.OptionalUint8Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalUint8Var(target **uint8) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newUint8Value(v.(*uint8))
+	}))
+}
+
 // -- uint16 Value
 type uint16Value struct{ v *uint16 }
 
@@ -200,7 +260,9 @@ func (f *uint16Value) Set(s string) error {
 
 func (f *uint16Value) Get() interface{} { return (uint16)(*f.v) }
 
-func (f *uint16Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *uint16Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *uint16Value) storage() interface{} { return f.v }
 
 // Uint16 parses the next command-line value as uint16.
 func (p *parserMixin) Uint16() (target *uint16) {
@@ -226,6 +288,19 @@ func (p *parserMixin) Uint16ListVar(target *[]uint16) {
 	}))
 }
 
+// OptionalUint16 parses the next command-line value as uint16, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalUint16() (target **uint16) {
+	target = new(*uint16)
+	p.OptionalUint16Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalUint16Var(target **uint16) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newUint16Value(v.(*uint16))
+	}))
+}
+
 // -- uint32 Value
 type uint32Value struct{ v *uint32 }
 
@@ -243,7 +318,9 @@ func (f *uint32Value) Set(s string) error {
 
 func (f *uint32Value) Get() interface{} { return (uint32)(*f.v) }
 
-func (f *uint32Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *uint32Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *uint32Value) storage() interface{} { return f.v }
 
 // Uint32 parses the next command-line value as uint32.
 func (p *parserMixin) Uint32() (target *uint32) {
@@ -269,6 +346,19 @@ func (p *parserMixin) Uint32ListVar(target *[]uint32) {
 	}))
 }
 
+// OptionalUint32 parses the next command-line value as uint32, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalUint32() (target **uint32) {
+	target = new(*uint32)
+	p.OptionalUint32Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalUint32Var(target **uint32) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newUint32Value(v.(*uint32))
+	}))
+}
+
 // -- uint64 Value
 type uint64Value struct{ v *uint64 }
 
@@ -286,7 +376,9 @@ func (f *uint64Value) Set(s string) error {
 
 func (f *uint64Value) Get() interface{} { return (uint64)(*f.v) }
 
-func (f *uint64Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *uint64Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *uint64Value) storage() interface{} { return f.v }
 
 // Uint64 parses the next command-line value as uint64.
 func (p *parserMixin) Uint64() (target *uint64) {
@@ -312,6 +404,19 @@ func (p *parserMixin) Uint64ListVar(target *[]uint64) {
 	}))
 }
 
+// OptionalUint64 parses the next command-line value as uint64, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalUint64() (target **uint64) {
+	target = new(*uint64)
+	p.OptionalUint64Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalUint64Var(target **uint64) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newUint64Value(v.(*uint64))
+	}))
+}
+
 // -- int Value
 type intValue struct{ v *int }
 
@@ -329,7 +434,9 @@ func (f *intValue) Set(s string) error {
 
 func (f *intValue) Get() interface{} { return (int)(*f.v) }
 
-func (f *intValue) String() string { return fmt.Sprintf("%v", *f) }
+func (f *intValue) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *intValue) storage() interface{} { return f.v }
 
 // Int parses the next command-line value as int.
 func (p *parserMixin) Int() (target *int) {
@@ -355,6 +462,19 @@ func (p *parserMixin) IntsVar(target *[]int) {
 	}))
 }
 
+// OptionalInt parses the next command-line value as int, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalInt() (target **int) {
+	target = new(*int)
+	p.OptionalIntVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalIntVar(target **int) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newIntValue(v.(*int))
+	}))
+}
+
 // -- int8 Value
 type int8Value struct{ v *int8 }
 
@@ -372,7 +492,9 @@ func (f *int8Value) Set(s string) error {
 
 func (f *int8Value) Get() interface{} { return (int8)(*f.v) }
 
-func (f *int8Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *int8Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *int8Value) storage() interface{} { return f.v }
 
 // Int8 parses the next command-line value as int8.
 func (p *parserMixin) Int8() (target *int8) {
@@ -398,6 +520,19 @@ func (p *parserMixin) Int8ListVar(target *[]int8) {
 	}))
 }
 
+// OptionalInt8 parses the next command-line value as int8, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalInt8() (target **int8) {
+	target = new(*int8)
+	p.OptionalInt8Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalInt8Var(target **int8) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newInt8Value(v.(*int8))
+	}))
+}
+
 // -- int16 Value
 type int16Value struct{ v *int16 }
 
@@ -415,7 +550,9 @@ func (f *int16Value) Set(s string) error {
 
 func (f *int16Value) Get() interface{} { return (int16)(*f.v) }
 
-func (f *int16Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *int16Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *int16Value) storage() interface{} { return f.v }
 
 // Int16 parses the next command-line value as int16.
 func (p *parserMixin) Int16() (target *int16) {
@@ -441,6 +578,19 @@ func (p *parserMixin) Int16ListVar(target *[]int16) {
 	}))
 }
 
+// OptionalInt16 parses the next command-line value as int16, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalInt16() (target **int16) {
+	target = new(*int16)
+	p.OptionalInt16Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalInt16Var(target **int16) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newInt16Value(v.(*int16))
+	}))
+}
+
 // -- int32 Value
 type int32Value struct{ v *int32 }
 
@@ -458,7 +608,9 @@ func (f *int32Value) Set(s string) error {
 
 func (f *int32Value) Get() interface{} { return (int32)(*f.v) }
 
-func (f *int32Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *int32Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *int32Value) storage() interface{} { return f.v }
 
 // Int32 parses the next command-line value as int32.
 func (p *parserMixin) Int32() (target *int32) {
@@ -484,6 +636,19 @@ func (p *parserMixin) Int32ListVar(target *[]int32) {
 	}))
 }
 
+// OptionalInt32 parses the next command-line value as int32, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalInt32() (target **int32) {
+	target = new(*int32)
+	p.OptionalInt32Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalInt32Var(target **int32) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newInt32Value(v.(*int32))
+	}))
+}
+
 // -- int64 Value
 type int64Value struct{ v *int64 }
 
@@ -501,7 +666,9 @@ func (f *int64Value) Set(s string) error {
 
 func (f *int64Value) Get() interface{} { return (int64)(*f.v) }
 
-func (f *int64Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *int64Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *int64Value) storage() interface{} { return f.v }
 
 // Int64 parses the next command-line value as int64.
 func (p *parserMixin) Int64() (target *int64) {
@@ -527,6 +694,19 @@ func (p *parserMixin) Int64ListVar(target *[]int64) {
 	}))
 }
 
+// OptionalInt64 parses the next command-line value as int64, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalInt64() (target **int64) {
+	target = new(*int64)
+	p.OptionalInt64Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalInt64Var(target **int64) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newInt64Value(v.(*int64))
+	}))
+}
+
 // -- float64 Value
 type float64Value struct{ v *float64 }
 
@@ -544,7 +724,9 @@ func (f *float64Value) Set(s string) error {
 
 func (f *float64Value) Get() interface{} { return (float64)(*f.v) }
 
-func (f *float64Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *float64Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *float64Value) storage() interface{} { return f.v }
 
 // Float64 parses the next command-line value as float64.
 func (p *parserMixin) Float64() (target *float64) {
@@ -570,6 +752,19 @@ func (p *parserMixin) Float64ListVar(target *[]float64) {
 	}))
 }
 
+// OptionalFloat64 parses the next command-line value as float64, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalFloat64() (target **float64) {
+	target = new(*float64)
+	p.OptionalFloat64Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalFloat64Var(target **float64) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newFloat64Value(v.(*float64))
+	}))
+}
+
 // -- float32 Value
 type float32Value struct{ v *float32 }
 
@@ -587,7 +782,9 @@ func (f *float32Value) Set(s string) error {
 
 func (f *float32Value) Get() interface{} { return (float32)(*f.v) }
 
-func (f *float32Value) String() string { return fmt.Sprintf("%v", *f) }
+func (f *float32Value) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *float32Value) storage() interface{} { return f.v }
 
 // Float32 parses the next command-line value as float32.
 func (p *parserMixin) Float32() (target *float32) {
@@ -613,6 +810,19 @@ func (p *parserMixin) Float32ListVar(target *[]float32) {
 	}))
 }
 
+// OptionalFloat32 parses the next command-line value as float32, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalFloat32() (target **float32) {
+	target = new(*float32)
+	p.OptionalFloat32Var(target)
+	return
+}
+
+func (p *parserMixin) OptionalFloat32Var(target **float32) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newFloat32Value(v.(*float32))
+	}))
+}
+
 // DurationList accumulates time.Duration values into a slice.
 func (p *parserMixin) DurationList() (target *[]time.Duration) {
 	target = new([]time.Duration)
@@ -626,6 +836,19 @@ func (p *parserMixin) DurationListVar(target *[]time.Duration) {
 	}))
 }
 
+// OptionalDuration parses the next command-line value as time.Duration, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalDuration() (target **time.Duration) {
+	target = new(*time.Duration)
+	p.OptionalDurationVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalDurationVar(target **time.Duration) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newDurationValue(v.(*time.Duration))
+	}))
+}
+
 // IPList accumulates net.IP values into a slice.
 func (p *parserMixin) IPList() (target *[]net.IP) {
 	target = new([]net.IP)
@@ -665,6 +888,19 @@ func (p *parserMixin) ExistingFilesVar(target *[]string) {
 	}))
 }
 
+// OptionalExistingFile parses the next command-line value as string, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalExistingFile() (target **string) {
+	target = new(*string)
+	p.OptionalExistingFileVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalExistingFileVar(target **string) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newExistingFileValue(v.(*string))
+	}))
+}
+
 // ExistingDirs accumulates string values into a slice.
 func (p *parserMixin) ExistingDirs() (target *[]string) {
 	target = new([]string)
@@ -678,6 +914,19 @@ func (p *parserMixin) ExistingDirsVar(target *[]string) {
 	}))
 }
 
+// OptionalExistingDir parses the next command-line value as string, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalExistingDir() (target **string) {
+	target = new(*string)
+	p.OptionalExistingDirVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalExistingDirVar(target **string) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newExistingDirValue(v.(*string))
+	}))
+}
+
 // ExistingFilesOrDirs accumulates string values into a slice.
 func (p *parserMixin) ExistingFilesOrDirs() (target *[]string) {
 	target = new([]string)
@@ -691,6 +940,19 @@ func (p *parserMixin) ExistingFilesOrDirsVar(target *[]string) {
 	}))
 }
 
+// OptionalExistingFileOrDir parses the next command-line value as string, leaving target nil unless a value is provided.
+func (p *parserMixin) OptionalExistingFileOrDir() (target **string) {
+	target = new(*string)
+	p.OptionalExistingFileOrDirVar(target)
+	return
+}
+
+func (p *parserMixin) OptionalExistingFileOrDirVar(target **string) {
+	p.SetValue(newOptionalValue(target, func(v interface{}) Value {
+		return newExistingFileOrDirValue(v.(*string))
+	}))
+}
+
 // -- *regexp.Regexp Value
 type regexpValue struct{ v **regexp.Regexp }
 
@@ -708,7 +970,9 @@ func (f *regexpValue) Set(s string) error {
 
 func (f *regexpValue) Get() interface{} { return (*regexp.Regexp)(*f.v) }
 
-func (f *regexpValue) String() string { return fmt.Sprintf("%v", *f) }
+func (f *regexpValue) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *regexpValue) storage() interface{} { return f.v }
 
 // Regexp parses the next command-line value as *regexp.Regexp.
 func (p *parserMixin) Regexp() (target **regexp.Regexp) {
@@ -751,7 +1015,9 @@ func (f *resolvedIPValue) Set(s string) error {
 
 func (f *resolvedIPValue) Get() interface{} { return (net.IP)(*f.v) }
 
-func (f *resolvedIPValue) String() string { return fmt.Sprintf("%v", *f) }
+func (f *resolvedIPValue) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *resolvedIPValue) storage() interface{} { return f.v }
 
 // Resolve a hostname or IP to an IP.
 func (p *parserMixin) ResolvedIP() (target *net.IP) {
@@ -794,7 +1060,9 @@ func (f *hexBytesValue) Set(s string) error {
 
 func (f *hexBytesValue) Get() interface{} { return ([]byte)(*f.v) }
 
-func (f *hexBytesValue) String() string { return fmt.Sprintf("%v", *f) }
+func (f *hexBytesValue) String() string { return fmt.Sprintf("%v", *f.v) }
+
+func (f *hexBytesValue) storage() interface{} { return f.v }
 
 // Bytes as a hex string.
 func (p *parserMixin) HexBytes() (target *[]byte) {
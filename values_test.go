@@ -2,10 +2,12 @@ package kingpin
 
 import (
 	"net"
+	"os"
 
 	"github.com/tj/assert"
 
 	"testing"
+	"time"
 )
 
 func TestAccumulatorStrings(t *testing.T) {
@@ -55,6 +57,91 @@ func TestCounter(t *testing.T) {
 	assert.Equal(t, 3, *c)
 }
 
+func TestCounterShortFlags(t *testing.T) {
+	app := newTestApp()
+	v := app.Flag("verbose", "").Short('v').Counter()
+	q := app.Flag("quiet", "").Short('q').Bool()
+	_, err := app.Parse([]string{"-vvqv", "--verbose"})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *v)
+	assert.True(t, *q)
+
+	_, err = app.Parse([]string{"-vv", "--no-verbose", "-v"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *v)
+}
+
+func TestCounterExplicitCount(t *testing.T) {
+	app := newTestApp()
+	v := app.Flag("verbose", "").Short('v').Envar("TEST_VERBOSE").Counter()
+	os.Setenv("TEST_VERBOSE", "3")
+	defer os.Unsetenv("TEST_VERBOSE")
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *v)
+
+	os.Setenv("TEST_VERBOSE", "lots")
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "invalid count 'lots'")
+}
+
+func TestExtendedDuration(t *testing.T) {
+	app := newTestApp()
+	retention := app.Flag("retention", "").Default("2w").ExtendedDuration()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 14*24*time.Hour, *retention)
+
+	_, err = app.Parse([]string{"--retention=1y2d12h"})
+	assert.NoError(t, err)
+	assert.Equal(t, (367*24+12)*time.Hour, *retention)
+
+	_, err = app.Parse([]string{"--retention=1fortnight"})
+	assert.EqualError(t, err, "invalid duration '1fortnight'")
+
+	for d, s := range map[time.Duration]string{
+		365 * 24 * time.Hour: "1y",
+		72 * time.Hour:       "3d",
+		90 * time.Minute:     "1h30m0s",
+	} {
+		assert.Equal(t, s, newExtendedDurationValue(&d).String())
+	}
+	assert.Contains(t, app.GetFlag("retention").resolveCompletions(), "30d")
+}
+
+func TestByteSize(t *testing.T) {
+	for in, out := range map[string]int64{
+		"512":    512,
+		"512B":   512,
+		"10K":    10 << 10,
+		"10kb":   10000,
+		"1.5GiB": 3 << 29,
+		"2GB":    2000000000,
+		"1 MiB":  1 << 20,
+	} {
+		n, err := parseByteSize(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, out, n, in)
+	}
+	for _, in := range []string{"", "K", "-1K", "10 parsecs", "100EiB"} {
+		_, err := parseByteSize(in)
+		assert.Error(t, err, in)
+	}
+
+	app := newTestApp()
+	size := app.Flag("size", "").Default("1073741824").ByteSize()
+	_, err := app.Parse([]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1<<30), *size)
+	assert.Equal(t, "1GiB", app.GetFlag("size").Model().FormatPlaceHolder())
+	assert.Equal(t, "1GiB", app.GetFlag("size").value.String())
+
+	_, err = app.Parse([]string{"--size=1.5MB"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1500000), *size)
+	assert.Equal(t, "1500kB", app.GetFlag("size").value.String())
+}
+
 func TestIPv4Addr(t *testing.T) {
 	app := newTestApp()
 	flag := app.Flag("addr", "").ResolvedIP()
@@ -96,3 +183,70 @@ func TestSetValueDoesNotReset(t *testing.T) {
 	app.Flag("set", "").StringMapVar(&mapping)
 	assert.NotEmpty(t, mapping)
 }
+
+func TestOptionalBool(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		expected interface{}
+	}{
+		{[]string{}, nil},
+		{[]string{"--flag"}, true},
+		{[]string{"--no-flag"}, false},
+	} {
+		app := newTestApp()
+		flag := app.Flag("flag", "").OptionalBool()
+		_, err := app.Parse(test.args)
+		assert.NoError(t, err)
+		if test.expected == nil {
+			assert.Nil(t, *flag)
+		} else {
+			assert.Equal(t, test.expected, **flag)
+		}
+	}
+}
+
+func TestOptionalValues(t *testing.T) {
+	app := newTestApp()
+	port := app.Flag("port", "").OptionalInt()
+	timeout := app.Flag("timeout", "").OptionalDuration()
+	name := app.Flag("name", "").Envar("TEST_OPTIONAL_NAME").OptionalString()
+	t.Setenv("TEST_OPTIONAL_NAME", "alec")
+
+	_, err := app.Parse([]string{"--timeout=5s"})
+	assert.NoError(t, err)
+	assert.Nil(t, *port)
+	assert.Equal(t, 5*time.Second, **timeout)
+	assert.Equal(t, "alec", **name)
+
+	_, err = app.Parse([]string{"--port=0"})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, **port)
+}
+
+func TestOptionalValueRejectsDefault(t *testing.T) {
+	app := newTestApp()
+	app.Flag("port", "").Default("80").OptionalInt()
+	_, err := app.Parse([]string{})
+	assert.EqualError(t, err, "optional flag '--port' with default value that would never leave it nil")
+
+	app = newTestApp()
+	app.Arg("name", "").Default("alec").OptionalString()
+	_, err = app.Parse([]string{})
+	assert.EqualError(t, err, "optional argument 'name' with default value that would never leave it nil")
+}
+
+func TestEnumCaseInsensitive(t *testing.T) {
+	app := newTestApp()
+	format := app.Flag("format", "").CaseInsensitive().Enum("json", "yaml")
+	tags := app.Flag("tag", "").CaseInsensitive().Enums("Alpha", "Beta")
+	strict := app.Flag("strict", "").Enum("json", "yaml")
+
+	_, err := app.Parse([]string{"--format=Json", "--tag=ALPHA", "--tag=beta"})
+	assert.NoError(t, err)
+	assert.Equal(t, "json", *format)
+	assert.Equal(t, []string{"Alpha", "Beta"}, *tags)
+
+	_, err = app.Parse([]string{"--strict=JSON"})
+	assert.EqualError(t, err, "enum value must be one of json,yaml, got 'JSON'")
+	assert.Equal(t, "", *strict)
+}
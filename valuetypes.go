@@ -0,0 +1,169 @@
+package kingpin
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/units"
+)
+
+// ValueFactory constructs a new Value of a named type. See
+// RegisterValueType().
+type ValueFactory func() Value
+
+var (
+	valueTypesLock sync.RWMutex
+	valueTypes     = map[string]ValueFactory{
+		"string":   func() Value { return newStringValue(new(string)) },
+		"bool":     func() Value { return newBoolValue(new(bool)) },
+		"int":      func() Value { return newIntValue(new(int)) },
+		"int8":     func() Value { return newInt8Value(new(int8)) },
+		"int16":    func() Value { return newInt16Value(new(int16)) },
+		"int32":    func() Value { return newInt32Value(new(int32)) },
+		"int64":    func() Value { return newInt64Value(new(int64)) },
+		"uint":     func() Value { return newUintValue(new(uint)) },
+		"uint8":    func() Value { return newUint8Value(new(uint8)) },
+		"uint16":   func() Value { return newUint16Value(new(uint16)) },
+		"uint32":   func() Value { return newUint32Value(new(uint32)) },
+		"uint64":   func() Value { return newUint64Value(new(uint64)) },
+		"float32":  func() Value { return newFloat32Value(new(float32)) },
+		"float64":  func() Value { return newFloat64Value(new(float64)) },
+		"duration": func() Value { return newDurationValue(new(time.Duration)) },
+		"bytes":    func() Value { return newBytesValue(new(units.Base2Bytes)) },
+		"ip":       func() Value { return newIPValue(new(net.IP)) },
+		"tcp":      func() Value { return newTCPAddrValue(new(*net.TCPAddr)) },
+		"cidr":     func() Value { return newCIDRValue(new(*net.IPNet)) },
+		"hostport": func() Value { return newHostPortValue(new(string)) },
+		"url":      func() Value { return newURLValue(new(*url.URL)) },
+		"regexp":   func() Value { return newRegexpValue(new(*regexp.Regexp)) },
+		"hexbytes": func() Value { return newHexBytesValue(new([]byte)) },
+	}
+	// Names of user registered types, by the type of Value they construct.
+	valueTypeNames = map[reflect.Type]string{}
+)
+
+// RegisterValueType registers a named value type, eg.
+//
+//     kingpin.RegisterValueType("ipnet", func() kingpin.Value { return &ipNetValue{} })
+//
+// Registered types can be selected by name with Type() or the type= key of
+// Struct() tags, and are reported by name in the JSON description of the
+// application (see __describe). Config file values are checked with a new
+// Value of the flag's type.
+// Registering an existing name replaces it.
+func RegisterValueType(name string, factory ValueFactory) {
+	valueTypesLock.Lock()
+	defer valueTypesLock.Unlock()
+	valueTypes[name] = factory
+	valueTypeNames[reflect.TypeOf(factory())] = name
+}
+
+// ValueTypes returns the names of all registered value types, including the
+// built-in ones.
+func ValueTypes() []string {
+	valueTypesLock.RLock()
+	defer valueTypesLock.RUnlock()
+	names := make([]string, 0, len(valueTypes))
+	for name := range valueTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Type sets the parser to a new value of the type registered under name with
+// RegisterValueType(). The value is returned so that it can be retrieved
+// after parsing, eg. with Getter.Get().
+func (p *parserMixin) Type(name string) Value {
+	valueTypesLock.RLock()
+	factory, ok := valueTypes[name]
+	valueTypesLock.RUnlock()
+	if !ok {
+		p.unknownType = name
+		return nil
+	}
+	value := factory()
+	p.SetValue(value)
+	return value
+}
+
+// The registered name of a user-defined value type, if any.
+func registeredValueTypeName(value Value) (string, bool) {
+	valueTypesLock.RLock()
+	defer valueTypesLock.RUnlock()
+	name, ok := valueTypeNames[reflect.TypeOf(value)]
+	return name, ok
+}
+
+// The factory registered under name, if any.
+func valueTypeFactory(name string) (ValueFactory, bool) {
+	valueTypesLock.RLock()
+	defer valueTypesLock.RUnlock()
+	factory, ok := valueTypes[name]
+	return factory, ok
+}
+
+// The factory of the built-in type that holds values of typ: the type named
+// after typ, such as "string" rather than "hostport", or the only one. Types
+// registered with RegisterValueType() must be selected by name.
+func builtinValueTypeFor(typ reflect.Type) (ValueFactory, bool) {
+	valueTypesLock.RLock()
+	defer valueTypesLock.RUnlock()
+	user := map[string]bool{}
+	for _, name := range valueTypeNames {
+		user[name] = true
+	}
+	candidates := []ValueFactory{}
+	for name, factory := range valueTypes {
+		if user[name] || heldType(factory()) != typ {
+			continue
+		}
+		if name == typ.String() {
+			return factory, true
+		}
+		candidates = append(candidates, factory)
+	}
+	if len(candidates) != 1 {
+		return nil, false
+	}
+	return candidates[0], true
+}
+
+// The factory of the registered type that value was constructed by, if any.
+func valueFactoryOf(value Value) (ValueFactory, bool) {
+	if field, ok := value.(*fieldValue); ok {
+		return field.factory, true
+	}
+	valueTypesLock.RLock()
+	defer valueTypesLock.RUnlock()
+	for _, factory := range valueTypes {
+		if reflect.TypeOf(factory()) == reflect.TypeOf(value) {
+			return factory, true
+		}
+	}
+	return nil, false
+}
+
+// The type of the variable value writes to, eg. time.Duration for
+// Duration(), or nil if it isn't known.
+func heldType(value Value) reflect.Type {
+	if s, ok := value.(storageValue); ok {
+		return reflect.TypeOf(s.storage()).Elem()
+	}
+	if g, ok := value.(Getter); ok {
+		if v := g.Get(); v != nil {
+			return reflect.TypeOf(v)
+		}
+	}
+	return nil
+}
+
+func unknownTypeError(name, clause string) error {
+	return fmt.Errorf("unknown value type %q for %s, expected one of %v", name, clause, ValueTypes())
+}
@@ -0,0 +1,44 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+type ipNetValue struct{ net *net.IPNet }
+
+func (i *ipNetValue) Set(value string) (err error) {
+	_, i.net, err = net.ParseCIDR(value)
+	return err
+}
+
+func (i *ipNetValue) String() string   { return i.net.String() }
+func (i *ipNetValue) Get() interface{} { return i.net }
+
+func TestRegisterValueType(t *testing.T) {
+	RegisterValueType("ipnet", func() Value { return &ipNetValue{} })
+	assert.Contains(t, ValueTypes(), "ipnet")
+
+	app := newTestApp()
+	network := app.Flag("network", "").Type("ipnet")
+	timeout := app.Flag("timeout", "").Type("duration")
+	_, err := app.Parse([]string{"--network=10.0.0.0/8", "--timeout=1m"})
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/8", network.String())
+	assert.Equal(t, "1m0s", timeout.String())
+
+	data, err := json.Marshal(app.GetFlag("network").Model())
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"type":"ipnet"`)
+}
+
+func TestUnknownValueType(t *testing.T) {
+	app := newTestApp()
+	assert.Nil(t, app.Flag("network", "").Type("nope"))
+	_, err := app.Parse([]string{})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown value type "nope" for --network`)
+}
@@ -0,0 +1,98 @@
+package kingpin
+
+import (
+	"fmt"
+)
+
+// Verbosity is the output level selected with the flags added by
+// VerbosityFlags().
+type Verbosity int
+
+// Output levels.
+const (
+	VerbosityQuiet   Verbosity = -1
+	VerbosityNormal  Verbosity = 0
+	VerbosityVerbose Verbosity = 1
+	VerbosityDebug   Verbosity = 2
+)
+
+// VerbosityFlags adds --quiet (-q) and --verbose (-v) flags controlling the
+// output of ParseContext.Output() and of kingpin's own warnings. --verbose may
+// be repeated to increase verbosity further.
+func (a *Application) VerbosityFlags() *Application {
+	a.QuietFlag = a.Flag("quiet", "Only output errors.").Short('q')
+	a.QuietFlag.BoolVar(&a.quiet)
+	a.VerboseFlag = a.Flag("verbose", "Increase output verbosity. May be repeated.").Short('v')
+	a.VerboseFlag.CounterVar(&a.verbose)
+	return a
+}
+
+func (a *Application) verbosity() Verbosity {
+	if a.quiet {
+		return VerbosityQuiet
+	}
+	return Verbosity(a.verbose)
+}
+
+// Write a warning to the error writer, unless --quiet was given.
+func (a *Application) warnf(format string, args ...interface{}) {
+	if a.verbosity() > VerbosityQuiet {
+		fmt.Fprintf(a.errorWriter, "%s: warning: %s\n", a.Name, fmt.Sprintf(format, args...))
+	}
+}
+
+// Output provides leveled printing that respects --quiet and --verbose.
+//
+// Normal output goes to the application's output writer, while verbose,
+// debug and warning output go to its error writer.
+type Output struct {
+	app *Application
+}
+
+// Output returns leveled print functions for use by actions.
+func (p *ParseContext) Output() *Output {
+	return &Output{app: p.app}
+}
+
+// Level returns the selected verbosity.
+func (o *Output) Level() Verbosity {
+	return o.app.verbosity()
+}
+
+// Printf writes normal output, suppressed by --quiet.
+func (o *Output) Printf(format string, args ...interface{}) {
+	if o.Level() >= VerbosityNormal {
+		fmt.Fprintf(o.app.outputWriter, format, args...)
+	}
+}
+
+// Println writes a line of normal output, suppressed by --quiet.
+func (o *Output) Println(args ...interface{}) {
+	if o.Level() >= VerbosityNormal {
+		fmt.Fprintln(o.app.outputWriter, args...)
+	}
+}
+
+// Verbosef writes output only shown with --verbose.
+func (o *Output) Verbosef(format string, args ...interface{}) {
+	if o.Level() >= VerbosityVerbose {
+		fmt.Fprintf(o.app.errorWriter, format, args...)
+	}
+}
+
+// Debugf writes output only shown with --verbose repeated twice or more.
+func (o *Output) Debugf(format string, args ...interface{}) {
+	if o.Level() >= VerbosityDebug {
+		fmt.Fprintf(o.app.errorWriter, format, args...)
+	}
+}
+
+// Warnf writes a warning, suppressed by --quiet.
+func (o *Output) Warnf(format string, args ...interface{}) {
+	o.app.warnf(format, args...)
+}
+
+// Errorf writes an error. Errors are never suppressed.
+func (o *Output) Errorf(format string, args ...interface{}) {
+	o.app.Errorf(format, args...)
+}
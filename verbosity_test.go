@@ -0,0 +1,62 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestVerbosityFlags(t *testing.T) {
+	for _, test := range []struct {
+		args   []string
+		stdout string
+		stderr string
+	}{
+		{nil, "normal\n", "test: warning: careful\n"},
+		{[]string{"-q"}, "", ""},
+		{[]string{"-v"}, "normal\n", "verbose\ntest: warning: careful\n"},
+		{[]string{"-vv"}, "normal\n", "verbose\ndebug\ntest: warning: careful\n"},
+	} {
+		stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+		app := newTestApp().VerbosityFlags().OutputWriter(stdout).ErrorWriter(stderr)
+		app.Action(func(ctx *ParseContext) error {
+			out := ctx.Output()
+			out.Println("normal")
+			out.Verbosef("verbose\n")
+			out.Debugf("debug\n")
+			out.Warnf("careful")
+			return nil
+		})
+		_, err := app.Parse(test.args)
+		assert.NoError(t, err)
+		assert.Equal(t, test.stdout, stdout.String(), "%v", test.args)
+		assert.Equal(t, test.stderr, stderr.String(), "%v", test.args)
+	}
+}
+
+func TestDeprecatedWarnings(t *testing.T) {
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	app := newTestApp().VerbosityFlags().OutputWriter(stdout).ErrorWriter(stderr).UsageWriter(stdout)
+	app.Flag("colour", "").Deprecated("use --color instead").Bool()
+	app.Flag("color", "").Bool()
+	get := app.Command("get", "").Deprecated("use fetch instead")
+	get.Arg("url", "").Required().String()
+	get.Arg("host", "").Deprecated("use a full URL instead").String()
+
+	_, err := app.Parse([]string{"get", "--colour", "http://x", "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "test: warning: command 'get' is deprecated, use fetch instead\n"+
+		"test: warning: flag --colour is deprecated, use --color instead\n"+
+		"test: warning: argument 'host' is deprecated, use a full URL instead\n", stderr.String())
+
+	stderr.Reset()
+	_, err = app.Parse([]string{"-q", "get", "--colour", "http://x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", stderr.String())
+
+	app.Usage([]string{"get"})
+	assert.NotContains(t, stdout.String(), "colour")
+	assert.NotContains(t, stdout.String(), "host")
+	assert.Contains(t, stdout.String(), "<url>")
+}
@@ -0,0 +1,81 @@
+package kingpin
+
+import (
+	"encoding/json"
+	"io"
+	"runtime"
+	"text/template"
+)
+
+// VersionInfo describes the build of an application, as shown by the
+// --version flag and the "version" command.
+type VersionInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	// Build date, in whatever form the build supplies it.
+	Date string `json:"date,omitempty"`
+	// Defaults to the version of Go the application was built with.
+	GoVersion string `json:"goVersion,omitempty"`
+}
+
+// VersionInfo adds a --version flag like Version(), and a "version" command
+// that shows info, optionally as JSON with "version --json". The values are
+// typically supplied at build time, eg.
+//
+//     var commit, date string // Set with -ldflags "-X main.commit=..."
+//
+//     app.VersionInfo(kingpin.VersionInfo{Version: "1.2.0", Commit: commit, Date: date})
+//
+// The command is only added to applications with commands, and not if a
+// "version" command has already been defined.
+func (a *Application) VersionInfo(info VersionInfo) *Application {
+	if info.GoVersion == "" {
+		info.GoVersion = runtime.Version()
+	}
+	a.versionInfo = &info
+	return a.Version(info.Version)
+}
+
+// VersionTemplate sets the text/template used to show the version. It is
+// executed with a VersionInfo. Defaults to DefaultVersionTemplate.
+func (a *Application) VersionTemplate(template string) *Application {
+	a.versionTmpl = template
+	return a
+}
+
+// Add the "version" command if VersionInfo() was given.
+func (a *Application) addVersionCommand() {
+	if a.versionInfo == nil || !a.cmdGroup.have() || a.GetCommand("version") != nil {
+		return
+	}
+	var asJSON bool
+	a.VersionCommand = a.Command("version", "Show version information.").PreAction(func(*ParseContext) error {
+		if err := a.writeVersion(a.usageWriter, asJSON); err != nil {
+			return err
+		}
+		a.terminate(0)
+		return nil
+	})
+	a.VersionCommand.Flag("json", "Output as JSON.").BoolVar(&asJSON)
+}
+
+func (a *Application) writeVersion(w io.Writer, asJSON bool) error {
+	info := VersionInfo{Version: a.version}
+	if a.versionInfo != nil {
+		info = *a.versionInfo
+	}
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	text := a.versionTmpl
+	if text == "" {
+		text = DefaultVersionTemplate
+	}
+	t, err := template.New("version").Parse(text)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, info)
+}
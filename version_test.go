@@ -0,0 +1,68 @@
+package kingpin
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tj/assert"
+)
+
+func TestVersionFlag(t *testing.T) {
+	var buf bytes.Buffer
+	app := newTestApp().Version("1.2.3").UsageWriter(&buf)
+	_, err := app.Parse([]string{"--version"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3\n", buf.String())
+}
+
+func TestVersionInfo(t *testing.T) {
+	var buf bytes.Buffer
+	app := newTestApp().UsageWriter(&buf).VersionInfo(VersionInfo{
+		Version:   "1.2.3",
+		Commit:    "abc123",
+		Date:      "2024-01-02",
+		GoVersion: "go1.21",
+	})
+	app.Command("run", "")
+
+	_, err := app.Parse([]string{"--version"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3\ncommit: abc123\nbuilt: 2024-01-02\ngo: go1.21\n", buf.String())
+
+	buf.Reset()
+	selected, err := app.Parse([]string{"version"})
+	assert.NoError(t, err)
+	assert.Equal(t, "version", selected)
+	assert.Equal(t, "1.2.3\ncommit: abc123\nbuilt: 2024-01-02\ngo: go1.21\n", buf.String())
+
+	buf.Reset()
+	_, err = app.Parse([]string{"version", "--json"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{
+  "version": "1.2.3",
+  "commit": "abc123",
+  "date": "2024-01-02",
+  "goVersion": "go1.21"
+}
+`, buf.String())
+}
+
+func TestVersionTemplate(t *testing.T) {
+	var buf bytes.Buffer
+	app := newTestApp().UsageWriter(&buf).
+		VersionInfo(VersionInfo{Version: "1.2.3", Commit: "abc123"}).
+		VersionTemplate("{{.Version}} ({{.Commit}})\n")
+	_, err := app.Parse([]string{"--version"})
+	assert.NoError(t, err)
+	assert.Equal(t, "1.2.3 (abc123)\n", buf.String())
+	assert.Nil(t, app.VersionCommand)
+}
+
+func TestVersionCommandNotReplaced(t *testing.T) {
+	app := newTestApp().VersionInfo(VersionInfo{Version: "1.2.3"})
+	version := app.Command("version", "")
+	_, err := app.Parse([]string{"version"})
+	assert.NoError(t, err)
+	assert.Equal(t, version, app.GetCommand("version"))
+	assert.Nil(t, app.VersionCommand)
+}